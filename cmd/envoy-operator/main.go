@@ -2,10 +2,15 @@ package main
 
 import (
 	"context"
+	"net/http"
+	"os"
 	"runtime"
 
 	sdk "github.com/operator-framework/operator-sdk/pkg/sdk"
 	sdkVersion "github.com/operator-framework/operator-sdk/version"
+	"github.com/solo-io/envoy-operator/pkg/envoy"
+	"github.com/solo-io/envoy-operator/pkg/inject"
+	"github.com/solo-io/envoy-operator/pkg/metrics"
 	stub "github.com/solo-io/envoy-operator/pkg/stub"
 
 	"flag"
@@ -23,10 +28,49 @@ func printVersion() {
 func main() {
 	namespace := flag.String("n", "default", "the namespace in which to monitor Envoy CRDs and manage "+
 		"resources")
+	webhookAddr := flag.String("webhook-addr", ":8443", "the address the sidecar injection webhook listens on")
+	webhookCert := flag.String("webhook-cert", "/etc/webhook/certs/tls.crt", "the TLS certificate of the "+
+		"sidecar injection webhook. The webhook is disabled if it doesn't exist")
+	webhookKey := flag.String("webhook-key", "/etc/webhook/certs/tls.key", "the TLS key of the sidecar "+
+		"injection webhook")
+	metricsAddr := flag.String("metrics-addr", ":8383", "the address the operator serves its prometheus metrics on; "+
+		"empty to disable them")
 	flag.Parse()
 	printVersion()
 	log.Printf("Envoy Operator: using namespace %s", *namespace)
+
+	clients, err := stub.NewClients()
+	if err != nil {
+		log.Fatalf("failed to create the kubernetes clients: %v", err)
+	}
+	envoy.SetClients(clients)
+
+	ctx := context.TODO()
+	registry := inject.NewRegistry()
+	if _, err := os.Stat(*webhookCert); err == nil {
+		go func() {
+			log.Printf("Envoy Operator: serving sidecar injection webhook on %s", *webhookAddr)
+			err := inject.ListenAndServeTLS(ctx, *webhookAddr, *webhookCert, *webhookKey, inject.NewWebhook(registry))
+			if err != nil {
+				log.Fatalf("sidecar injection webhook failed: %v", err)
+			}
+		}()
+	} else {
+		log.Printf("Envoy Operator: sidecar injection webhook disabled: %v", err)
+	}
+
+	if *metricsAddr != "" {
+		go func() {
+			log.Printf("Envoy Operator: serving metrics on %s", *metricsAddr)
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", metrics.Handler())
+			if err := http.ListenAndServe(*metricsAddr, mux); err != nil {
+				log.Fatalf("metrics server failed: %v", err)
+			}
+		}()
+	}
+
 	sdk.Watch("envoy.solo.io/v1alpha1", "Envoy", *namespace, 5)
-	sdk.Handle(stub.NewHandler())
-	sdk.Run(context.TODO())
+	sdk.Handle(stub.NewHandler(registry))
+	sdk.Run(ctx)
 }
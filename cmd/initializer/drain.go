@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// drain tells envoy to drain through its admin endpoint, and waits for it to. It runs as the
+// preStop hook of the envoy container, so failing to reach envoy is logged but doesn't stop the
+// wait.
+func drain(args []string) {
+	fs := flag.NewFlagSet("drain", flag.ExitOnError)
+	adminAddress := fs.String("admin-address", "127.0.0.1", "the address envoy's admin endpoint is reached at")
+	adminPort := fs.Int("admin-port", 0, "the port of envoy's admin endpoint; if 0, only wait")
+	path := fs.String("path", "/drain_listeners?graceful", "the admin path that starts draining")
+	wait := fs.Duration("wait", 15*time.Second, "how long to wait for envoy to drain")
+	fs.Parse(args)
+
+	if *adminPort != 0 {
+		url := fmt.Sprintf("http://%s%s", net.JoinHostPort(*adminAddress, strconv.Itoa(*adminPort)), *path)
+		client := http.Client{Timeout: 5 * time.Second}
+		resp, err := client.Post(url, "text/plain", nil)
+		if err != nil {
+			log.Printf("failed to drain envoy: %v", err)
+		} else {
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				log.Printf("failed to drain envoy: %s returned %s", *path, resp.Status)
+			}
+		}
+	}
+	time.Sleep(*wait)
+}
+
+// install copies the initializer to path, so that other containers of the pod can run it
+func install(path string) error {
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(self)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
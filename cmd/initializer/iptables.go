@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/solo-io/envoy-operator/pkg/iptables"
+)
+
+// setupIptables installs the rules that redirect the pod's traffic to envoy
+func setupIptables(args []string) {
+	fs := flag.NewFlagSet("iptables", flag.ExitOnError)
+	mode := fs.String("mode", iptables.ModeRedirect, "how inbound traffic is intercepted: REDIRECT or TPROXY")
+	outboundPort := fs.Uint("outbound-port", 15001, "the port envoy listens on for outbound traffic")
+	inboundPort := fs.Uint("inbound-port", 15006, "the port envoy listens on for inbound traffic")
+	proxyUID := fs.Int64("proxy-uid", 1337, "the uid envoy runs as")
+	includeOutboundCIDRs := fs.String("include-outbound-cidrs", "", "comma separated cidrs to intercept; all if empty")
+	excludeOutboundCIDRs := fs.String("exclude-outbound-cidrs", "", "comma separated cidrs not to intercept")
+	excludeOutboundPorts := fs.String("exclude-outbound-ports", "", "comma separated outbound ports not to intercept")
+	includeInboundPorts := fs.String("include-inbound-ports", "", "comma separated inbound ports to intercept; all if empty")
+	excludeInboundPorts := fs.String("exclude-inbound-ports", "", "comma separated inbound ports not to intercept")
+	excludeUIDs := fs.String("exclude-uids", "", "comma separated uids whose traffic is not intercepted")
+	dryRun := fs.Bool("dry-run", false, "print the rules instead of installing them")
+	fs.Parse(args)
+
+	cfg := iptables.Config{
+		Mode:                 *mode,
+		OutboundPort:         uint32(*outboundPort),
+		InboundPort:          uint32(*inboundPort),
+		ProxyUID:             *proxyUID,
+		IncludeOutboundCIDRs: splitList(*includeOutboundCIDRs),
+		ExcludeOutboundCIDRs: splitList(*excludeOutboundCIDRs),
+		ExcludeOutboundPorts: parsePorts(*excludeOutboundPorts),
+		IncludeInboundPorts:  parsePorts(*includeInboundPorts),
+		ExcludeInboundPorts:  parsePorts(*excludeInboundPorts),
+		ExcludeUIDs:          parseUIDs(*excludeUIDs),
+	}
+	rules, err := iptables.Generate(cfg)
+	if err != nil {
+		log.Fatalf("iptables setup failed: %v", err)
+	}
+
+	if *dryRun {
+		fmt.Print(rules.Restore)
+		for _, c := range rules.Commands {
+			fmt.Println(strings.Join(c, " "))
+		}
+		return
+	}
+
+	restore := exec.Command("iptables-restore", "--noflush")
+	restore.Stdin = strings.NewReader(rules.Restore)
+	restore.Stdout = os.Stdout
+	restore.Stderr = os.Stderr
+	if err := restore.Run(); err != nil {
+		log.Fatalf("iptables setup failed: iptables-restore: %v\n%s", err, rules.Restore)
+	}
+	for _, c := range rules.Commands {
+		cmd := exec.Command(c[0], c[1:]...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			log.Fatalf("iptables setup failed: %s: %v", strings.Join(c, " "), err)
+		}
+	}
+}
+
+func splitList(s string) []string {
+	var ret []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			ret = append(ret, item)
+		}
+	}
+	return ret
+}
+
+func parsePorts(s string) []uint32 {
+	var ret []uint32
+	for _, item := range splitList(s) {
+		port, err := strconv.ParseUint(item, 10, 16)
+		if err != nil {
+			log.Fatalf("invalid port %q: %v", item, err)
+		}
+		ret = append(ret, uint32(port))
+	}
+	return ret
+}
+
+func parseUIDs(s string) []int64 {
+	var ret []int64
+	for _, item := range splitList(s) {
+		uid, err := strconv.ParseInt(item, 10, 64)
+		if err != nil {
+			log.Fatalf("invalid uid %q: %v", item, err)
+		}
+		ret = append(ret, uid)
+	}
+	return ret
+}
@@ -3,17 +3,41 @@ package main
 import (
 	"flag"
 	"log"
+	"os"
 
 	"github.com/solo-io/envoy-operator/pkg/downward"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "iptables":
+			setupIptables(os.Args[2:])
+			return
+		case "drain":
+			drain(os.Args[2:])
+			return
+		case "spiffe":
+			requestIdentity(os.Args[2:])
+			return
+		case "ready":
+			ready(os.Args[2:])
+			return
+		}
+	}
+
 	inputfile := flag.String("input", "", "input file")
 	outfile := flag.String("output", "", "output file")
+	installPath := flag.String("install", "", "copy the initializer to this path, for the drain hook and the readiness probe")
 	flag.Parse()
 	transformer := downward.NewTransformer()
 	err := transformer.TransformFiles(*inputfile, *outfile)
 	if err != nil {
 		log.Fatalf("initializer failed: %v", err)
 	}
+	if *installPath != "" {
+		if err := install(*installPath); err != nil {
+			log.Fatalf("failed to install the initializer: %v", err)
+		}
+	}
 }
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+)
+
+// ready runs as the readiness probe of envoys with their own spiffe certificate: the certificate
+// isn't renewed, so the pod turns unready once it expires, and otherwise once envoy does.
+func ready(args []string) {
+	fs := flag.NewFlagSet("ready", flag.ExitOnError)
+	certFile := fs.String("cert", "", "the pem certificate that has to be valid")
+	url := fs.String("url", "", "envoy's ready url")
+	fs.Parse(args)
+
+	if *certFile != "" {
+		if err := checkExpiry(*certFile, time.Now()); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *url != "" {
+		client := http.Client{Timeout: 5 * time.Second}
+		resp, err := client.Get(*url)
+		if err != nil {
+			log.Fatalf("envoy isn't ready: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			log.Fatalf("envoy isn't ready: %s", resp.Status)
+		}
+	}
+}
+
+// checkExpiry returns an error if the first certificate of the file isn't valid at now
+func checkExpiry(certFile string, now time.Time) error {
+	data, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		return err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return fmt.Errorf("%s has no pem certificate", certFile)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return err
+	}
+	if now.After(cert.NotAfter) {
+		return fmt.Errorf("the certificate %s expired at %s", certFile, cert.NotAfter.Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/solo-io/envoy-operator/pkg/spiffe"
+	"k8s.io/client-go/rest"
+)
+
+// requestIdentity gets the pod its own client certificate, for its SPIFFE ID, and writes it with
+// its key to the directory the bootstrap config reads them from. It runs as an init container of
+// the envoy pods, which fail to start until the certificate is issued.
+func requestIdentity(args []string) {
+	fs := flag.NewFlagSet("spiffe", flag.ExitOnError)
+	trustDomain := fs.String("trust-domain", spiffe.DefaultTrustDomain, "the trust domain of the spiffe id")
+	namespace := fs.String("namespace", os.Getenv("POD_NAMESPACE"), "the namespace of the pod")
+	serviceAccount := fs.String("service-account", os.Getenv("POD_SVCACCNT"), "the service account of the pod")
+	pod := fs.String("pod", os.Getenv("POD_NAME"), "the name of the pod")
+	podUID := fs.String("pod-uid", os.Getenv("POD_UID"), "the uid of the pod")
+	signerName := fs.String("signer-name", "", "have the certificate signed by this signer of the kubernetes CSR API")
+	caEndpoint := fs.String("ca-endpoint", "", "have the certificate signed by the CA at this url")
+	caFile := fs.String("ca-file", "", "the ca certs the ca endpoint is verified with, instead of the system's")
+	tokenFile := fs.String("token-file", "", "the token the ca endpoint authenticates the pod with, instead of one the api server issues for the ca endpoint")
+	duration := fs.Duration("duration", 0, "how long the certificate is valid; 0 for the signer's default")
+	timeout := fs.Duration("timeout", 5*time.Minute, "how long to wait for the certificate")
+	outDir := fs.String("out-dir", "", "the directory the certificate and key are written to")
+	fs.Parse(args)
+
+	if *outDir == "" {
+		log.Fatalf("spiffe needs an -out-dir")
+	}
+	id, err := spiffe.ID(*trustDomain, *namespace, *serviceAccount, *pod)
+	if err != nil {
+		log.Fatalf("spiffe failed: %v", err)
+	}
+
+	var signer spiffe.Signer
+	switch {
+	case *signerName != "" && *caEndpoint != "":
+		log.Fatalf("set either -signer-name or -ca-endpoint")
+	case *signerName != "":
+		cfg, err := rest.InClusterConfig()
+		if err != nil {
+			log.Fatalf("spiffe failed: %v", err)
+		}
+		client, err := spiffe.NewCSRClient(cfg)
+		if err != nil {
+			log.Fatalf("spiffe failed: %v", err)
+		}
+		signer = &spiffe.CSRSigner{
+			Client:       client,
+			SignerName:   *signerName,
+			GenerateName: "envoy-" + *namespace + "-" + *pod + "-",
+			Duration:     *duration,
+			Timeout:      *timeout,
+			PollInterval: 2 * time.Second,
+		}
+	case *caEndpoint != "":
+		client, err := caClient(*caFile, *timeout)
+		if err != nil {
+			log.Fatalf("spiffe failed: %v", err)
+		}
+		token, err := caToken(*tokenFile, *caEndpoint, *namespace, *serviceAccount, *pod, *podUID)
+		if err != nil {
+			log.Fatalf("spiffe failed: %v", err)
+		}
+		signer = &spiffe.CASigner{
+			Endpoint: *caEndpoint,
+			Client:   client,
+			Token:    token,
+		}
+	default:
+		log.Fatalf("spiffe needs a -signer-name or a -ca-endpoint")
+	}
+
+	cert, key, err := spiffe.Request(signer, id)
+	if err != nil {
+		log.Fatalf("failed to get the certificate of %s: %v", id, err)
+	}
+	if err := spiffe.Write(*outDir, cert, key); err != nil {
+		log.Fatalf("failed to write the certificate: %v", err)
+	}
+	log.Printf("wrote the certificate of %s", id)
+}
+
+// caToken returns the token the pod authenticates to the ca endpoint with: the one of the token
+// file, or else a token of the pod's service account for the ca endpoint only. The pod's own
+// service account token is never sent, as the ca endpoint could use it against the api server.
+func caToken(tokenFile, caEndpoint, namespace, serviceAccount, pod, podUID string) (string, error) {
+	if tokenFile != "" {
+		token, err := ioutil.ReadFile(tokenFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the token: %v", err)
+		}
+		return strings.TrimSpace(string(token)), nil
+	}
+	cfg, err := rest.InClusterConfig()
+	if err != nil {
+		return "", err
+	}
+	client, err := spiffe.NewCoreClient(cfg)
+	if err != nil {
+		return "", err
+	}
+	requester := &spiffe.TokenRequester{
+		Client:         client,
+		Namespace:      namespace,
+		ServiceAccount: serviceAccount,
+		Pod:            pod,
+		PodUID:         podUID,
+		Expiration:     10 * time.Minute,
+	}
+	return requester.Token(caEndpoint)
+}
+
+// caClient returns the client of the ca endpoint, which trusts the certs of caFile if set
+func caClient(caFile string, timeout time.Duration) (*http.Client, error) {
+	client := &http.Client{Timeout: timeout}
+	if caFile == "" {
+		return client, nil
+	}
+	pem, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certs in %s", caFile)
+	}
+	client.Transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}
+	return client, nil
+}
@@ -8,7 +8,7 @@ require (
 	github.com/cncf/udpa/go v0.0.0-20200629203442-efcf912fb354 // indirect
 	github.com/emicklei/go-restful v2.7.0+incompatible // indirect
 	github.com/envoyproxy/go-control-plane v0.9.6-0.20200529035633-fc42e08917e9
-	github.com/ghodss/yaml v1.0.0 // indirect
+	github.com/ghodss/yaml v1.0.0
 	github.com/go-openapi/jsonpointer v0.0.0-20180322222829-3a0015ad55fa // indirect
 	github.com/go-openapi/jsonreference v0.0.0-20180322222742-3fb327e6747d // indirect
 	github.com/go-openapi/spec v0.0.0-20180415031709-bcff419492ee // indirect
@@ -39,6 +39,6 @@ require (
 	gopkg.in/yaml.v2 v2.2.2
 	k8s.io/api v0.0.0-20180127130940-acf347b865f2
 	k8s.io/apimachinery v0.0.0-20180126010752-19e3f5aa3adc
-	k8s.io/client-go v0.0.0-20180103015815-9389c055a838 // indirect
+	k8s.io/client-go v0.0.0-20180103015815-9389c055a838
 	k8s.io/kube-openapi v0.0.0-20180501212615-f08db293d3ef // indirect
 )
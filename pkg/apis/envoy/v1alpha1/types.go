@@ -1,15 +1,20 @@
 package v1alpha1
 
 import (
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 const defaultContainerImage = "soloio/envoy:v0.1.6-131"
 
 const (
-	TLSCA           = "ca.crt"
-	TLSCert         = "tls.cert"
-	TLSKey          = "tls.key"
+	TLSCA   = "ca.crt"
+	TLSCert = "tls.crt"
+	TLSKey  = "tls.key"
+	// The client certificate key of secrets made for older versions of the operator
+	TLSCertLegacy   = "tls.cert"
 	EnvoyTLSVolPath = "/etc/certs/"
 )
 
@@ -34,15 +39,29 @@ type EnvoySpec struct {
 	ADSServer string `json:"adsServer"`
 	ADSPort   int32  `json:"adsPort"`
 
+	// Several ads servers, for failover or load balancing, and how envoy connects to them.
+	// The endpoints replace the adsServer and adsPort.
+	ControlPlane *ControlPlaneSpec `json:"controlPlane,omitempty"`
+	// How the listeners and clusters are fetched from the control plane. Defaults to ADS.
+	XDS *XDSSpec `json:"xds,omitempty"`
+
 	Image        string   `json:"image"`
 	ImageCommand []string `json:"imageCommand"`
 
 	// Secret name, containing ca cert, and potentially client cert and key with the names
-	// ca.crt tls.crt, tls.key
+	// ca.crt tls.crt, tls.key. The client cert may also be named tls.cert.
 	TLSSecretName string `json:"tls_secret_name"`
+	// Deliver the certs of the control plane connection with the secret discovery service, so
+	// that envoy picks up their rotation without restarting
+	SDS *SDSSpec `json:"sds,omitempty"`
+	// TLS settings of the control plane connection
+	TLS *TLSSpec `json:"tls,omitempty"`
 
 	AdminPort int32 `json:"adminPort"`
 
+	// Where the admin interface listens
+	Admin *AdminSpec `json:"admin,omitempty"`
+
 	ClusterIdTemplate string `json:"clusterIdTemplate"`
 
 	NodeIdTemplate string `json:"nodeIdTemplate"`
@@ -52,31 +71,384 @@ type EnvoySpec struct {
 	// folllows format name: portnumber
 	ServicePorts map[string]int32 `json:"servicePorts"`
 
-	// StatsdSink string
+	// Listeners, routes and clusters that are configured without a control plane. With static
+	// resources, the adsServer may be left empty.
+	StaticResources *StaticResourcesSpec `json:"staticResources,omitempty"`
+
+	// Merged onto the generated bootstrap config, for the bootstrap features the spec doesn't
+	// cover, like the overload manager or the runtime
+	BootstrapOverlay *BootstrapOverlaySpec `json:"bootstrapOverlay,omitempty"`
+
+	// The service in front of the envoys. By default, it's a LoadBalancer service.
+	Service *ServiceSpec `json:"service,omitempty"`
+
+	// Probes of the envoy container, against the stats listener, or the admin endpoint when it
+	// isn't bound to localhost
+	Probes *ProbesSpec `json:"probes,omitempty"`
+
+	// How envoys drain their connections when their pods terminate
+	Drain *DrainSpec `json:"drain,omitempty"`
+
+	// Where envoys send their stats
+	Stats *StatsSpec `json:"stats,omitempty"`
 
-	// OpenTracing string
+	// Where envoys send their traces
+	Tracing *TracingSpec `json:"tracing,omitempty"`
+
+	// How prometheus scrapes the envoys
+	Metrics *MetricsSpec `json:"metrics,omitempty"`
 
 	Deployment *EnvoyDeploymentSpec `json:"deployment,omitempty"`
-	Injection  *InjectionSpec       `json:"ingress,omitempty"`
+	DaemonSet  *EnvoyDaemonSetSpec  `json:"daemonSet,omitempty"`
+	Injection  *InjectionSpec       `json:"injection,omitempty"`
+
+	// Deprecated: the injection used to be read from the ingress key. It's moved to injection
+	// when the envoy is defaulted.
+	LegacyInjection *InjectionSpec `json:"ingress,omitempty"`
 }
 
 type EnvoyDeploymentSpec struct {
 	// How many replicas of envoy we should have?
 	Replicas uint32 `json:"replicas"`
+
+	// Merged onto the generated pod template as a strategic merge patch. Use it to set
+	// resources, scheduling constraints, service accounts, etc. The envoy container is named
+	// "envoy".
+	PodTemplate *v1.PodTemplateSpec `json:"podTemplate,omitempty"`
+}
+
+// EnvoyDaemonSetSpec runs an envoy on every selected node
+type EnvoyDaemonSetSpec struct {
+	// Run the envoys in the network namespace of their node
+	HostNetwork bool `json:"hostNetwork,omitempty"`
+
+	// Ports to expose on the address of the node
+	// folllows format name: portnumber
+	HostPorts map[string]int32 `json:"hostPorts,omitempty"`
+
+	// Only run envoys on the nodes matching this selector
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	UpdateStrategy appsv1.DaemonSetUpdateStrategy `json:"updateStrategy,omitempty"`
+
+	// Merged onto the generated pod template, as in EnvoyDeploymentSpec
+	PodTemplate *v1.PodTemplateSpec `json:"podTemplate,omitempty"`
+}
+
+// BootstrapOverlaySpec holds a partial bootstrap config, inline or in a config map. It is merged
+// onto the generated config as a proto merge: fields that are set replace the generated ones,
+// messages are merged, and lists are appended to.
+type BootstrapOverlaySpec struct {
+	// The overlay, in yaml or json
+	Inline string `json:"inline,omitempty"`
+	// A key of a config map in the namespace of the envoy holding the overlay
+	ConfigMapRef *v1.ConfigMapKeySelector `json:"configMapRef,omitempty"`
+}
+
+// StaticResourcesSpec holds the listeners and clusters rendered into the bootstrap config
+type StaticResourcesSpec struct {
+	Listeners []StaticListener `json:"listeners,omitempty"`
+	Clusters  []StaticCluster  `json:"clusters,omitempty"`
+	// The dns domain of the cluster the services of the clusters live in; defaults to
+	// cluster.local
+	ClusterDomain string `json:"clusterDomain,omitempty"`
+}
+
+// StaticListener is an http listener routing to the static clusters, or a tcp listener
+// forwarding to one of them
+type StaticListener struct {
+	Name string `json:"name"`
+	// The address to listen on; 0.0.0.0 by default
+	Address string `json:"address,omitempty"`
+	Port    uint32 `json:"port"`
+
+	// The virtual hosts of an http listener
+	VirtualHosts []StaticVirtualHost `json:"virtualHosts,omitempty"`
+
+	// The cluster a tcp listener forwards to. Only valid without virtual hosts.
+	Cluster string `json:"cluster,omitempty"`
+}
+
+type StaticVirtualHost struct {
+	Name string `json:"name"`
+	// The host headers to match; all hosts by default
+	Domains []string `json:"domains,omitempty"`
+	// Routes are matched in order
+	Routes []StaticRoute `json:"routes"`
+}
+
+// StaticRoute routes the requests matching a path, or a path prefix, to a cluster
+type StaticRoute struct {
+	// Match the requests with this path prefix; "/" if neither prefix nor path are set
+	Prefix string `json:"prefix,omitempty"`
+	// Match the requests with this exact path
+	Path string `json:"path,omitempty"`
+
+	Cluster string `json:"cluster"`
+	// Replaces the matched prefix or path
+	PrefixRewrite string `json:"prefixRewrite,omitempty"`
+	// Envoy's default of 15 seconds if not set
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// StaticCluster is an upstream cluster pointing at a kube service
+type StaticCluster struct {
+	Name string `json:"name"`
+
+	// The service, in the namespace of the envoy by default. Headless services are load balanced
+	// over all their pods.
+	Service   string `json:"service"`
+	Namespace string `json:"namespace,omitempty"`
+	Port      uint32 `json:"port"`
+
+	// Talk http2 to the service, e.g. for grpc
+	HTTP2 bool `json:"http2,omitempty"`
+	// 5 seconds if not set
+	ConnectTimeout *metav1.Duration `json:"connectTimeout,omitempty"`
+}
+
+// StatsSpec configures the stats sinks of the envoys. Sink addresses may reference the downward
+// api templates, e.g. {{.NodeIp}} to send stats to an agent running on the node.
+type StatsSpec struct {
+	Statsd         *StatsdSinkSpec         `json:"statsd,omitempty"`
+	DogStatsd      *StatsdSinkSpec         `json:"dogStatsd,omitempty"`
+	MetricsService *MetricsServiceSinkSpec `json:"metricsService,omitempty"`
+
+	// Tags extracted from the stat names, in addition to envoy's default tags
+	Tags []StatsTag `json:"tags,omitempty"`
+}
+
+type StatsdSinkSpec struct {
+	// The ip address of the statsd server; envoy doesn't resolve host names for statsd
+	Address string `json:"address"`
+	Port    uint32 `json:"port"`
+	// Prefixes the names of all stats
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// MetricsServiceSinkSpec streams the stats to a grpc metrics service
+type MetricsServiceSinkSpec struct {
+	// The host name or ip address of the metrics service
+	Address string `json:"address"`
+	Port    uint32 `json:"port"`
+}
+
+// StatsTag is a tag added to the stats, with either a value extracted from the stat names or a
+// fixed value
+type StatsTag struct {
+	Name string `json:"name"`
+	// The first capture group is the value of the tag, and is removed from the stat name
+	Regex      string `json:"regex,omitempty"`
+	FixedValue string `json:"fixedValue,omitempty"`
+}
+
+// ServiceSpec configures the service in front of the envoys
+type ServiceSpec struct {
+	// ClusterIP, NodePort or LoadBalancer (default, or ClusterIP for headless services)
+	Type v1.ServiceType `json:"type,omitempty"`
+
+	// A headless service resolves to the envoy pods instead of a cluster ip. Only valid for
+	// ClusterIP services, which it defaults the type to.
+	Headless bool `json:"headless,omitempty"`
+
+	// Annotations of the service, e.g. for cloud load balancer controllers
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	ExternalTrafficPolicy    v1.ServiceExternalTrafficPolicyType `json:"externalTrafficPolicy,omitempty"`
+	LoadBalancerSourceRanges []string                            `json:"loadBalancerSourceRanges,omitempty"`
+
+	// Ports to expose, in addition to the servicePorts
+	Ports []ServicePortSpec `json:"ports,omitempty"`
 }
 
-// TODO: this is not implemented yet, but is written out to allow comments and discussion
+type ServicePortSpec struct {
+	Name string `json:"name"`
+	Port int32  `json:"port"`
+	// The port envoy listens on; the service port by default
+	TargetPort intstr.IntOrString `json:"targetPort,omitempty"`
+	// Only for NodePort and LoadBalancer services; allocated by kube by default
+	NodePort int32 `json:"nodePort,omitempty"`
+	// TCP (default), UDP or SCTP
+	Protocol v1.Protocol `json:"protocol,omitempty"`
+}
+
+// ProbesSpec configures the probes of the envoy container. Readiness is checked with the /ready
+// endpoint of the stats listener, and liveness with its stats; or with the admin /ready and
+// /server_info endpoints when the admin interface isn't bound to localhost. There are no
+// startup probes, as the vendored k8s.io/api predates StartupProbe; raise the liveness
+// initialDelaySeconds of slow starting envoys instead.
+type ProbesSpec struct {
+	// Don't probe the envoy at all
+	Disabled bool `json:"disabled,omitempty"`
+
+	Readiness ProbeSpec `json:"readiness,omitempty"`
+	Liveness  ProbeSpec `json:"liveness,omitempty"`
+}
+
+// ProbeSpec holds the thresholds of a probe. Zero values keep the operator's defaults.
+type ProbeSpec struct {
+	InitialDelaySeconds int32 `json:"initialDelaySeconds,omitempty"`
+	TimeoutSeconds      int32 `json:"timeoutSeconds,omitempty"`
+	PeriodSeconds       int32 `json:"periodSeconds,omitempty"`
+	SuccessThreshold    int32 `json:"successThreshold,omitempty"`
+	FailureThreshold    int32 `json:"failureThreshold,omitempty"`
+}
+
+// DrainSpec configures the draining of envoys. Before a pod terminates, its envoy is told to
+// drain through the admin endpoint, and is given the drain period to finish in flight requests.
+type DrainSpec struct {
+	// Stop envoys right away
+	Disabled bool `json:"disabled,omitempty"`
+
+	// How envoy is told to drain; DrainListeners (default) or FailHealthcheck
+	Strategy DrainStrategy `json:"strategy,omitempty"`
+
+	// How long envoy drains before it is stopped; 15 seconds by default. Also passed to envoy
+	// with --drain-time-s.
+	DrainSeconds int32 `json:"drainSeconds,omitempty"`
+
+	// Passed to envoy with --parent-shutdown-time-s; the drain period plus 5 seconds by default
+	ParentShutdownSeconds int32 `json:"parentShutdownSeconds,omitempty"`
+}
+
+type DrainStrategy string
+
+const (
+	// Gracefully drain the listeners with /drain_listeners?graceful
+	DrainStrategyDrainListeners DrainStrategy = "DrainListeners"
+	// Fail the health checks of downstream load balancers with /healthcheck/fail
+	DrainStrategyFailHealthcheck DrainStrategy = "FailHealthcheck"
+)
+
+// InjectionSpec configures which pods get the envoy injected as a sidecar
 type InjectionSpec struct {
-	// This is should have configuration for how to inject.
-	// for example:
+	// Is the namespaces list below a whitelist or blacklist
+	Mode           InjectionMode `json:"mode"`
+	Namespaceslist []string      `json:"namespaces"`
+	// Name of the pod annotation that overrides the above. Pods annotated with "true" are
+	// always injected, and pods annotated with "false" never are.
+	Annotation string `json:"annotation"`
+
+	// Redirect the pod's traffic through the envoy. If nil, traffic is not intercepted.
+	Interception *InterceptionSpec `json:"interception,omitempty"`
+}
 
-	Mode           string // is the list below a whitelist or blacklist
-	Namespaceslist []string
-	// annotation per pod \ namespace that overrides above
-	Annotation string
+// InterceptionSpec configures the iptables rules that redirect the traffic of an injected pod
+// through the envoy sidecar
+type InterceptionSpec struct {
+	// How inbound traffic is redirected; REDIRECT (default) or TPROXY. Outbound traffic is
+	// always redirected with REDIRECT.
+	Mode InterceptionMode `json:"mode"`
+
+	// The ports envoy listens on for the intercepted traffic
+	OutboundPort uint32 `json:"outboundPort"`
+	InboundPort  uint32 `json:"inboundPort"`
+
+	// Outbound traffic to these CIDRs is intercepted; if empty, all outbound traffic is
+	IncludeOutboundCIDRs []string `json:"includeOutboundCidrs,omitempty"`
+	ExcludeOutboundCIDRs []string `json:"excludeOutboundCidrs,omitempty"`
+	ExcludeOutboundPorts []uint32 `json:"excludeOutboundPorts,omitempty"`
+
+	// Inbound traffic to these ports is intercepted; if empty, all inbound traffic is
+	IncludeInboundPorts []uint32 `json:"includeInboundPorts,omitempty"`
+	ExcludeInboundPorts []uint32 `json:"excludeInboundPorts,omitempty"`
+
+	// Outbound traffic of processes running as these users is not intercepted. Envoy's own
+	// traffic is never intercepted. The pod's init containers run after the traffic is
+	// redirected, so the ones that need the network should run as one of these users.
+	ExcludeUIDs []int64 `json:"excludeUids,omitempty"`
 }
 
+type InterceptionMode string
+
+const (
+	InterceptionModeRedirect InterceptionMode = "REDIRECT"
+	InterceptionModeTProxy   InterceptionMode = "TPROXY"
+)
+
+type InjectionMode string
+
+const (
+	InjectionModeWhitelist InjectionMode = "whitelist"
+	InjectionModeBlacklist InjectionMode = "blacklist"
+)
+
 type EnvoyStatus struct {
+	// The generation of the Envoy spec that was last reconciled successfully
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Replica counts, as reported by the deployment that runs the envoys
+	Replicas        int32 `json:"replicas"`
+	ReadyReplicas   int32 `json:"readyReplicas"`
+	UpdatedReplicas int32 `json:"updatedReplicas"`
+
+	// Hash of the bootstrap config in the generated config map
+	ConfigHash string `json:"configHash,omitempty"`
+
+	Conditions []EnvoyCondition `json:"conditions,omitempty"`
+}
+
+type EnvoyConditionType string
+
+const (
+	// The bootstrap config was rendered and written to the config map
+	EnvoyConditionConfigRendered EnvoyConditionType = "ConfigRendered"
+	// The deployment running the envoys is in sync with the spec
+	EnvoyConditionDeployed EnvoyConditionType = "Deployed"
+	// At least one envoy is ready
+	EnvoyConditionAvailable EnvoyConditionType = "Available"
+	// Some envoys are not ready, or reconciliation failed
+	EnvoyConditionDegraded EnvoyConditionType = "Degraded"
+	// The tls secret exists, and holds a ca, and a client cert matching its key, that can be used
+	EnvoyConditionTLSSecretValid EnvoyConditionType = "TLSSecretValid"
+)
+
+type EnvoyCondition struct {
+	Type               EnvoyConditionType `json:"type"`
+	Status             v1.ConditionStatus `json:"status"`
+	LastTransitionTime metav1.Time        `json:"lastTransitionTime,omitempty"`
+	Reason             string             `json:"reason,omitempty"`
+	Message            string             `json:"message,omitempty"`
+}
+
+// GetCondition returns the condition with the given type, or nil if it was never set
+func (s *EnvoyStatus) GetCondition(t EnvoyConditionType) *EnvoyCondition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == t {
+			return &s.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// SetCondition sets the condition with the given type. The transition time is only
+// updated when the status of the condition changes.
+func (s *EnvoyStatus) SetCondition(t EnvoyConditionType, status bool, reason, message string) {
+	cs := v1.ConditionFalse
+	if status {
+		cs = v1.ConditionTrue
+	}
+	c := s.GetCondition(t)
+	if c == nil {
+		s.Conditions = append(s.Conditions, EnvoyCondition{Type: t})
+		c = &s.Conditions[len(s.Conditions)-1]
+	}
+	if c.Status != cs {
+		c.Status = cs
+		c.LastTransitionTime = metav1.Now()
+	}
+	c.Reason = reason
+	c.Message = message
+}
+
+// RemoveCondition removes the condition with the given type, if it is set
+func (s *EnvoyStatus) RemoveCondition(t EnvoyConditionType) {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == t {
+			s.Conditions = append(s.Conditions[:i], s.Conditions[i+1:]...)
+			return
+		}
+	}
 }
 
 // SetDefaults sets the default vaules for the Envoy spec and returns true if the spec was changed
@@ -84,6 +456,13 @@ func (e *Envoy) SetDefaults() bool {
 	changed := false
 	es := &e.Spec
 
+	if es.LegacyInjection != nil {
+		if es.Injection == nil {
+			es.Injection = es.LegacyInjection
+		}
+		es.LegacyInjection = nil
+		changed = true
+	}
 	if es.Image == "" {
 		es.Image = defaultContainerImage
 		changed = true
@@ -96,7 +475,42 @@ func (e *Envoy) SetDefaults() bool {
 		es.AdminPort = 19000
 		changed = true
 	}
-	if es.Injection == nil {
+	if es.Admin == nil {
+		es.Admin = &AdminSpec{}
+		changed = true
+	}
+	if es.Admin.BindAddress == "" {
+		es.Admin.BindAddress = "127.0.0.1"
+		changed = true
+	}
+	if es.Admin.StatsPort == 0 {
+		es.Admin.StatsPort = 19001
+		changed = true
+	}
+	if es.Injection != nil && es.Injection.Interception != nil {
+		ic := es.Injection.Interception
+		if ic.Mode == "" {
+			ic.Mode = InterceptionModeRedirect
+			changed = true
+		}
+		if ic.OutboundPort == 0 {
+			ic.OutboundPort = 15001
+			changed = true
+		}
+		if ic.InboundPort == 0 {
+			ic.InboundPort = 15006
+			changed = true
+		}
+	}
+	if es.Metrics != nil && es.Metrics.Monitor == "" {
+		es.Metrics.Monitor = MetricsMonitorAuto
+		changed = true
+	}
+	if es.DaemonSet != nil && es.DaemonSet.UpdateStrategy.Type == "" {
+		es.DaemonSet.UpdateStrategy.Type = appsv1.RollingUpdateDaemonSetStrategyType
+		changed = true
+	}
+	if es.Injection == nil && es.DaemonSet == nil {
 		if es.Deployment == nil {
 			es.Deployment = &EnvoyDeploymentSpec{}
 			changed = true
@@ -108,3 +522,336 @@ func (e *Envoy) SetDefaults() bool {
 	}
 	return changed
 }
+
+type DNSType string
+
+const (
+	// Resolve the addresses, and use all the ips they resolve to
+	DNSTypeStrict DNSType = "STRICT_DNS"
+	// Resolve the address, and only connect to the first ip it resolves to; one endpoint only
+	DNSTypeLogical DNSType = "LOGICAL_DNS"
+	// The addresses are ips
+	DNSTypeStatic DNSType = "STATIC"
+)
+
+// ControlPlaneSpec configures the ads-control-plane cluster
+type ControlPlaneSpec struct {
+	Endpoints []ControlPlaneEndpoint `json:"endpoints,omitempty"`
+	// Defaults to STRICT_DNS
+	DNSType DNSType `json:"dnsType,omitempty"`
+	// Defaults to 5s
+	ConnectTimeout *metav1.Duration `json:"connectTimeout,omitempty"`
+	// TCP keepalives on the connections to the ads servers
+	Keepalive *KeepaliveSpec `json:"keepalive,omitempty"`
+	// grpc health checks of the ads servers
+	HealthCheck *HealthCheckSpec `json:"healthCheck,omitempty"`
+	// The grpc client of the xds streams, and its credentials
+	GRPC *GRPCClientSpec `json:"grpc,omitempty"`
+}
+
+type ControlPlaneEndpoint struct {
+	Address string `json:"address"`
+	Port    uint32 `json:"port"`
+	// Endpoints with a lower priority are only used when the ones with a higher priority,
+	// 0 being the highest, are unhealthy
+	Priority uint32 `json:"priority,omitempty"`
+	// Share of the connections among the endpoints of the same priority
+	Weight uint32 `json:"weight,omitempty"`
+}
+
+// KeepaliveSpec overrides the tcp keepalive settings of the os
+type KeepaliveSpec struct {
+	// Idle time before the first probe
+	Time *metav1.Duration `json:"time,omitempty"`
+	// Time between probes
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// Unanswered probes before the connection is dropped
+	Probes uint32 `json:"probes,omitempty"`
+}
+
+type HealthCheckSpec struct {
+	// Defaults to 10s
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// Defaults to 2s
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// Defaults to 3
+	UnhealthyThreshold uint32 `json:"unhealthyThreshold,omitempty"`
+	// Defaults to 1
+	HealthyThreshold uint32 `json:"healthyThreshold,omitempty"`
+	// The service name sent in the grpc health check requests
+	ServiceName string `json:"serviceName,omitempty"`
+}
+
+// GRPCClientSpec configures the grpc client envoy fetches its config with. Secrets are read from
+// the namespace of the envoy pods.
+type GRPCClientSpec struct {
+	// Headers sent with every xds request, like auth tokens
+	InitialMetadata []GRPCHeader `json:"initialMetadata,omitempty"`
+	// Use the google grpc client, which supports call credentials, instead of envoy's
+	Google *GoogleGRPCSpec `json:"google,omitempty"`
+}
+
+type GRPCHeader struct {
+	Key   string `json:"key"`
+	Value string `json:"value,omitempty"`
+	// Read the value from a secret instead
+	ValueFrom *v1.SecretKeySelector `json:"valueFrom,omitempty"`
+}
+
+// GoogleGRPCSpec configures the google grpc client. It connects to a single control plane
+// endpoint over tls, with the certs of the tlsSecretName, or else the system's root certs.
+type GoogleGRPCSpec struct {
+	// Defaults to ads
+	StatPrefix      string                `json:"statPrefix,omitempty"`
+	CallCredentials []CallCredentialsSpec `json:"callCredentials,omitempty"`
+}
+
+// CallCredentialsSpec sets one of the credentials the google grpc client sends with its requests
+type CallCredentialsSpec struct {
+	// A secret holding an oauth2 access token
+	AccessToken *v1.SecretKeySelector `json:"accessToken,omitempty"`
+	// JWTs signed with a service account key
+	ServiceAccountJWT *ServiceAccountJWTSpec `json:"serviceAccountJwt,omitempty"`
+	// Access tokens exchanged for a subject token with an STS server
+	STS *STSSpec `json:"sts,omitempty"`
+	// The credentials of the compute engine vm envoy runs on
+	GoogleComputeEngine bool `json:"googleComputeEngine,omitempty"`
+}
+
+type ServiceAccountJWTSpec struct {
+	// A secret holding the json key of the service account
+	JSONKey              v1.SecretKeySelector `json:"jsonKey"`
+	TokenLifetimeSeconds uint64               `json:"tokenLifetimeSeconds,omitempty"`
+}
+
+// STSSpec configures an OAuth 2.0 token exchange (RFC 8693)
+type STSSpec struct {
+	TokenExchangeServiceURI string `json:"tokenExchangeServiceUri"`
+	Resource                string `json:"resource,omitempty"`
+	Audience                string `json:"audience,omitempty"`
+	Scope                   string `json:"scope,omitempty"`
+	RequestedTokenType      string `json:"requestedTokenType,omitempty"`
+	// A secret holding the subject token. It is mounted in the envoy container, so that envoy
+	// picks up its rotation.
+	SubjectToken     v1.SecretKeySelector `json:"subjectToken"`
+	SubjectTokenType string               `json:"subjectTokenType"`
+}
+
+// SDSSpec configures the secret discovery service, which delivers the certs of the control plane
+// connection instead of the tls_secret_name
+type SDSSpec struct {
+	// Required
+	Server *SDSServerSpec `json:"server,omitempty"`
+}
+
+// SDSServerSpec points at an sds server in the pod. The connection to it is plaintext, so the
+// address must be a loopback address.
+type SDSServerSpec struct {
+	Address string `json:"address"`
+	Port    uint32 `json:"port"`
+	// The name of the client certificate. Defaults to default.
+	CertificateName string `json:"certificateName,omitempty"`
+	// The name of the ca that validates the control plane. Defaults to ROOTCA.
+	ValidationContextName string `json:"validationContextName,omitempty"`
+}
+
+// TLSSpec configures the tls connection to the control plane
+type TLSSpec struct {
+	// Have cert-manager issue the client certificate of the envoy, instead of setting a
+	// tls_secret_name. The operator creates a Certificate, and uses the secret cert-manager
+	// writes.
+	IssuerRef *CertIssuerRef `json:"issuerRef,omitempty"`
+	// How long the certificate is valid. Defaults to the issuer's default.
+	Duration *metav1.Duration `json:"duration,omitempty"`
+	// How long before it expires the certificate is renewed. Defaults to the issuer's default.
+	RenewBefore *metav1.Duration `json:"renewBefore,omitempty"`
+	// Give each envoy pod its own client certificate, with a SPIFFE ID, instead of sharing the
+	// one of the tls_secret_name. The tls_secret_name still holds the ca.crt of the control
+	// plane.
+	SPIFFE *SPIFFESpec `json:"spiffe,omitempty"`
+
+	// The server name sent to the control plane. Defaults to the address of the first control
+	// plane endpoint.
+	SNI string `json:"sni,omitempty"`
+	// The certificate of the control plane must have a subject alt name matching one of these.
+	// Any certificate of the ca is accepted if empty.
+	MatchSubjectAltNames []StringMatch `json:"matchSubjectAltNames,omitempty"`
+	// The range of tls versions. Envoy's defaults if not set.
+	MinVersion TLSVersion `json:"minVersion,omitempty"`
+	MaxVersion TLSVersion `json:"maxVersion,omitempty"`
+	// The BoringSSL names of the cipher suites offered up to TLSv1_2, e.g.
+	// ECDHE-ECDSA-AES128-GCM-SHA256. Envoy's defaults if not set.
+	CipherSuites []string `json:"cipherSuites,omitempty"`
+	// The protocols offered with ALPN, e.g. h2
+	ALPN []string `json:"alpn,omitempty"`
+	// The key of the tls_secret_name holding the PEM certificate revocation list of the ca
+	CRLKey string `json:"crlKey,omitempty"`
+}
+
+// StringMatch matches a string exactly, by prefix, or with a RE2 regex. Set one of them.
+type StringMatch struct {
+	Exact  string `json:"exact,omitempty"`
+	Prefix string `json:"prefix,omitempty"`
+	Regex  string `json:"regex,omitempty"`
+}
+
+type TLSVersion string
+
+const (
+	TLSVersion1_0 TLSVersion = "TLSv1_0"
+	TLSVersion1_1 TLSVersion = "TLSv1_1"
+	TLSVersion1_2 TLSVersion = "TLSv1_2"
+	TLSVersion1_3 TLSVersion = "TLSv1_3"
+)
+
+// SPIFFESpec has an init container request the client certificate of each pod, for the id
+// spiffe://<trustDomain>/ns/<namespace>/sa/<service account>/pod/<pod name>. The certificate is
+// signed by either a signer of the kubernetes CSR API, or a CA endpoint.
+type SPIFFESpec struct {
+	// Defaults to cluster.local
+	TrustDomain string `json:"trustDomain,omitempty"`
+	// The signerName of the CertificateSigningRequests. Approving them is up to the signer.
+	SignerName string `json:"signerName,omitempty"`
+	// The https url the PEM certificate requests are posted to, with the pod's service account
+	// token. It returns the PEM certificate chain.
+	CAEndpoint string `json:"caEndpoint,omitempty"`
+	// How long the certificates are valid. They aren't renewed, so this should outlive the pods.
+	// Defaults to the signer's default.
+	Duration *metav1.Duration `json:"duration,omitempty"`
+}
+
+// CertIssuerRef references a cert-manager issuer
+type CertIssuerRef struct {
+	Name string `json:"name"`
+	// Issuer or ClusterIssuer. Defaults to Issuer.
+	Kind string `json:"kind,omitempty"`
+	// Defaults to cert-manager.io
+	Group string `json:"group,omitempty"`
+}
+
+type XDSMode string
+
+const (
+	// One state of the world grpc stream for all the resources
+	XDSModeADS XDSMode = "ADS"
+	// One incremental grpc stream for all the resources
+	XDSModeDeltaADS XDSMode = "DELTA_ADS"
+	// A state of the world grpc stream per resource type
+	XDSModeGRPC XDSMode = "GRPC"
+	// An incremental grpc stream per resource type
+	XDSModeDeltaGRPC XDSMode = "DELTA_GRPC"
+	// Polling of the REST endpoints of each resource type
+	XDSModeREST XDSMode = "REST"
+)
+
+type APIVersion string
+
+const (
+	APIVersionAuto APIVersion = "AUTO"
+	APIVersionV2   APIVersion = "V2"
+	APIVersionV3   APIVersion = "V3"
+)
+
+// XDSSpec configures the config sources of the listeners and clusters
+type XDSSpec struct {
+	// Defaults to ADS
+	Mode XDSMode `json:"mode,omitempty"`
+	// How often REST config sources are polled. Defaults to 10s.
+	RefreshDelay *metav1.Duration `json:"refreshDelay,omitempty"`
+	// Timeout of the REST requests. Defaults to envoy's 1s.
+	RequestTimeout *metav1.Duration `json:"requestTimeout,omitempty"`
+	// The api version of the requested resources. Defaults to AUTO.
+	ResourceAPIVersion APIVersion `json:"resourceApiVersion,omitempty"`
+	// The api version of the discovery service. Defaults to AUTO.
+	TransportAPIVersion APIVersion `json:"transportApiVersion,omitempty"`
+	// Only send the node in the first request of a grpc stream, to save bandwidth
+	SetNodeOnFirstMessageOnly bool `json:"setNodeOnFirstMessageOnly,omitempty"`
+}
+
+// AdminSpec configures envoy's admin interface. It can quit envoy and change its runtime, so it
+// is bound to localhost by default; a stats listener then proxies the read only /ready and
+// /stats endpoints to it, for probes and scrapers.
+type AdminSpec struct {
+	// Defaults to 127.0.0.1. Set to 0.0.0.0 to reach the whole admin interface from the pod ip.
+	BindAddress string `json:"bindAddress,omitempty"`
+	// The port of the stats listener when the admin interface is bound to localhost, unless the
+	// metrics spec sets one; defaults to 19001
+	StatsPort uint32 `json:"statsPort,omitempty"`
+}
+
+// TracingSpec configures the tracer of the envoys. Only one tracer can be set. The envoys reach
+// the collector through a static cluster, so it works with a control plane too; the listeners
+// served by the control plane still need tracing enabled in their http connection managers.
+type TracingSpec struct {
+	Zipkin *ZipkinTracerSpec `json:"zipkin,omitempty"`
+	// Exports to an OpenCensus agent over grpc, e.g. the opencensus receiver of an OpenTelemetry
+	// collector
+	OpenCensus *OpenCensusTracerSpec `json:"openCensus,omitempty"`
+	Datadog    *DatadogTracerSpec    `json:"datadog,omitempty"`
+
+	// Sampling of the static http listeners
+	Sampling *TracingSamplingSpec `json:"sampling,omitempty"`
+}
+
+// TracingCollectorSpec is the address of a trace collector
+type TracingCollectorSpec struct {
+	// The host name or ip address of the collector
+	Address string `json:"address"`
+	Port    uint32 `json:"port"`
+}
+
+type ZipkinTracerSpec struct {
+	Collector TracingCollectorSpec `json:"collector"`
+	// The path spans are posted to; defaults to /api/v2/spans
+	Endpoint string `json:"endpoint,omitempty"`
+	// Generate 128 bit trace ids instead of 64 bit ones
+	TraceID128Bit bool `json:"traceId128Bit,omitempty"`
+}
+
+type OpenCensusTracerSpec struct {
+	Collector TracingCollectorSpec `json:"collector"`
+}
+
+type DatadogTracerSpec struct {
+	Collector TracingCollectorSpec `json:"collector"`
+	// Defaults to the name of the envoy
+	ServiceName string `json:"serviceName,omitempty"`
+}
+
+// TracingSamplingSpec holds the percentages, from 0 to 100, of requests that are traced
+type TracingSamplingSpec struct {
+	// Requests traced when no trace decision was made by the client or an upstream proxy;
+	// defaults to 100
+	Random *float64 `json:"random,omitempty"`
+	// Requests traced when the client forces tracing with the x-client-trace-id header;
+	// defaults to 100
+	Client *float64 `json:"client,omitempty"`
+	// Cap on all traced requests, applied after the other two; defaults to 100
+	Overall *float64 `json:"overall,omitempty"`
+}
+
+type MetricsMonitor string
+
+const (
+	// A PodMonitor when the prometheus-operator crds are installed, annotations otherwise
+	MetricsMonitorAuto MetricsMonitor = "Auto"
+	// The prometheus.io/scrape, port and path pod annotations
+	MetricsMonitorAnnotations MetricsMonitor = "Annotations"
+	// A prometheus-operator PodMonitor selecting the envoy pods
+	MetricsMonitorPodMonitor MetricsMonitor = "PodMonitor"
+	// A prometheus-operator ServiceMonitor, with a headless service for the metrics port
+	MetricsMonitorServiceMonitor MetricsMonitor = "ServiceMonitor"
+)
+
+// MetricsSpec exposes envoy's prometheus stats. They are scraped from the admin port, unless a
+// port is set for a listener that only serves the stats.
+type MetricsSpec struct {
+	// The port of the stats only listener. Needs an admin port.
+	Port uint32 `json:"port,omitempty"`
+	// Defaults to Auto
+	Monitor MetricsMonitor `json:"monitor,omitempty"`
+	// Scrape interval of the monitors, e.g. 30s; defaults to the interval of prometheus
+	Interval string `json:"interval,omitempty"`
+	// Labels of the monitors, for prometheus to select them by
+	MonitorLabels map[string]string `json:"monitorLabels,omitempty"`
+}
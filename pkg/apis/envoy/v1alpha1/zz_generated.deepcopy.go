@@ -5,16 +5,223 @@
 package v1alpha1
 
 import (
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdminSpec) DeepCopyInto(out *AdminSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdminSpec.
+func (in *AdminSpec) DeepCopy() *AdminSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AdminSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BootstrapOverlaySpec) DeepCopyInto(out *BootstrapOverlaySpec) {
+	*out = *in
+	if in.ConfigMapRef != nil {
+		in, out := &in.ConfigMapRef, &out.ConfigMapRef
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(v1.ConfigMapKeySelector)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootstrapOverlaySpec.
+func (in *BootstrapOverlaySpec) DeepCopy() *BootstrapOverlaySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BootstrapOverlaySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CallCredentialsSpec) DeepCopyInto(out *CallCredentialsSpec) {
+	*out = *in
+	if in.AccessToken != nil {
+		in, out := &in.AccessToken, &out.AccessToken
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(v1.SecretKeySelector)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.ServiceAccountJWT != nil {
+		in, out := &in.ServiceAccountJWT, &out.ServiceAccountJWT
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(ServiceAccountJWTSpec)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.STS != nil {
+		in, out := &in.STS, &out.STS
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(STSSpec)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CallCredentialsSpec.
+func (in *CallCredentialsSpec) DeepCopy() *CallCredentialsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CallCredentialsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertIssuerRef) DeepCopyInto(out *CertIssuerRef) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertIssuerRef.
+func (in *CertIssuerRef) DeepCopy() *CertIssuerRef {
+	if in == nil {
+		return nil
+	}
+	out := new(CertIssuerRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControlPlaneEndpoint) DeepCopyInto(out *ControlPlaneEndpoint) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneEndpoint.
+func (in *ControlPlaneEndpoint) DeepCopy() *ControlPlaneEndpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(ControlPlaneEndpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControlPlaneSpec) DeepCopyInto(out *ControlPlaneSpec) {
+	*out = *in
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make([]ControlPlaneEndpoint, len(*in))
+		copy(*out, *in)
+	}
+	if in.ConnectTimeout != nil {
+		in, out := &in.ConnectTimeout, &out.ConnectTimeout
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(meta_v1.Duration)
+			**out = **in
+		}
+	}
+	if in.Keepalive != nil {
+		in, out := &in.Keepalive, &out.Keepalive
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(KeepaliveSpec)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.HealthCheck != nil {
+		in, out := &in.HealthCheck, &out.HealthCheck
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(HealthCheckSpec)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.GRPC != nil {
+		in, out := &in.GRPC, &out.GRPC
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(GRPCClientSpec)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneSpec.
+func (in *ControlPlaneSpec) DeepCopy() *ControlPlaneSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ControlPlaneSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatadogTracerSpec) DeepCopyInto(out *DatadogTracerSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatadogTracerSpec.
+func (in *DatadogTracerSpec) DeepCopy() *DatadogTracerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DatadogTracerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DrainSpec) DeepCopyInto(out *DrainSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DrainSpec.
+func (in *DrainSpec) DeepCopy() *DrainSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DrainSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Envoy) DeepCopyInto(out *Envoy) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -37,9 +244,75 @@ func (in *Envoy) DeepCopyObject() runtime.Object {
 	}
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvoyCondition) DeepCopyInto(out *EnvoyCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvoyCondition.
+func (in *EnvoyCondition) DeepCopy() *EnvoyCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvoyCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvoyDaemonSetSpec) DeepCopyInto(out *EnvoyDaemonSetSpec) {
+	*out = *in
+	if in.HostPorts != nil {
+		in, out := &in.HostPorts, &out.HostPorts
+		*out = make(map[string]int32, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.UpdateStrategy.DeepCopyInto(&out.UpdateStrategy)
+	if in.PodTemplate != nil {
+		in, out := &in.PodTemplate, &out.PodTemplate
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(v1.PodTemplateSpec)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvoyDaemonSetSpec.
+func (in *EnvoyDaemonSetSpec) DeepCopy() *EnvoyDaemonSetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvoyDaemonSetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EnvoyDeploymentSpec) DeepCopyInto(out *EnvoyDeploymentSpec) {
 	*out = *in
+	if in.PodTemplate != nil {
+		in, out := &in.PodTemplate, &out.PodTemplate
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(v1.PodTemplateSpec)
+			(*in).DeepCopyInto(*out)
+		}
+	}
 	return
 }
 
@@ -90,11 +363,56 @@ func (in *EnvoyList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EnvoySpec) DeepCopyInto(out *EnvoySpec) {
 	*out = *in
+	if in.ControlPlane != nil {
+		in, out := &in.ControlPlane, &out.ControlPlane
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(ControlPlaneSpec)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.XDS != nil {
+		in, out := &in.XDS, &out.XDS
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(XDSSpec)
+			(*in).DeepCopyInto(*out)
+		}
+	}
 	if in.ImageCommand != nil {
 		in, out := &in.ImageCommand, &out.ImageCommand
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.SDS != nil {
+		in, out := &in.SDS, &out.SDS
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(SDSSpec)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(TLSSpec)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.Admin != nil {
+		in, out := &in.Admin, &out.Admin
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(AdminSpec)
+			**out = **in
+		}
+	}
 	if in.ServicePorts != nil {
 		in, out := &in.ServicePorts, &out.ServicePorts
 		*out = make(map[string]int32, len(*in))
@@ -102,53 +420,264 @@ func (in *EnvoySpec) DeepCopyInto(out *EnvoySpec) {
 			(*out)[key] = val
 		}
 	}
-	if in.Deployment != nil {
-		in, out := &in.Deployment, &out.Deployment
+	if in.StaticResources != nil {
+		in, out := &in.StaticResources, &out.StaticResources
 		if *in == nil {
 			*out = nil
 		} else {
-			*out = new(EnvoyDeploymentSpec)
-			**out = **in
+			*out = new(StaticResourcesSpec)
+			(*in).DeepCopyInto(*out)
 		}
 	}
-	if in.Injection != nil {
-		in, out := &in.Injection, &out.Injection
+	if in.BootstrapOverlay != nil {
+		in, out := &in.BootstrapOverlay, &out.BootstrapOverlay
 		if *in == nil {
 			*out = nil
 		} else {
-			*out = new(InjectionSpec)
+			*out = new(BootstrapOverlaySpec)
 			(*in).DeepCopyInto(*out)
 		}
 	}
-	return
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvoySpec.
-func (in *EnvoySpec) DeepCopy() *EnvoySpec {
-	if in == nil {
-		return nil
+	if in.Service != nil {
+		in, out := &in.Service, &out.Service
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(ServiceSpec)
+			(*in).DeepCopyInto(*out)
+		}
 	}
-	out := new(EnvoySpec)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *EnvoyStatus) DeepCopyInto(out *EnvoyStatus) {
-	*out = *in
-	return
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvoyStatus.
-func (in *EnvoyStatus) DeepCopy() *EnvoyStatus {
-	if in == nil {
-		return nil
+	if in.Probes != nil {
+		in, out := &in.Probes, &out.Probes
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(ProbesSpec)
+			**out = **in
+		}
+	}
+	if in.Stats != nil {
+		in, out := &in.Stats, &out.Stats
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(StatsSpec)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.Tracing != nil {
+		in, out := &in.Tracing, &out.Tracing
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(TracingSpec)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(MetricsSpec)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.Drain != nil {
+		in, out := &in.Drain, &out.Drain
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(DrainSpec)
+			**out = **in
+		}
+	}
+	if in.Deployment != nil {
+		in, out := &in.Deployment, &out.Deployment
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(EnvoyDeploymentSpec)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.DaemonSet != nil {
+		in, out := &in.DaemonSet, &out.DaemonSet
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(EnvoyDaemonSetSpec)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.Injection != nil {
+		in, out := &in.Injection, &out.Injection
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(InjectionSpec)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.LegacyInjection != nil {
+		in, out := &in.LegacyInjection, &out.LegacyInjection
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(InjectionSpec)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvoySpec.
+func (in *EnvoySpec) DeepCopy() *EnvoySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvoySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvoyStatus) DeepCopyInto(out *EnvoyStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]EnvoyCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvoyStatus.
+func (in *EnvoyStatus) DeepCopy() *EnvoyStatus {
+	if in == nil {
+		return nil
 	}
 	out := new(EnvoyStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GRPCClientSpec) DeepCopyInto(out *GRPCClientSpec) {
+	*out = *in
+	if in.InitialMetadata != nil {
+		in, out := &in.InitialMetadata, &out.InitialMetadata
+		*out = make([]GRPCHeader, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Google != nil {
+		in, out := &in.Google, &out.Google
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(GoogleGRPCSpec)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GRPCClientSpec.
+func (in *GRPCClientSpec) DeepCopy() *GRPCClientSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GRPCClientSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GRPCHeader) DeepCopyInto(out *GRPCHeader) {
+	*out = *in
+	if in.ValueFrom != nil {
+		in, out := &in.ValueFrom, &out.ValueFrom
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(v1.SecretKeySelector)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GRPCHeader.
+func (in *GRPCHeader) DeepCopy() *GRPCHeader {
+	if in == nil {
+		return nil
+	}
+	out := new(GRPCHeader)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GoogleGRPCSpec) DeepCopyInto(out *GoogleGRPCSpec) {
+	*out = *in
+	if in.CallCredentials != nil {
+		in, out := &in.CallCredentials, &out.CallCredentials
+		*out = make([]CallCredentialsSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GoogleGRPCSpec.
+func (in *GoogleGRPCSpec) DeepCopy() *GoogleGRPCSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GoogleGRPCSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HealthCheckSpec) DeepCopyInto(out *HealthCheckSpec) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(meta_v1.Duration)
+			**out = **in
+		}
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(meta_v1.Duration)
+			**out = **in
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HealthCheckSpec.
+func (in *HealthCheckSpec) DeepCopy() *HealthCheckSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HealthCheckSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *InjectionSpec) DeepCopyInto(out *InjectionSpec) {
 	*out = *in
@@ -157,6 +686,15 @@ func (in *InjectionSpec) DeepCopyInto(out *InjectionSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Interception != nil {
+		in, out := &in.Interception, &out.Interception
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(InterceptionSpec)
+			(*in).DeepCopyInto(*out)
+		}
+	}
 	return
 }
 
@@ -169,3 +707,777 @@ func (in *InjectionSpec) DeepCopy() *InjectionSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InterceptionSpec) DeepCopyInto(out *InterceptionSpec) {
+	*out = *in
+	if in.IncludeOutboundCIDRs != nil {
+		in, out := &in.IncludeOutboundCIDRs, &out.IncludeOutboundCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludeOutboundCIDRs != nil {
+		in, out := &in.ExcludeOutboundCIDRs, &out.ExcludeOutboundCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludeOutboundPorts != nil {
+		in, out := &in.ExcludeOutboundPorts, &out.ExcludeOutboundPorts
+		*out = make([]uint32, len(*in))
+		copy(*out, *in)
+	}
+	if in.IncludeInboundPorts != nil {
+		in, out := &in.IncludeInboundPorts, &out.IncludeInboundPorts
+		*out = make([]uint32, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludeInboundPorts != nil {
+		in, out := &in.ExcludeInboundPorts, &out.ExcludeInboundPorts
+		*out = make([]uint32, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludeUIDs != nil {
+		in, out := &in.ExcludeUIDs, &out.ExcludeUIDs
+		*out = make([]int64, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InterceptionSpec.
+func (in *InterceptionSpec) DeepCopy() *InterceptionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(InterceptionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeepaliveSpec) DeepCopyInto(out *KeepaliveSpec) {
+	*out = *in
+	if in.Time != nil {
+		in, out := &in.Time, &out.Time
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(meta_v1.Duration)
+			**out = **in
+		}
+	}
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(meta_v1.Duration)
+			**out = **in
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeepaliveSpec.
+func (in *KeepaliveSpec) DeepCopy() *KeepaliveSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KeepaliveSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricsServiceSinkSpec) DeepCopyInto(out *MetricsServiceSinkSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricsServiceSinkSpec.
+func (in *MetricsServiceSinkSpec) DeepCopy() *MetricsServiceSinkSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricsServiceSinkSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricsSpec) DeepCopyInto(out *MetricsSpec) {
+	*out = *in
+	if in.MonitorLabels != nil {
+		in, out := &in.MonitorLabels, &out.MonitorLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricsSpec.
+func (in *MetricsSpec) DeepCopy() *MetricsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenCensusTracerSpec) DeepCopyInto(out *OpenCensusTracerSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenCensusTracerSpec.
+func (in *OpenCensusTracerSpec) DeepCopy() *OpenCensusTracerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenCensusTracerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProbeSpec) DeepCopyInto(out *ProbeSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProbeSpec.
+func (in *ProbeSpec) DeepCopy() *ProbeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ProbeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProbesSpec) DeepCopyInto(out *ProbesSpec) {
+	*out = *in
+	out.Readiness = in.Readiness
+	out.Liveness = in.Liveness
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProbesSpec.
+func (in *ProbesSpec) DeepCopy() *ProbesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ProbesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SDSServerSpec) DeepCopyInto(out *SDSServerSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SDSServerSpec.
+func (in *SDSServerSpec) DeepCopy() *SDSServerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SDSServerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SDSSpec) DeepCopyInto(out *SDSSpec) {
+	*out = *in
+	if in.Server != nil {
+		in, out := &in.Server, &out.Server
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(SDSServerSpec)
+			**out = **in
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SDSSpec.
+func (in *SDSSpec) DeepCopy() *SDSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SDSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SPIFFESpec) DeepCopyInto(out *SPIFFESpec) {
+	*out = *in
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(meta_v1.Duration)
+			**out = **in
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SPIFFESpec.
+func (in *SPIFFESpec) DeepCopy() *SPIFFESpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SPIFFESpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *STSSpec) DeepCopyInto(out *STSSpec) {
+	*out = *in
+	in.SubjectToken.DeepCopyInto(&out.SubjectToken)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new STSSpec.
+func (in *STSSpec) DeepCopy() *STSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(STSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceAccountJWTSpec) DeepCopyInto(out *ServiceAccountJWTSpec) {
+	*out = *in
+	in.JSONKey.DeepCopyInto(&out.JSONKey)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceAccountJWTSpec.
+func (in *ServiceAccountJWTSpec) DeepCopy() *ServiceAccountJWTSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceAccountJWTSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServicePortSpec) DeepCopyInto(out *ServicePortSpec) {
+	*out = *in
+	out.TargetPort = in.TargetPort
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServicePortSpec.
+func (in *ServicePortSpec) DeepCopy() *ServicePortSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServicePortSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceSpec) DeepCopyInto(out *ServiceSpec) {
+	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.LoadBalancerSourceRanges != nil {
+		in, out := &in.LoadBalancerSourceRanges, &out.LoadBalancerSourceRanges
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]ServicePortSpec, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceSpec.
+func (in *ServiceSpec) DeepCopy() *ServiceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StaticCluster) DeepCopyInto(out *StaticCluster) {
+	*out = *in
+	if in.ConnectTimeout != nil {
+		in, out := &in.ConnectTimeout, &out.ConnectTimeout
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(meta_v1.Duration)
+			**out = **in
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StaticCluster.
+func (in *StaticCluster) DeepCopy() *StaticCluster {
+	if in == nil {
+		return nil
+	}
+	out := new(StaticCluster)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StaticListener) DeepCopyInto(out *StaticListener) {
+	*out = *in
+	if in.VirtualHosts != nil {
+		in, out := &in.VirtualHosts, &out.VirtualHosts
+		*out = make([]StaticVirtualHost, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StaticListener.
+func (in *StaticListener) DeepCopy() *StaticListener {
+	if in == nil {
+		return nil
+	}
+	out := new(StaticListener)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StaticResourcesSpec) DeepCopyInto(out *StaticResourcesSpec) {
+	*out = *in
+	if in.Listeners != nil {
+		in, out := &in.Listeners, &out.Listeners
+		*out = make([]StaticListener, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]StaticCluster, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StaticResourcesSpec.
+func (in *StaticResourcesSpec) DeepCopy() *StaticResourcesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(StaticResourcesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StaticRoute) DeepCopyInto(out *StaticRoute) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(meta_v1.Duration)
+			**out = **in
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StaticRoute.
+func (in *StaticRoute) DeepCopy() *StaticRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(StaticRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StaticVirtualHost) DeepCopyInto(out *StaticVirtualHost) {
+	*out = *in
+	if in.Domains != nil {
+		in, out := &in.Domains, &out.Domains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]StaticRoute, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StaticVirtualHost.
+func (in *StaticVirtualHost) DeepCopy() *StaticVirtualHost {
+	if in == nil {
+		return nil
+	}
+	out := new(StaticVirtualHost)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StatsSpec) DeepCopyInto(out *StatsSpec) {
+	*out = *in
+	if in.Statsd != nil {
+		in, out := &in.Statsd, &out.Statsd
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(StatsdSinkSpec)
+			**out = **in
+		}
+	}
+	if in.DogStatsd != nil {
+		in, out := &in.DogStatsd, &out.DogStatsd
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(StatsdSinkSpec)
+			**out = **in
+		}
+	}
+	if in.MetricsService != nil {
+		in, out := &in.MetricsService, &out.MetricsService
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(MetricsServiceSinkSpec)
+			**out = **in
+		}
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]StatsTag, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StatsSpec.
+func (in *StatsSpec) DeepCopy() *StatsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(StatsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StatsTag) DeepCopyInto(out *StatsTag) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StatsTag.
+func (in *StatsTag) DeepCopy() *StatsTag {
+	if in == nil {
+		return nil
+	}
+	out := new(StatsTag)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StatsdSinkSpec) DeepCopyInto(out *StatsdSinkSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StatsdSinkSpec.
+func (in *StatsdSinkSpec) DeepCopy() *StatsdSinkSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(StatsdSinkSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StringMatch) DeepCopyInto(out *StringMatch) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StringMatch.
+func (in *StringMatch) DeepCopy() *StringMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(StringMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TLSSpec) DeepCopyInto(out *TLSSpec) {
+	*out = *in
+	if in.IssuerRef != nil {
+		in, out := &in.IssuerRef, &out.IssuerRef
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(CertIssuerRef)
+			**out = **in
+		}
+	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(meta_v1.Duration)
+			**out = **in
+		}
+	}
+	if in.RenewBefore != nil {
+		in, out := &in.RenewBefore, &out.RenewBefore
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(meta_v1.Duration)
+			**out = **in
+		}
+	}
+	if in.SPIFFE != nil {
+		in, out := &in.SPIFFE, &out.SPIFFE
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(SPIFFESpec)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.MatchSubjectAltNames != nil {
+		in, out := &in.MatchSubjectAltNames, &out.MatchSubjectAltNames
+		*out = make([]StringMatch, len(*in))
+		copy(*out, *in)
+	}
+	if in.CipherSuites != nil {
+		in, out := &in.CipherSuites, &out.CipherSuites
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ALPN != nil {
+		in, out := &in.ALPN, &out.ALPN
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSSpec.
+func (in *TLSSpec) DeepCopy() *TLSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TLSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TracingCollectorSpec) DeepCopyInto(out *TracingCollectorSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TracingCollectorSpec.
+func (in *TracingCollectorSpec) DeepCopy() *TracingCollectorSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TracingCollectorSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TracingSamplingSpec) DeepCopyInto(out *TracingSamplingSpec) {
+	*out = *in
+	if in.Random != nil {
+		in, out := &in.Random, &out.Random
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(float64)
+			**out = **in
+		}
+	}
+	if in.Client != nil {
+		in, out := &in.Client, &out.Client
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(float64)
+			**out = **in
+		}
+	}
+	if in.Overall != nil {
+		in, out := &in.Overall, &out.Overall
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(float64)
+			**out = **in
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TracingSamplingSpec.
+func (in *TracingSamplingSpec) DeepCopy() *TracingSamplingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TracingSamplingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TracingSpec) DeepCopyInto(out *TracingSpec) {
+	*out = *in
+	if in.Zipkin != nil {
+		in, out := &in.Zipkin, &out.Zipkin
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(ZipkinTracerSpec)
+			**out = **in
+		}
+	}
+	if in.OpenCensus != nil {
+		in, out := &in.OpenCensus, &out.OpenCensus
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(OpenCensusTracerSpec)
+			**out = **in
+		}
+	}
+	if in.Datadog != nil {
+		in, out := &in.Datadog, &out.Datadog
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(DatadogTracerSpec)
+			**out = **in
+		}
+	}
+	if in.Sampling != nil {
+		in, out := &in.Sampling, &out.Sampling
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(TracingSamplingSpec)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TracingSpec.
+func (in *TracingSpec) DeepCopy() *TracingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TracingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *XDSSpec) DeepCopyInto(out *XDSSpec) {
+	*out = *in
+	if in.RefreshDelay != nil {
+		in, out := &in.RefreshDelay, &out.RefreshDelay
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(meta_v1.Duration)
+			**out = **in
+		}
+	}
+	if in.RequestTimeout != nil {
+		in, out := &in.RequestTimeout, &out.RequestTimeout
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(meta_v1.Duration)
+			**out = **in
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new XDSSpec.
+func (in *XDSSpec) DeepCopy() *XDSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(XDSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ZipkinTracerSpec) DeepCopyInto(out *ZipkinTracerSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZipkinTracerSpec.
+func (in *ZipkinTracerSpec) DeepCopy() *ZipkinTracerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ZipkinTracerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
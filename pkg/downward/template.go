@@ -2,8 +2,11 @@ package downward
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
+	"strings"
 	"text/template"
 )
 
@@ -19,6 +22,24 @@ func NewInterpolator() Interpolator {
 
 type interpolator struct{}
 
+// SecretEnvPrefix prefixes the env vars of the initializer that the templates can read
+const SecretEnvPrefix = "GRPC_SECRET_"
+
+// funcs are available to the templates besides the downward api. env reads the env vars of the
+// initializer, which hold secrets that must not be written in the config map.
+var funcs = template.FuncMap{
+	"env": secretEnv,
+}
+
+// secretEnv returns the value of a secret env var. Other env vars of the initializer aren't
+// exposed to the configs, which the users of the envoy resource write.
+func secretEnv(name string) (string, error) {
+	if !strings.HasPrefix(name, SecretEnvPrefix) {
+		return "", fmt.Errorf("env var %q doesn't start with %s", name, SecretEnvPrefix)
+	}
+	return os.Getenv(name), nil
+}
+
 func (i *interpolator) InterpolateIO(in io.Reader, out io.Writer, data DownwardAPI) error {
 	inbyte, err := ioutil.ReadAll(in)
 	if err != nil {
@@ -29,7 +50,7 @@ func (i *interpolator) InterpolateIO(in io.Reader, out io.Writer, data DownwardA
 }
 
 func (*interpolator) Interpolate(tmpl string, out io.Writer, data DownwardAPI) error {
-	t, err := template.New("template").Option("missingkey=zero").Parse(tmpl)
+	t, err := template.New("template").Option("missingkey=zero").Funcs(funcs).Parse(tmpl)
 	if err != nil {
 		return err
 	}
@@ -1,6 +1,8 @@
 package downward_test
 
 import (
+	"os"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -89,4 +91,21 @@ var _ = Describe("Template", func() {
 		Expect(err).To(HaveOccurred())
 	})
 
+	It("should read the secret env vars", func() {
+		os.Setenv("GRPC_SECRET_0", "token")
+		defer os.Unsetenv("GRPC_SECRET_0")
+		s := `{{env "GRPC_SECRET_0"}}`
+		err := interpolator.InterpolateString(&s, downwardMock)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s).To(Equal("token"))
+	})
+
+	It("should not read other env vars", func() {
+		os.Setenv("ENVOY_TEST_ENV", "value")
+		defer os.Unsetenv("ENVOY_TEST_ENV")
+		s := `{{env "ENVOY_TEST_ENV"}}`
+		err := interpolator.InterpolateString(&s, downwardMock)
+		Expect(err).To(MatchError(ContainSubstring("GRPC_SECRET_")))
+	})
+
 })
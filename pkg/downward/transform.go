@@ -7,14 +7,15 @@ import (
 	"io/ioutil"
 	"os"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
 	structpb "github.com/golang/protobuf/ptypes/struct"
 
 	envoy_config_bootstrap "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v3"
+	envoy_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_metrics "github.com/envoyproxy/go-control-plane/envoy/config/metrics/v3"
 	"github.com/golang/protobuf/jsonpb"
 	yaml "gopkg.in/yaml.v2"
-
-	// register all top level types used in the bootstrap config
-	_ "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
 )
 
 type Transformer struct {
@@ -96,6 +97,115 @@ func TransformConfigTemplatesWithApi(bootstrapConfig *envoy_config_bootstrap.Boo
 
 	transformStruct(interpolate, bootstrapConfig.Node.Metadata)
 
+	if err := transformStatsSinks(interpolate, bootstrapConfig.StatsSinks); err != nil {
+		return err
+	}
+	if err := transformStaticClusters(interpolate, bootstrapConfig.StaticResources); err != nil {
+		return err
+	}
+	return transformDynamicResources(interpolate, bootstrapConfig.DynamicResources)
+}
+
+// transformStaticClusters interpolates the endpoint addresses of the static clusters, like the
+// ones of a metrics service or a trace collector running on the node
+func transformStaticClusters(interpolate func(*string) error, resources *envoy_config_bootstrap.Bootstrap_StaticResources) error {
+	for _, cluster := range resources.GetClusters() {
+		for _, endpoints := range cluster.GetLoadAssignment().GetEndpoints() {
+			for _, endpoint := range endpoints.GetLbEndpoints() {
+				address := endpoint.GetEndpoint().GetAddress().GetSocketAddress()
+				if address == nil {
+					continue
+				}
+				if err := interpolate(&address.Address); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// transformDynamicResources interpolates the credentials of the grpc services of the xds streams
+func transformDynamicResources(interpolate func(*string) error, resources *envoy_config_bootstrap.Bootstrap_DynamicResources) error {
+	if resources == nil {
+		return nil
+	}
+	sources := []*envoy_core.ApiConfigSource{
+		resources.AdsConfig,
+		resources.CdsConfig.GetApiConfigSource(),
+		resources.LdsConfig.GetApiConfigSource(),
+	}
+	for _, source := range sources {
+		for _, service := range source.GetGrpcServices() {
+			if err := transformGrpcService(interpolate, service); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func transformGrpcService(interpolate func(*string) error, service *envoy_core.GrpcService) error {
+	for _, header := range service.InitialMetadata {
+		if err := interpolate(&header.Value); err != nil {
+			return err
+		}
+	}
+	for _, creds := range service.GetGoogleGrpc().GetCallCredentials() {
+		switch c := creds.CredentialSpecifier.(type) {
+		case *envoy_core.GrpcService_GoogleGrpc_CallCredentials_AccessToken:
+			if err := interpolate(&c.AccessToken); err != nil {
+				return err
+			}
+		case *envoy_core.GrpcService_GoogleGrpc_CallCredentials_ServiceAccountJwtAccess:
+			if err := interpolate(&c.ServiceAccountJwtAccess.JsonKey); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// transformStatsSinks interpolates the addresses of statsd sinks, which are often the ip of the node
+func transformStatsSinks(interpolate func(*string) error, sinks []*envoy_metrics.StatsSink) error {
+	for _, sink := range sinks {
+		typedConfig := sink.GetTypedConfig()
+		if typedConfig == nil {
+			continue
+		}
+		var (
+			config  proto.Message
+			address *envoy_core.Address
+		)
+		switch {
+		case ptypes.Is(typedConfig, &envoy_metrics.StatsdSink{}):
+			statsd := &envoy_metrics.StatsdSink{}
+			config = statsd
+			if err := ptypes.UnmarshalAny(typedConfig, statsd); err != nil {
+				return err
+			}
+			address = statsd.GetAddress()
+		case ptypes.Is(typedConfig, &envoy_metrics.DogStatsdSink{}):
+			dogStatsd := &envoy_metrics.DogStatsdSink{}
+			config = dogStatsd
+			if err := ptypes.UnmarshalAny(typedConfig, dogStatsd); err != nil {
+				return err
+			}
+			address = dogStatsd.GetAddress()
+		}
+		socketAddress := address.GetSocketAddress()
+		if socketAddress == nil {
+			continue
+		}
+		if err := interpolate(&socketAddress.Address); err != nil {
+			return err
+		}
+		repacked, err := ptypes.MarshalAny(config)
+		if err != nil {
+			return err
+		}
+		sink.ConfigType = &envoy_metrics.StatsSink_TypedConfig{TypedConfig: repacked}
+	}
 	return nil
 }
 func transformValue(interpolate func(*string) error, v *structpb.Value) error {
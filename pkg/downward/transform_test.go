@@ -3,12 +3,17 @@ package downward_test
 import (
 	"bytes"
 	"fmt"
+	"os"
 
 	envoy_config_bootstrap "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v3"
 	envoy_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_metrics "github.com/envoyproxy/go-control-plane/envoy/config/metrics/v3"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/ptypes"
 	structpb "github.com/golang/protobuf/ptypes/struct"
 	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
 	kube "github.com/solo-io/envoy-operator/pkg/kube"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	. "github.com/onsi/ginkgo"
@@ -48,6 +53,117 @@ var _ = Describe("Transform", func() {
 		Expect(outb.String()).To(ContainSubstring("soloio"))
 	})
 
+	It("should transform stats sink addresses", func() {
+		e := api.Envoy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "myingress",
+			},
+			Spec: api.EnvoySpec{
+				ADSServer:         "test.blah.com",
+				ADSPort:           1234,
+				ClusterIdTemplate: "soloio",
+				NodeIdTemplate:    "soloio",
+				Stats: &api.StatsSpec{
+					DogStatsd: &api.StatsdSinkSpec{Address: "{{.NodeIp}}", Port: 8125},
+				},
+			},
+		}
+		cfg, err := kube.GenerateEnvoyConfig(&e, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		var bootstrapConfig envoy_config_bootstrap.Bootstrap
+		err = jsonpb.UnmarshalString(cfg, &bootstrapConfig)
+		Expect(err).NotTo(HaveOccurred())
+		err = TransformConfigTemplatesWithApi(&bootstrapConfig, &mockDownward{nodeIp: "10.0.0.1"})
+		Expect(err).NotTo(HaveOccurred())
+
+		var dogStatsd envoy_metrics.DogStatsdSink
+		err = ptypes.UnmarshalAny(bootstrapConfig.StatsSinks[0].GetTypedConfig(), &dogStatsd)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dogStatsd.GetAddress().GetSocketAddress().Address).To(Equal("10.0.0.1"))
+	})
+
+	It("should transform the addresses of the metrics service and the trace collector", func() {
+		e := api.Envoy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "myingress",
+			},
+			Spec: api.EnvoySpec{
+				ADSServer:         "test.blah.com",
+				ADSPort:           1234,
+				ClusterIdTemplate: "soloio",
+				NodeIdTemplate:    "soloio",
+				Stats: &api.StatsSpec{
+					MetricsService: &api.MetricsServiceSinkSpec{Address: "{{.NodeIp}}", Port: 9000},
+				},
+				Tracing: &api.TracingSpec{
+					Zipkin: &api.ZipkinTracerSpec{Collector: api.TracingCollectorSpec{Address: "{{.NodeIp}}", Port: 9411}},
+				},
+			},
+		}
+		cfg, err := kube.GenerateEnvoyConfig(&e, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		var bootstrapConfig envoy_config_bootstrap.Bootstrap
+		err = jsonpb.UnmarshalString(cfg, &bootstrapConfig)
+		Expect(err).NotTo(HaveOccurred())
+		err = TransformConfigTemplatesWithApi(&bootstrapConfig, &mockDownward{nodeIp: "10.0.0.1"})
+		Expect(err).NotTo(HaveOccurred())
+
+		addresses := map[string]string{}
+		for _, c := range bootstrapConfig.StaticResources.Clusters {
+			addresses[c.Name] = c.LoadAssignment.Endpoints[0].LbEndpoints[0].GetEndpoint().Address.GetSocketAddress().Address
+		}
+		Expect(addresses).To(HaveKeyWithValue("metrics-service", "10.0.0.1"))
+		Expect(addresses).To(HaveKeyWithValue("tracing-collector", "10.0.0.1"))
+		Expect(addresses).To(HaveKeyWithValue("ads-control-plane", "test.blah.com"))
+	})
+
+	It("should read grpc credentials from env vars", func() {
+		e := api.Envoy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "myingress",
+			},
+			Spec: api.EnvoySpec{
+				ADSServer:         "test.blah.com",
+				ADSPort:           1234,
+				ClusterIdTemplate: "soloio",
+				NodeIdTemplate:    "soloio",
+				ControlPlane: &api.ControlPlaneSpec{
+					GRPC: &api.GRPCClientSpec{
+						InitialMetadata: []api.GRPCHeader{{
+							Key:       "authorization",
+							ValueFrom: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "ads"}, Key: "header"},
+						}},
+						Google: &api.GoogleGRPCSpec{
+							CallCredentials: []api.CallCredentialsSpec{{
+								AccessToken: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "ads"}, Key: "token"},
+							}},
+						},
+					},
+				},
+			},
+		}
+		cfg, err := kube.GenerateEnvoyConfig(&e, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).NotTo(ContainSubstring("Bearer"))
+
+		os.Setenv("GRPC_SECRET_0", "Bearer header")
+		os.Setenv("GRPC_SECRET_1", "access-token")
+		defer os.Unsetenv("GRPC_SECRET_0")
+		defer os.Unsetenv("GRPC_SECRET_1")
+
+		var bootstrapConfig envoy_config_bootstrap.Bootstrap
+		err = jsonpb.UnmarshalString(cfg, &bootstrapConfig)
+		Expect(err).NotTo(HaveOccurred())
+		err = TransformConfigTemplatesWithApi(&bootstrapConfig, &mockDownward{})
+		Expect(err).NotTo(HaveOccurred())
+
+		service := bootstrapConfig.DynamicResources.AdsConfig.GrpcServices[0]
+		Expect(service.InitialMetadata[0].Value).To(Equal("Bearer header"))
+		Expect(service.GetGoogleGrpc().CallCredentials[0].GetAccessToken()).To(Equal("access-token"))
+	})
+
 	Context("bootstrap transforms", func() {
 		var (
 			api             *mockDownward
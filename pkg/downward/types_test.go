@@ -0,0 +1,78 @@
+package downward_test
+
+import (
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const controlPlaneModule = "github.com/envoyproxy/go-control-plane/"
+
+// The packages of the bootstrap config's own fields, which the initializer links through the
+// bootstrap package; only the types packed in typed configs need to be imported on their own
+var bootstrapFieldPackages = []string{
+	controlPlaneModule + "envoy/config/bootstrap/",
+	controlPlaneModule + "envoy/config/cluster/",
+	controlPlaneModule + "envoy/config/core/",
+	controlPlaneModule + "envoy/config/endpoint/",
+	controlPlaneModule + "envoy/config/listener/",
+	controlPlaneModule + "envoy/config/route/",
+	controlPlaneModule + "envoy/type/",
+	// not protos
+	controlPlaneModule + "pkg/",
+}
+
+// protoImports returns the go-control-plane packages the sources of dir import
+func protoImports(dir string) map[string]bool {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	Expect(err).NotTo(HaveOccurred())
+	imports := map[string]bool{}
+	for _, f := range files {
+		if strings.HasSuffix(f, "_test.go") {
+			continue
+		}
+		parsed, err := parser.ParseFile(token.NewFileSet(), f, nil, parser.ImportsOnly)
+		Expect(err).NotTo(HaveOccurred())
+		for _, spec := range parsed.Imports {
+			path, err := strconv.Unquote(spec.Path.Value)
+			Expect(err).NotTo(HaveOccurred())
+			if strings.HasPrefix(path, controlPlaneModule) {
+				imports[path] = true
+			}
+		}
+	}
+	return imports
+}
+
+func isBootstrapField(p string) bool {
+	for _, prefix := range bootstrapFieldPackages {
+		if strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+var _ = Describe("Types", func() {
+
+	// the operator validates the bootstrap overlay with its registry, so the initializer needs
+	// to know every type the operator does to parse the bootstrap config
+	It("should know every type the operator knows", func() {
+		operator := protoImports("../kube")
+		initializer := protoImports(".")
+		Expect(operator).NotTo(BeEmpty())
+
+		var missing []string
+		for p := range operator {
+			if !initializer[p] && !isBootstrapField(p) {
+				missing = append(missing, p)
+			}
+		}
+		Expect(missing).To(BeEmpty(), "add the missing packages to pkg/downward/types.go")
+	})
+})
@@ -0,0 +1,80 @@
+package envoy
+
+import (
+	"fmt"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	"github.com/solo-io/envoy-operator/pkg/kube"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// certManagerInstalled returns true if the cert-manager Certificate crd exists
+func certManagerInstalled() bool {
+	return resourceInstalled(kube.CertManagerAPIVersion, kube.CertificateKind)
+}
+
+// syncCertificate creates the cert-manager Certificate of the envoy's client certificate, and
+// deletes it once the envoy no longer has an issuerRef. Certificates of the same name the envoy
+// doesn't control are left alone.
+func syncCertificate(e *api.Envoy) error {
+	if e.Spec.TLS == nil || e.Spec.TLS.IssuerRef == nil {
+		if !certManagerInstalled() {
+			return nil
+		}
+		return deleteIfExists(e, kube.EmptyCertificate(e))
+	}
+	if !certManagerInstalled() {
+		return fmt.Errorf("the tls issuerRef needs cert-manager, which is not installed")
+	}
+
+	desired, err := kube.CertificateForEnvoy(e)
+	if err != nil {
+		return err
+	}
+	live := kube.EmptyCertificate(e)
+	err = getObject(live)
+	if apierrors.IsNotFound(err) {
+		addOwnerRefToObject(desired, asOwner(&e.ObjectMeta))
+		if err := createObject(desired); err != nil {
+			return fmt.Errorf("failed to create certificate (%s): %v", desired.GetName(), err)
+		}
+		recordEvent(e, v1.EventTypeNormal, "CertificateCreated", "Created certificate %s", desired.GetName())
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get certificate (%s): %v", live.GetName(), err)
+	}
+	if err := checkOwner(e, live); err != nil {
+		return fmt.Errorf("failed to update certificate (%s): %v", live.GetName(), err)
+	}
+
+	needsUpdate, err := kube.CertificateNeedsUpdate(desired, live)
+	if err != nil {
+		return fmt.Errorf("failed to compare certificate (%s): %v", live.GetName(), err)
+	}
+	if !needsUpdate {
+		return nil
+	}
+	kube.UpdateCertificate(desired, live)
+	if err := updateObject(live); err != nil {
+		return fmt.Errorf("failed to update certificate (%s): %v", live.GetName(), err)
+	}
+	return nil
+}
+
+// certificateNotReady returns why the envoy's Certificate isn't ready, or "" if it is or can't
+// be read
+func certificateNotReady(e *api.Envoy) string {
+	if e.Spec.TLS == nil || e.Spec.TLS.IssuerRef == nil {
+		return ""
+	}
+	live := kube.EmptyCertificate(e)
+	if err := getObject(live); err != nil {
+		return ""
+	}
+	if ready, message := kube.CertificateReady(live); !ready {
+		return message
+	}
+	return ""
+}
@@ -0,0 +1,108 @@
+package envoy_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	. "github.com/solo-io/envoy-operator/pkg/envoy"
+	"github.com/solo-io/envoy-operator/pkg/kube"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("Certificate", func() {
+	var (
+		cluster *fakeCluster
+		e       *api.Envoy
+	)
+
+	BeforeEach(func() {
+		cluster = newFakeCluster()
+		e = testEnvoy()
+		e.Spec.TLS = &api.TLSSpec{IssuerRef: &api.CertIssuerRef{Name: "my-ca"}}
+	})
+
+	certificate := func() *unstructured.Unstructured {
+		u := kube.EmptyCertificate(e)
+		Expect(cluster.get(u)).To(Succeed())
+		return u
+	}
+
+	It("should create the certificate", func() {
+		Expect(SyncCertificate(e)).To(Succeed())
+		u := certificate()
+		Expect(u.GetOwnerReferences()).To(HaveLen(1))
+		Expect(u.GetOwnerReferences()[0].Name).To(Equal(e.Name))
+		issuer, _ := unstructured.NestedString(u.Object, "spec", "issuerRef", "name")
+		Expect(issuer).To(Equal("my-ca"))
+		Expect(cluster.eventReasons()).To(ConsistOf("CertificateCreated"))
+	})
+
+	It("should update the certificate when the spec changes", func() {
+		Expect(SyncCertificate(e)).To(Succeed())
+		e.Spec.TLS.Duration = &metav1.Duration{Duration: 24 * time.Hour}
+		Expect(SyncCertificate(e)).To(Succeed())
+		Expect(cluster.actions("update", "certificates")).To(HaveLen(1))
+		duration, _ := unstructured.NestedString(certificate().Object, "spec", "duration")
+		Expect(duration).To(Equal("24h0m0s"))
+	})
+
+	It("should not update an unchanged certificate", func() {
+		Expect(SyncCertificate(e)).To(Succeed())
+		Expect(SyncCertificate(e)).To(Succeed())
+		Expect(cluster.actions("update", "certificates")).To(BeEmpty())
+	})
+
+	It("should delete the certificate without an issuerRef", func() {
+		Expect(SyncCertificate(e)).To(Succeed())
+		e.Spec.TLS = nil
+		Expect(SyncCertificate(e)).To(Succeed())
+		Expect(cluster.actions("delete", "certificates")).To(HaveLen(1))
+		Expect(errors.IsNotFound(cluster.get(kube.EmptyCertificate(e)))).To(BeTrue())
+	})
+
+	Context("with a certificate of others", func() {
+		BeforeEach(func() {
+			u := kube.EmptyCertificate(e)
+			u.Object["spec"] = map[string]interface{}{"secretName": "other"}
+			cluster.create(u)
+		})
+
+		It("should not update it", func() {
+			Expect(SyncCertificate(e)).To(MatchError(ContainSubstring("isn't controlled by the envoy")))
+			Expect(cluster.actions("update", "certificates")).To(BeEmpty())
+			secretName, _ := unstructured.NestedString(certificate().Object, "spec", "secretName")
+			Expect(secretName).To(Equal("other"))
+		})
+
+		It("should not delete it", func() {
+			e.Spec.TLS = nil
+			Expect(SyncCertificate(e)).To(MatchError(ContainSubstring("isn't controlled by the envoy")))
+			Expect(cluster.actions("delete", "certificates")).To(BeEmpty())
+		})
+
+		It("should keep reconciling the envoy", func() {
+			e.Spec.TLS = nil
+			cluster.create(e)
+			Expect(Reconcile(e)).To(Succeed())
+			Expect(cluster.actions("delete", "certificates")).To(BeEmpty())
+		})
+	})
+
+	It("should need cert-manager for an issuerRef", func() {
+		cluster = newFakeCluster("Certificate")
+		Expect(SyncCertificate(e)).To(MatchError(ContainSubstring("cert-manager")))
+	})
+
+	It("should do nothing without cert-manager nor an issuerRef", func() {
+		cluster = newFakeCluster("Certificate")
+		e.Spec.TLS = nil
+		Expect(SyncCertificate(e)).To(Succeed())
+		Expect(cluster.Actions()).To(BeEmpty())
+	})
+})
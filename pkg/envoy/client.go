@@ -0,0 +1,102 @@
+package envoy
+
+import (
+	"fmt"
+
+	"github.com/operator-framework/operator-sdk/pkg/sdk/types"
+	"github.com/operator-framework/operator-sdk/pkg/util/k8sutil"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+// ResourceClientFunc returns the dynamic client of the resources of the kind in the namespace,
+// and fails if the api server doesn't serve the kind, like the sdk's k8sclient.GetResourceClient
+type ResourceClientFunc func(apiVersion, kind, namespace string) (dynamic.ResourceInterface, string, error)
+
+// Clients are how the reconciler talks to the api server. The sdk's clients need a cluster as
+// soon as they are imported, so the operator sets them, and tests set fakes.
+type Clients struct {
+	Resources ResourceClientFunc
+	// A client of the envoy api group, for the status sub resource the dynamic clients can't
+	// reach. The whole envoy is updated if nil.
+	Status rest.Interface
+}
+
+var clients Clients
+
+// SetClients sets the clients of the reconciler
+func SetClients(c Clients) {
+	clients = c
+}
+
+// resourceInstalled returns true if the api server serves the kind, e.g. the kind of a crd
+func resourceInstalled(apiVersion, kind string) bool {
+	_, _, err := clients.Resources(apiVersion, kind, "")
+	return err == nil
+}
+
+// resourceClientFor returns the dynamic client of the object's resources, and the object's name
+func resourceClientFor(o types.Object) (dynamic.ResourceInterface, string, error) {
+	name, namespace, err := k8sutil.GetNameAndNamespace(o)
+	if err != nil {
+		return nil, "", err
+	}
+	gvk := o.GetObjectKind().GroupVersionKind()
+	apiVersion, kind := gvk.ToAPIVersionAndKind()
+	client, _, err := clients.Resources(apiVersion, kind, namespace)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get resource client: %v", err)
+	}
+	return client, name, nil
+}
+
+// getObject reads the object named by o into o, like the sdk's query.Get
+func getObject(o types.Object) error {
+	client, name, err := resourceClientFor(o)
+	if err != nil {
+		return err
+	}
+	u, err := client.Get(name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	return k8sutil.UnstructuredIntoRuntimeObject(u, o)
+}
+
+// createObject creates o, and updates it with the result, like the sdk's action.Create
+func createObject(o types.Object) error {
+	return writeObject(o, func(client dynamic.ResourceInterface, u *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+		return client.Create(u)
+	})
+}
+
+// updateObject updates o, and updates it with the result, like the sdk's action.Update
+func updateObject(o types.Object) error {
+	return writeObject(o, func(client dynamic.ResourceInterface, u *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+		return client.Update(u)
+	})
+}
+
+func writeObject(o types.Object, write func(dynamic.ResourceInterface, *unstructured.Unstructured) (*unstructured.Unstructured, error)) error {
+	client, _, err := resourceClientFor(o)
+	if err != nil {
+		return err
+	}
+	u, err := write(client, k8sutil.UnstructuredFromRuntimeObject(o))
+	if err != nil {
+		return err
+	}
+	return k8sutil.UnstructuredIntoRuntimeObject(u, o)
+}
+
+// deleteObject deletes o, like the sdk's action.Delete
+func deleteObject(o types.Object) error {
+	client, name, err := resourceClientFor(o)
+	if err != nil {
+		return err
+	}
+	return client.Delete(name, &metav1.DeleteOptions{})
+}
@@ -1,11 +1,12 @@
 package envoy
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"path/filepath"
-
-	"github.com/operator-framework/operator-sdk/pkg/sdk/action"
-	"github.com/operator-framework/operator-sdk/pkg/sdk/query"
+	"reflect"
+	"sort"
+	"time"
 
 	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
 	"github.com/solo-io/envoy-operator/pkg/kube"
@@ -14,32 +15,126 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-func prepareEnvoyConfig(e *api.Envoy) error {
-	var tlsSecret *v1.Secret
-	if e.Spec.TLSSecretName != "" {
-		sec := &v1.Secret{
-			TypeMeta: metav1.TypeMeta{
-				Kind:       "Secret",
-				APIVersion: "v1",
-			},
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      e.Spec.TLSSecretName,
-				Namespace: e.Namespace,
-			},
-		}
-		err := query.Get(sec)
-		if err != nil {
-			return err
+// getTLSSecret returns the secret referenced by the envoy, or nil if it doesn't reference one. It
+// returns an error if the secret can't be used, rather than render a broken bootstrap config.
+func getTLSSecret(e *api.Envoy) (*v1.Secret, error) {
+	if kube.TLSSecretNameForEnvoy(e) == "" {
+		return nil, nil
+	}
+	sec := &v1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      kube.TLSSecretNameForEnvoy(e),
+			Namespace: e.Namespace,
+		},
+	}
+	err := getObject(sec)
+	if err != nil {
+		return nil, err
+	}
+	if err := kube.ValidateTLSSecret(sec, time.Now()); err != nil {
+		return nil, err
+	}
+	return sec, nil
+}
+
+// setTLSSecretCondition reports whether the tls secret can be used in the status, and records an
+// event when it stops being usable or its problem changes
+func setTLSSecretCondition(e *api.Envoy, status *api.EnvoyStatus, err error) {
+	if kube.TLSSecretNameForEnvoy(e) == "" {
+		status.RemoveCondition(api.EnvoyConditionTLSSecretValid)
+		return
+	}
+	if err == nil {
+		status.SetCondition(api.EnvoyConditionTLSSecretValid, true, "Valid", "")
+		return
+	}
+	reason := "InvalidTLSSecret"
+	if apierrors.IsNotFound(err) {
+		reason = "TLSSecretNotFound"
+		if message := certificateNotReady(e); message != "" {
+			reason = "CertificateNotReady"
+			err = fmt.Errorf("%v: certificate not issued yet: %s", err, message)
 		}
-		tlsSecret = sec
+	}
+	if c := status.GetCondition(api.EnvoyConditionTLSSecretValid); c == nil || c.Status != v1.ConditionFalse || c.Message != err.Error() {
+		recordEvent(e, v1.EventTypeWarning, reason, "TLS secret %s can't be used: %v", kube.TLSSecretNameForEnvoy(e), err)
+	}
+	status.SetCondition(api.EnvoyConditionTLSSecretValid, false, reason, err.Error())
+}
 
+// getBootstrapOverlay returns the bootstrap overlay of the envoy, or "" if it has none
+func getBootstrapOverlay(e *api.Envoy) (string, error) {
+	spec := e.Spec.BootstrapOverlay
+	if spec == nil {
+		return "", nil
+	}
+	if spec.ConfigMapRef == nil {
+		return spec.Inline, nil
+	}
+	if spec.Inline != "" {
+		return "", fmt.Errorf("bootstrap overlay can't be both inline and in a config map")
 	}
 
-	cfgData, err := kube.GenerateEnvoyConfig(e, tlsSecret)
+	ref := spec.ConfigMapRef
+	cm := &v1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ref.Name,
+			Namespace: e.Namespace,
+		},
+	}
+	err := getObject(cm)
+	if apierrors.IsNotFound(err) && ref.Optional != nil && *ref.Optional {
+		return "", nil
+	}
 	if err != nil {
-		return err
+		return "", fmt.Errorf("failed to get bootstrap overlay config map (%s): %v", ref.Name, err)
 	}
+	overlay, ok := cm.Data[ref.Key]
+	if !ok && (ref.Optional == nil || !*ref.Optional) {
+		return "", fmt.Errorf("bootstrap overlay config map (%s) has no key %s", ref.Name, ref.Key)
+	}
+	return overlay, nil
+}
 
+// renderEnvoyConfig generates the bootstrap config of the envoy and applies its overlay. The
+// reason is set when rendering failed.
+func renderEnvoyConfig(e *api.Envoy, tlsSecret *v1.Secret) (cfgData string, reason string, err error) {
+	cfgData, err = kube.GenerateEnvoyConfig(e, tlsSecret)
+	if err != nil {
+		return "", "RenderFailed", err
+	}
+	overlay, err := getBootstrapOverlay(e)
+	if err != nil {
+		return "", "BootstrapOverlayError", err
+	}
+	cfgData, err = kube.ApplyBootstrapOverlay(cfgData, overlay)
+	if err != nil {
+		return "", "InvalidBootstrapOverlay", err
+	}
+	return cfgData, "", nil
+}
+
+// RenderBootstrap renders the bootstrap config of the envoy
+func RenderBootstrap(e *api.Envoy) (string, error) {
+	tlsSecret, err := getTLSSecret(e)
+	if err != nil {
+		return "", err
+	}
+	cfgData, _, err := renderEnvoyConfig(e, tlsSecret)
+	return cfgData, err
+}
+
+// prepareEnvoyConfig writes the bootstrap config into the envoy's config map, and returns the
+// hash of the config
+func prepareEnvoyConfig(e *api.Envoy, cfgData string) (string, error) {
 	cm := &v1.ConfigMap{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "ConfigMap",
@@ -47,18 +142,109 @@ func prepareEnvoyConfig(e *api.Envoy) error {
 		},
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: e.Namespace,
-			Name:      configMapNameForEnvoy(e),
+			Name:      kube.ConfigMapNameForEnvoy(e),
 		},
 	}
 
-	cm.Labels = labelsForEnvoy(e)
+	cm.Labels = kube.LabelsForEnvoy(e)
 
-	cm.Data = map[string]string{filepath.Base(envoyConfigFilePath): cfgData}
+	cm.Data = map[string]string{filepath.Base(kube.EnvoyConfigFilePath): cfgData}
 	addOwnerRefToObject(cm, asOwner(&e.ObjectMeta))
 
-	// TODO: check if config map changed?
-	if err := action.Create(cm); err != nil && !apierrors.IsAlreadyExists(err) {
-		return fmt.Errorf("prepare envoy config error: create new configmap (%s) failed: %v", cm.Name, err)
+	err := createObject(cm)
+	if apierrors.IsAlreadyExists(err) {
+		err = syncConfigMap(e, cm)
+	} else if err != nil {
+		err = fmt.Errorf("prepare envoy config error: create new configmap (%s) failed: %v", cm.Name, err)
+	}
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(cfgData))), nil
+}
+
+// hashSecret returns a hash of the secret's data, so that pods can be rolled when it changes
+func hashSecret(s *v1.Secret) string {
+	keys := make([]string, 0, len(s.Data))
+	for k := range s.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, k := range keys {
+		h.Write([]byte(k))
+		h.Write([]byte{0})
+		h.Write(s.Data[k])
+		h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// hashGRPCSecrets returns a hash of the secret values the initializer writes into the bootstrap
+// config, so that pods can be rolled when they rotate. It's empty if the envoy references none.
+func hashGRPCSecrets(e *api.Envoy) (string, error) {
+	refs := kube.GRPCSecretRefs(e)
+	if len(refs) == 0 {
+		return "", nil
+	}
+	h := sha256.New()
+	for _, ref := range refs {
+		sec := &v1.Secret{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Secret",
+				APIVersion: "v1",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      ref.Name,
+				Namespace: e.Namespace,
+			},
+		}
+		// the pods don't start without the secrets, unless they're optional
+		optional := ref.Optional != nil && *ref.Optional
+		err := getObject(sec)
+		if apierrors.IsNotFound(err) && optional {
+			err = nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("get grpc secret %s failed: %v", ref.Name, err)
+		}
+		value, ok := sec.Data[ref.Key]
+		if !ok && !optional {
+			return "", fmt.Errorf("grpc secret %s has no key %s", ref.Name, ref.Key)
+		}
+		h.Write([]byte(ref.Name))
+		h.Write([]byte{0})
+		h.Write([]byte(ref.Key))
+		h.Write([]byte{0})
+		h.Write(value)
+		h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// syncConfigMap updates the existing config map if its data differs from the desired config map
+func syncConfigMap(e *api.Envoy, desired *v1.ConfigMap) error {
+	cm := &v1.ConfigMap{
+		TypeMeta: desired.TypeMeta,
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: desired.Namespace,
+			Name:      desired.Name,
+		},
+	}
+	err := getObject(cm)
+	if err != nil {
+		return fmt.Errorf("prepare envoy config error: get configmap (%s) failed: %v", cm.Name, err)
+	}
+
+	if reflect.DeepEqual(cm.Data, desired.Data) {
+		return nil
+	}
+	cm.Data = desired.Data
+	err = updateObject(cm)
+	if err != nil {
+		return fmt.Errorf("prepare envoy config error: update configmap (%s) failed: %v", cm.Name, err)
 	}
+	recordEvent(e, v1.EventTypeNormal, "ConfigUpdated", "Updated bootstrap config in configmap %s", cm.Name)
 	return nil
 }
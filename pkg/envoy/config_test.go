@@ -0,0 +1,84 @@
+package envoy_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	. "github.com/solo-io/envoy-operator/pkg/envoy"
+	"github.com/solo-io/envoy-operator/pkg/kube"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func configMapOf(e *api.Envoy) *v1.ConfigMap {
+	return &v1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: kube.ConfigMapNameForEnvoy(e), Namespace: e.Namespace},
+	}
+}
+
+var _ = Describe("Config", func() {
+	var (
+		cluster *fakeCluster
+		e       *api.Envoy
+	)
+
+	BeforeEach(func() {
+		cluster = newFakeCluster("Certificate", "ServiceMonitor", "PodMonitor")
+		e = testEnvoy()
+		cluster.create(e)
+	})
+
+	Context("syncConfigMap", func() {
+		BeforeEach(func() {
+			cm := configMapOf(e)
+			cm.Data = map[string]string{"envoy.json": "old"}
+			cluster.create(cm)
+		})
+
+		It("should update a config map that drifted", func() {
+			desired := configMapOf(e)
+			desired.Data = map[string]string{"envoy.json": "new"}
+			Expect(SyncConfigMap(e, desired)).To(Succeed())
+
+			live := configMapOf(e)
+			Expect(cluster.get(live)).To(Succeed())
+			Expect(live.Data).To(Equal(desired.Data))
+			Expect(cluster.eventReasons()).To(ConsistOf("ConfigUpdated"))
+		})
+
+		It("should leave an up to date config map alone", func() {
+			desired := configMapOf(e)
+			desired.Data = map[string]string{"envoy.json": "old"}
+			Expect(SyncConfigMap(e, desired)).To(Succeed())
+
+			Expect(cluster.actions("update", "configmaps")).To(BeEmpty())
+			Expect(cluster.eventReasons()).To(BeEmpty())
+		})
+	})
+
+	Context("reconcile", func() {
+		It("should render the changed spec into the existing config map", func() {
+			Expect(Reconcile(e)).To(Succeed())
+			before := configMapOf(e)
+			Expect(cluster.get(before)).To(Succeed())
+			Expect(before.Data["envoy.json"]).To(ContainSubstring("ads.solo.io"))
+
+			e.Spec.ADSServer = "ads.example.com"
+			Expect(Reconcile(e)).To(Succeed())
+			after := configMapOf(e)
+			Expect(cluster.get(after)).To(Succeed())
+			Expect(after.Data["envoy.json"]).To(ContainSubstring("ads.example.com"))
+			Expect(after.Data["envoy.json"]).NotTo(ContainSubstring("ads.solo.io"))
+			Expect(cluster.eventReasons()).To(ContainElement("ConfigUpdated"))
+		})
+
+		It("should not update the config map of an unchanged spec", func() {
+			Expect(Reconcile(e)).To(Succeed())
+			Expect(Reconcile(e)).To(Succeed())
+			Expect(cluster.actions("update", "configmaps")).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,76 @@
+package envoy
+
+import (
+	"fmt"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	"github.com/solo-io/envoy-operator/pkg/kube"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func deployEnvoyDaemonSet(e *api.Envoy, podAnnotations map[string]string) error {
+	ds, err := kube.DaemonSetForEnvoy(e, podAnnotations)
+	if err != nil {
+		return err
+	}
+
+	addOwnerRefToObject(ds, asOwner(&e.ObjectMeta))
+	err = createObject(ds)
+	if apierrors.IsAlreadyExists(err) {
+		return syncDaemonSet(e, ds)
+	}
+	return err
+}
+
+func emptyDaemonSet(e *api.Envoy) *appsv1.DaemonSet {
+	return &appsv1.DaemonSet{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "DaemonSet",
+			APIVersion: "apps/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      e.GetName(),
+			Namespace: e.GetNamespace(),
+		}}
+}
+
+// syncDaemonSet updates the existing envoy daemon set if it drifted from the desired one
+func syncDaemonSet(e *api.Envoy, desired *appsv1.DaemonSet) error {
+	ds := emptyDaemonSet(e)
+	err := getObject(ds)
+	if err != nil {
+		return fmt.Errorf("failed to get daemon set (%s): %v", ds.Name, err)
+	}
+	if err := checkOwner(e, ds); err != nil {
+		return fmt.Errorf("failed to update daemon set (%s): %v", ds.Name, err)
+	}
+
+	needsUpdate, err := kube.DaemonSetNeedsUpdate(desired, ds)
+	if err != nil {
+		return fmt.Errorf("failed to compare daemon set (%s): %v", ds.Name, err)
+	}
+	if !needsUpdate {
+		return nil
+	}
+
+	kube.UpdateDaemonSet(desired, ds)
+	err = updateObject(ds)
+	if err != nil {
+		return fmt.Errorf("failed to update daemon set (%s): %v", ds.Name, err)
+	}
+	return nil
+}
+
+// daemonSetStatus copies the scheduled and ready counts of the envoy daemon set to the status
+func daemonSetStatus(e *api.Envoy, status *api.EnvoyStatus) error {
+	ds := emptyDaemonSet(e)
+	err := getObject(ds)
+	if err != nil {
+		return fmt.Errorf("failed to get daemon set (%s): %v", ds.Name, err)
+	}
+	setReplicaStatus(status, ds.Status.DesiredNumberScheduled, ds.Status.NumberReady, ds.Status.UpdatedNumberScheduled)
+	return nil
+}
@@ -0,0 +1,142 @@
+package envoy_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/operator-framework/operator-sdk/pkg/sdk/types"
+	"github.com/operator-framework/operator-sdk/pkg/util/k8sutil"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	"github.com/solo-io/envoy-operator/pkg/envoy"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/fake"
+	kubetesting "k8s.io/client-go/testing"
+)
+
+func TestEnvoy(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Envoy Suite")
+}
+
+func testEnvoy() *api.Envoy {
+	e := &api.Envoy{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: api.SchemeGroupVersion.String(),
+			Kind:       "Envoy",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "myenvoy",
+			Namespace:  "default",
+			UID:        "myenvoy-uid",
+			Generation: 1,
+		},
+		Spec: api.EnvoySpec{
+			ADSServer:         "ads.solo.io",
+			ADSPort:           1234,
+			ClusterIdTemplate: "ingress",
+			NodeIdTemplate:    "{{.PodName}}-ingress",
+		},
+	}
+	e.SetDefaults()
+	return e
+}
+
+// fakeCluster is a fake api server for the reconciler's dynamic clients
+type fakeCluster struct {
+	*kubetesting.Fake
+	// kinds the api server doesn't serve, e.g. the kinds of crds that aren't installed
+	missing map[string]bool
+}
+
+// newFakeCluster returns a fake api server, and sets the reconciler's clients to it
+func newFakeCluster(missingKinds ...string) *fakeCluster {
+	tracker := kubetesting.NewObjectTracker(runtime.NewScheme(), unstructured.UnstructuredJSONScheme)
+	c := &fakeCluster{Fake: &kubetesting.Fake{}, missing: map[string]bool{}}
+	c.AddReactor("*", "*", kubetesting.ObjectReaction(tracker))
+	for _, kind := range missingKinds {
+		c.missing[kind] = true
+	}
+	envoy.SetClients(envoy.Clients{Resources: c.resources})
+	return c
+}
+
+func (c *fakeCluster) resources(apiVersion, kind, namespace string) (dynamic.ResourceInterface, string, error) {
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return nil, "", err
+	}
+	if c.missing[kind] {
+		return nil, "", errors.NewNotFound(gv.WithResource(kind).GroupResource(), kind)
+	}
+	resource := &metav1.APIResource{Name: strings.ToLower(kind) + "s", Kind: kind, Namespaced: true}
+	client := &fake.FakeClient{GroupVersion: gv, Fake: c.Fake}
+	return client.Resource(resource, namespace), resource.Name, nil
+}
+
+// get reads the object named by o into o
+func (c *fakeCluster) get(o types.Object) error {
+	client, _, err := c.resourcesOf(o)
+	if err != nil {
+		return err
+	}
+	u, err := client.Get(o.(metav1.Object).GetName(), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	return k8sutil.UnstructuredIntoRuntimeObject(u, o)
+}
+
+// create adds the object to the cluster
+func (c *fakeCluster) create(o types.Object) {
+	client, _, err := c.resourcesOf(o)
+	Expect(err).NotTo(HaveOccurred())
+	_, err = client.Create(k8sutil.UnstructuredFromRuntimeObject(o))
+	Expect(err).NotTo(HaveOccurred())
+}
+
+// update replaces the object in the cluster
+func (c *fakeCluster) update(o types.Object) {
+	client, _, err := c.resourcesOf(o)
+	Expect(err).NotTo(HaveOccurred())
+	_, err = client.Update(k8sutil.UnstructuredFromRuntimeObject(o))
+	Expect(err).NotTo(HaveOccurred())
+}
+
+func (c *fakeCluster) resourcesOf(o types.Object) (dynamic.ResourceInterface, string, error) {
+	gvk := o.GetObjectKind().GroupVersionKind()
+	apiVersion, kind := gvk.ToAPIVersionAndKind()
+	return c.resources(apiVersion, kind, o.(metav1.Object).GetNamespace())
+}
+
+// actions returns the requests of the verb the cluster got for the resource, e.g. "update" and
+// "configmaps"
+func (c *fakeCluster) actions(verb, resource string) []kubetesting.Action {
+	var actions []kubetesting.Action
+	for _, a := range c.Actions() {
+		if a.GetVerb() == verb && a.GetResource().Resource == resource {
+			actions = append(actions, a)
+		}
+	}
+	return actions
+}
+
+// eventReasons returns the reasons of the events the reconciler recorded
+func (c *fakeCluster) eventReasons() []string {
+	var reasons []string
+	for _, a := range c.actions("create", "events") {
+		u := a.(kubetesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		reason, _ := u.Object["reason"].(string)
+		reasons = append(reasons, reason)
+	}
+	return reasons
+}
@@ -0,0 +1,252 @@
+package envoy_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	. "github.com/solo-io/envoy-operator/pkg/envoy"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	configChecksum = "envoy.solo.io/config-checksum"
+	tlsChecksum    = "envoy.solo.io/tls-checksum"
+	grpcChecksum   = "envoy.solo.io/grpc-secrets-checksum"
+)
+
+// selfSignedCA returns a pem encoded self signed ca certificate
+func selfSignedCA() []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func tlsSecretOf(e *api.Envoy) *v1.Secret {
+	return &v1.Secret{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{Name: e.Spec.TLSSecretName, Namespace: e.Namespace},
+	}
+}
+
+func deploymentOf(e *api.Envoy) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Name: e.Name, Namespace: e.Namespace},
+	}
+}
+
+var _ = Describe("Pod annotations", func() {
+
+	Context("hashSecret", func() {
+		secret := func(data map[string]string) *v1.Secret {
+			s := &v1.Secret{Data: map[string][]byte{}}
+			for k, v := range data {
+				s.Data[k] = []byte(v)
+			}
+			return s
+		}
+
+		It("should not depend on the order of the keys", func() {
+			for i := 0; i < 10; i++ {
+				Expect(HashSecret(secret(map[string]string{"ca.crt": "ca", "tls.crt": "cert", "tls.key": "key"}))).
+					To(Equal(HashSecret(secret(map[string]string{"tls.key": "key", "tls.crt": "cert", "ca.crt": "ca"}))))
+			}
+		})
+
+		It("should change with the data", func() {
+			Expect(HashSecret(secret(map[string]string{"ca.crt": "ca"}))).
+				NotTo(Equal(HashSecret(secret(map[string]string{"ca.crt": "rotated ca"}))))
+		})
+
+		It("should change with the keys", func() {
+			Expect(HashSecret(secret(map[string]string{"tls.crt": "cert"}))).
+				NotTo(Equal(HashSecret(secret(map[string]string{"tls.cert": "cert"}))))
+			Expect(HashSecret(secret(map[string]string{"a": "bc"}))).
+				NotTo(Equal(HashSecret(secret(map[string]string{"ab": "c"}))))
+		})
+	})
+
+	Context("podAnnotationsForEnvoy", func() {
+		It("should stamp the config checksum", func() {
+			annotations := PodAnnotationsForEnvoy(testEnvoy(), "abc", nil, "")
+			Expect(annotations).To(Equal(map[string]string{configChecksum: "abc"}))
+		})
+
+		It("should stamp the checksum of the tls secret", func() {
+			s := &v1.Secret{Data: map[string][]byte{api.TLSCA: []byte("ca")}}
+			annotations := PodAnnotationsForEnvoy(testEnvoy(), "abc", s, "")
+			Expect(annotations).To(HaveKeyWithValue(tlsChecksum, HashSecret(s)))
+		})
+
+		It("should leave the rotation to the sds server", func() {
+			e := testEnvoy()
+			e.Spec.SDS = &api.SDSSpec{Server: &api.SDSServerSpec{Address: "127.0.0.1", Port: 8234}}
+			s := &v1.Secret{Data: map[string][]byte{api.TLSCA: []byte("ca")}}
+			annotations := PodAnnotationsForEnvoy(e, "abc", s, "")
+			Expect(annotations).NotTo(HaveKey(tlsChecksum))
+		})
+
+		It("should stamp the checksum of the grpc secrets", func() {
+			annotations := PodAnnotationsForEnvoy(testEnvoy(), "abc", nil, "def")
+			Expect(annotations).To(HaveKeyWithValue(grpcChecksum, "def"))
+		})
+	})
+
+	Context("reconcile", func() {
+		var (
+			cluster *fakeCluster
+			e       *api.Envoy
+		)
+
+		BeforeEach(func() {
+			cluster = newFakeCluster("Certificate", "ServiceMonitor", "PodMonitor")
+			e = testEnvoy()
+			e.Spec.TLSSecretName = "certs"
+			cluster.create(e)
+			s := tlsSecretOf(e)
+			s.Data = map[string][]byte{api.TLSCA: selfSignedCA()}
+			cluster.create(s)
+			Expect(Reconcile(e)).To(Succeed())
+		})
+
+		podAnnotations := func() map[string]string {
+			d := deploymentOf(e)
+			Expect(cluster.get(d)).To(Succeed())
+			return d.Spec.Template.Annotations
+		}
+
+		It("should roll the pods when the config changes", func() {
+			before := podAnnotations()
+			Expect(before).To(HaveKey(configChecksum))
+
+			e.Spec.ADSServer = "ads.example.com"
+			Expect(Reconcile(e)).To(Succeed())
+			after := podAnnotations()
+			Expect(after[configChecksum]).NotTo(Equal(before[configChecksum]))
+			Expect(after[tlsChecksum]).To(Equal(before[tlsChecksum]))
+			Expect(cluster.actions("update", "deployments")).To(HaveLen(1))
+		})
+
+		It("should roll the pods when the tls secret changes", func() {
+			before := podAnnotations()
+			Expect(before).To(HaveKey(tlsChecksum))
+
+			s := tlsSecretOf(e)
+			Expect(cluster.get(s)).To(Succeed())
+			s.Data[api.TLSCA] = selfSignedCA()
+			cluster.update(s)
+
+			Expect(Reconcile(e)).To(Succeed())
+			after := podAnnotations()
+			Expect(after[tlsChecksum]).NotTo(Equal(before[tlsChecksum]))
+			Expect(after[configChecksum]).To(Equal(before[configChecksum]))
+			Expect(cluster.actions("update", "deployments")).To(HaveLen(1))
+		})
+
+		It("should not roll the pods of an unchanged envoy", func() {
+			Expect(Reconcile(e)).To(Succeed())
+			Expect(cluster.actions("update", "deployments")).To(BeEmpty())
+		})
+
+		It("should move the injection of the legacy ingress key", func() {
+			e.Spec.LegacyInjection = &api.InjectionSpec{}
+			cluster.update(e)
+			Expect(Reconcile(e)).To(Succeed())
+			stored := e.DeepCopy()
+			stored.Spec = api.EnvoySpec{}
+			Expect(cluster.get(stored)).To(Succeed())
+			Expect(stored.Spec.Injection).NotTo(BeNil())
+			Expect(stored.Spec.LegacyInjection).To(BeNil())
+		})
+	})
+
+	Context("grpc secrets", func() {
+		var (
+			cluster *fakeCluster
+			e       *api.Envoy
+			auth    *v1.Secret
+		)
+
+		BeforeEach(func() {
+			cluster = newFakeCluster("Certificate", "ServiceMonitor", "PodMonitor")
+			e = testEnvoy()
+			e.Spec.ControlPlane = &api.ControlPlaneSpec{
+				GRPC: &api.GRPCClientSpec{
+					InitialMetadata: []api.GRPCHeader{{
+						Key: "authorization",
+						ValueFrom: &v1.SecretKeySelector{
+							LocalObjectReference: v1.LocalObjectReference{Name: "ads-auth"},
+							Key:                  "header",
+						},
+					}},
+				},
+			}
+			cluster.create(e)
+			auth = &v1.Secret{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+				ObjectMeta: metav1.ObjectMeta{Name: "ads-auth", Namespace: e.Namespace},
+				Data:       map[string][]byte{"header": []byte("Bearer a")},
+			}
+		})
+
+		podAnnotations := func() map[string]string {
+			d := deploymentOf(e)
+			Expect(cluster.get(d)).To(Succeed())
+			return d.Spec.Template.Annotations
+		}
+
+		It("should roll the pods when a grpc secret rotates", func() {
+			cluster.create(auth)
+			Expect(Reconcile(e)).To(Succeed())
+			before := podAnnotations()
+			Expect(before).To(HaveKey(grpcChecksum))
+
+			auth.Data["unrelated"] = []byte("value")
+			cluster.update(auth)
+			Expect(Reconcile(e)).To(Succeed())
+			Expect(cluster.actions("update", "deployments")).To(BeEmpty())
+
+			auth.Data["header"] = []byte("Bearer b")
+			cluster.update(auth)
+			Expect(Reconcile(e)).To(Succeed())
+			after := podAnnotations()
+			Expect(after[grpcChecksum]).NotTo(Equal(before[grpcChecksum]))
+			Expect(after[configChecksum]).To(Equal(before[configChecksum]))
+			Expect(cluster.actions("update", "deployments")).To(HaveLen(1))
+		})
+
+		It("should fail without the grpc secret", func() {
+			Expect(Reconcile(e)).To(MatchError(ContainSubstring("ads-auth")))
+		})
+
+		It("should skip a missing optional grpc secret", func() {
+			optional := true
+			e.Spec.ControlPlane.GRPC.InitialMetadata[0].ValueFrom.Optional = &optional
+			Expect(Reconcile(e)).To(Succeed())
+			Expect(podAnnotations()).To(HaveKey(grpcChecksum))
+		})
+	})
+})
@@ -0,0 +1,48 @@
+package envoy
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const eventSource = "envoy-operator"
+
+// recordEvent creates a kube event for the envoy. Failing to record an event is logged but
+// otherwise ignored, as events are best effort.
+func recordEvent(e *api.Envoy, eventType, reason, messageFmt string, args ...interface{}) {
+	now := metav1.Now()
+	ev := &v1.Event{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Event",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%v.%x", e.Name, time.Now().UnixNano()),
+			Namespace: e.Namespace,
+		},
+		InvolvedObject: v1.ObjectReference{
+			Kind:            "Envoy",
+			APIVersion:      api.SchemeGroupVersion.String(),
+			Name:            e.Name,
+			Namespace:       e.Namespace,
+			UID:             e.UID,
+			ResourceVersion: e.ResourceVersion,
+		},
+		Reason:         reason,
+		Message:        fmt.Sprintf(messageFmt, args...),
+		Source:         v1.EventSource{Component: eventSource},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+		Type:           eventType,
+	}
+	if err := createObject(ev); err != nil {
+		log.Printf("failed to record event %s for envoy (%s): %v\n", reason, e.Name, err)
+	}
+}
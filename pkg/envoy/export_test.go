@@ -0,0 +1,9 @@
+package envoy
+
+var (
+	SetReplicaStatus       = setReplicaStatus
+	SyncConfigMap          = syncConfigMap
+	HashSecret             = hashSecret
+	PodAnnotationsForEnvoy = podAnnotationsForEnvoy
+	SyncCertificate        = syncCertificate
+)
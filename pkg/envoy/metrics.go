@@ -0,0 +1,109 @@
+package envoy
+
+import (
+	"fmt"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	"github.com/solo-io/envoy-operator/pkg/kube"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+var monitorKinds = []api.MetricsMonitor{api.MetricsMonitorPodMonitor, api.MetricsMonitorServiceMonitor}
+
+// monitorFor returns how the envoy's metrics are scraped, or "" if they are not. The pods of
+// injected envoys belong to other workloads, so they are left alone.
+func monitorFor(e *api.Envoy) api.MetricsMonitor {
+	if e.Spec.Metrics == nil || e.Spec.Injection != nil {
+		return ""
+	}
+	monitor := e.Spec.Metrics.Monitor
+	if monitor == api.MetricsMonitorAuto {
+		if monitorInstalled(api.MetricsMonitorPodMonitor) {
+			return api.MetricsMonitorPodMonitor
+		}
+		return api.MetricsMonitorAnnotations
+	}
+	return monitor
+}
+
+// monitorInstalled returns true if the prometheus-operator crd of the monitor kind exists
+func monitorInstalled(kind api.MetricsMonitor) bool {
+	return resourceInstalled(kube.MonitoringAPIVersion, string(kind))
+}
+
+// syncMetrics creates the monitor of the envoy, and the service a ServiceMonitor needs, and
+// deletes the ones it no longer uses. It returns a notOwnedError once it's done if it left
+// objects of others alone.
+func syncMetrics(e *api.Envoy, monitor api.MetricsMonitor) error {
+	var notOwned error
+	for _, kind := range monitorKinds {
+		if kind == monitor {
+			if err := syncMonitor(e, kind); err != nil {
+				return err
+			}
+			continue
+		}
+		if !monitorInstalled(kind) {
+			continue
+		}
+		err := deleteIfExists(e, kube.EmptyMonitor(e, kind))
+		if _, ok := err.(*notOwnedError); ok {
+			notOwned = err
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if monitor != api.MetricsMonitorServiceMonitor {
+		s := emptyService(e)
+		s.Name = kube.MetricsServiceName(e)
+		if err := deleteIfExists(e, s); err != nil {
+			return err
+		}
+		return notOwned
+	}
+	desired, err := kube.MetricsServiceForEnvoy(e)
+	if err != nil {
+		return err
+	}
+	if err := syncServiceTo(e, desired); err != nil {
+		return err
+	}
+	return notOwned
+}
+
+func syncMonitor(e *api.Envoy, kind api.MetricsMonitor) error {
+	desired, err := kube.MonitorForEnvoy(e, kind)
+	if err != nil {
+		return err
+	}
+
+	live := kube.EmptyMonitor(e, kind)
+	err = getObject(live)
+	if apierrors.IsNotFound(err) {
+		addOwnerRefToObject(desired, asOwner(&e.ObjectMeta))
+		if err := createObject(desired); err != nil {
+			return fmt.Errorf("failed to create %s (%s): %v", kind, desired.GetName(), err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get %s (%s): %v", kind, live.GetName(), err)
+	}
+	if err := checkOwner(e, live); err != nil {
+		return fmt.Errorf("failed to update %s (%s): %v", kind, live.GetName(), err)
+	}
+
+	needsUpdate, err := kube.MonitorNeedsUpdate(desired, live)
+	if err != nil {
+		return fmt.Errorf("failed to compare %s (%s): %v", kind, live.GetName(), err)
+	}
+	if !needsUpdate {
+		return nil
+	}
+	kube.UpdateMonitor(desired, live)
+	if err := updateObject(live); err != nil {
+		return fmt.Errorf("failed to update %s (%s): %v", kind, live.GetName(), err)
+	}
+	return nil
+}
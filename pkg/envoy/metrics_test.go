@@ -0,0 +1,59 @@
+package envoy_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	. "github.com/solo-io/envoy-operator/pkg/envoy"
+	"github.com/solo-io/envoy-operator/pkg/kube"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Metrics", func() {
+	var (
+		cluster *fakeCluster
+		e       *api.Envoy
+	)
+
+	BeforeEach(func() {
+		cluster = newFakeCluster("Certificate")
+		e = testEnvoy()
+		e.Spec.Metrics = &api.MetricsSpec{Monitor: api.MetricsMonitorAnnotations}
+		cluster.create(e)
+	})
+
+	It("should delete its own monitors it no longer uses", func() {
+		e.Spec.Metrics.Monitor = api.MetricsMonitorPodMonitor
+		Expect(Reconcile(e)).To(Succeed())
+		e.Spec.Metrics.Monitor = api.MetricsMonitorAnnotations
+		Expect(Reconcile(e)).To(Succeed())
+		Expect(cluster.actions("delete", "podmonitors")).To(HaveLen(1))
+	})
+
+	Context("with monitors and a service of others", func() {
+		BeforeEach(func() {
+			cluster.create(kube.EmptyMonitor(e, api.MetricsMonitorPodMonitor))
+			cluster.create(kube.EmptyMonitor(e, api.MetricsMonitorServiceMonitor))
+			cluster.create(&v1.Service{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+				ObjectMeta: metav1.ObjectMeta{Name: kube.MetricsServiceName(e), Namespace: e.Namespace},
+			})
+		})
+
+		It("should not delete them", func() {
+			Expect(Reconcile(e)).To(Succeed())
+			Expect(cluster.actions("delete", "podmonitors")).To(BeEmpty())
+			Expect(cluster.actions("delete", "servicemonitors")).To(BeEmpty())
+			Expect(cluster.actions("delete", "services")).To(BeEmpty())
+		})
+
+		It("should not take them over", func() {
+			e.Spec.Metrics.Monitor = api.MetricsMonitorPodMonitor
+			Expect(Reconcile(e)).To(MatchError(ContainSubstring("PodMonitor myenvoy isn't controlled by the envoy")))
+			Expect(cluster.actions("update", "podmonitors")).To(BeEmpty())
+		})
+	})
+})
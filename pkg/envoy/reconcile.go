@@ -2,10 +2,10 @@ package envoy
 
 import (
 	"log"
+	"strings"
 
 	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
-
-	"github.com/operator-framework/operator-sdk/pkg/sdk/action"
+	"github.com/solo-io/envoy-operator/pkg/kube"
 )
 
 // Reconcile reconciles the Envoy instance's state to the spec specified in the crd
@@ -21,27 +21,111 @@ func Reconcile(e *api.Envoy) (err error) {
 	// Simulate initializer.
 	changed := e.SetDefaults()
 	if changed {
-		return action.Update(e)
+		return updateObject(e)
+	}
+
+	status := e.Status.DeepCopy()
+	// objects with the names of the envoy's that it would delete, but doesn't control
+	var notOwned []string
+	leftAlone := func(err error) error {
+		if n, ok := err.(*notOwnedError); ok {
+			notOwned = append(notOwned, n.Error())
+			return nil
+		}
+		return err
+	}
+	defer func() {
+		if err != nil {
+			status.SetCondition(api.EnvoyConditionDegraded, true, "ReconcileFailed", err.Error())
+		} else {
+			// the status reflects the generation only once all of it is reconciled
+			status.ObservedGeneration = e.Generation
+			if len(notOwned) != 0 {
+				status.SetCondition(api.EnvoyConditionDegraded, true, "NotOwned", strings.Join(notOwned, "; "))
+			}
+		}
+		if serr := updateStatus(e, status); serr != nil && err == nil {
+			err = serr
+		}
+	}()
+
+	if err = leftAlone(syncCertificate(e)); err != nil {
+		status.SetCondition(api.EnvoyConditionTLSSecretValid, false, "CertificateFailed", err.Error())
+		return err
+	}
+	tlsSecret, err := getTLSSecret(e)
+	setTLSSecretCondition(e, status, err)
+	if err != nil {
+		status.SetCondition(api.EnvoyConditionConfigRendered, false, "TLSSecretError", err.Error())
+		return err
 	}
 
-	err = prepareEnvoyConfig(e)
+	cfgData, reason, err := renderEnvoyConfig(e, tlsSecret)
 	if err != nil {
+		status.SetCondition(api.EnvoyConditionConfigRendered, false, reason, err.Error())
 		return err
 	}
+	configHash, err := prepareEnvoyConfig(e, cfgData)
+	if err != nil {
+		status.SetCondition(api.EnvoyConditionConfigRendered, false, "ConfigMapFailed", err.Error())
+		return err
+	}
+	status.ConfigHash = configHash
+	status.SetCondition(api.EnvoyConditionConfigRendered, true, "Rendered", "")
 
-	err = deployEnvoy(e)
+	grpcSecretsHash, err := hashGRPCSecrets(e)
 	if err != nil {
+		status.SetCondition(api.EnvoyConditionDeployed, false, "GRPCSecretError", err.Error())
 		return err
 	}
 
-	if e.Spec.Deployment != nil {
-		err = syncDeployment(e)
+	monitor := monitorFor(e)
+	podAnnotations := podAnnotationsForEnvoy(e, configHash, tlsSecret, grpcSecretsHash)
+	if monitor == api.MetricsMonitorAnnotations {
+		for k, v := range kube.MetricsPodAnnotations(e) {
+			podAnnotations[k] = v
+		}
+	}
+
+	switch {
+	case e.Spec.DaemonSet != nil:
+		err = deployEnvoyDaemonSet(e, podAnnotations)
+		if err == nil {
+			err = leftAlone(deleteIfExists(e, emptyDeployment(e)))
+		}
+		if err != nil {
+			status.SetCondition(api.EnvoyConditionDeployed, false, "DeployFailed", err.Error())
+			return err
+		}
+		status.SetCondition(api.EnvoyConditionDeployed, true, "DaemonSetSynced", "")
+
+		err = daemonSetStatus(e, status)
 		if err != nil {
 			return err
 		}
+	case e.Spec.Deployment != nil:
+		err = deployEnvoy(e, podAnnotations)
+		if err == nil {
+			err = leftAlone(deleteIfExists(e, emptyDaemonSet(e)))
+		}
+		if err != nil {
+			status.SetCondition(api.EnvoyConditionDeployed, false, "DeployFailed", err.Error())
+			return err
+		}
+		status.SetCondition(api.EnvoyConditionDeployed, true, "DeploymentSynced", "")
+
+		err = deploymentStatus(e, status)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = leftAlone(syncService(e))
+	if err != nil {
+		return err
 	}
 
-	err = syncService(e)
+	err = leftAlone(syncMetrics(e, monitor))
 	if err != nil {
 		return err
 	}
@@ -1,10 +1,10 @@
 package envoy
 
 import (
-	"github.com/operator-framework/operator-sdk/pkg/sdk/action"
-	"github.com/operator-framework/operator-sdk/pkg/sdk/query"
+	"fmt"
 
 	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	"github.com/solo-io/envoy-operator/pkg/kube"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 
 	v1 "k8s.io/api/core/v1"
@@ -12,52 +12,68 @@ import (
 )
 
 func syncService(e *api.Envoy) error {
-	needService := len(e.Spec.ServicePorts) != 0
-	// get the envoy deployment
-
-	s := &v1.Service{
-		TypeMeta: metav1.TypeMeta{
-			Kind:       "Service",
-			APIVersion: "v1",
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      e.GetName(),
-			Namespace: e.GetNamespace(),
-		}}
-
-	err := query.Get(s)
-
-	if !needService {
-		// not needed service exists - get rid of it:
-		if err == nil {
-			// TODO: should we confirm ownership?
-			return action.Delete(s)
-		}
-		if apierrors.IsNotFound(err) {
-			return nil
-		}
+	desired, err := kube.ServiceForEnvoy(e)
+	if err != nil {
 		return err
 	}
+	if desired == nil {
+		// not needed service exists - get rid of it:
+		return deleteIfExists(e, emptyService(e))
+	}
+	return syncServiceTo(e, desired)
+}
+
+// syncServiceTo creates the desired service, or updates the existing one if it drifted
+func syncServiceTo(e *api.Envoy, desired *v1.Service) error {
+	s := emptyService(e)
+	s.Name = desired.Name
+	err := getObject(s)
+	if apierrors.IsNotFound(err) {
+		// service doesnt exist: create it
+		return createService(e, desired)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get service (%s): %v", s.Name, err)
+	}
+	if err := checkOwner(e, s); err != nil {
+		return fmt.Errorf("failed to update service (%s): %v", s.Name, err)
+	}
 
 	// service is needed and exists; make sure it is up-to-date
-	if err == nil && needsUpdate(e, s) {
-		return syncPorts(e, s)
+	needsUpdate, err := kube.ServiceNeedsUpdate(desired, s)
+	if err != nil {
+		return fmt.Errorf("failed to compare service (%s): %v", s.Name, err)
+	}
+	if !needsUpdate {
+		return nil
+	}
+	if kube.ServiceNeedsRecreate(desired, s) {
+		err = deleteObject(s)
+		if err != nil {
+			return fmt.Errorf("failed to delete service (%s): %v", s.Name, err)
+		}
+		return createService(e, desired)
 	}
 
-	if !apierrors.IsNotFound(err) {
-		return err
+	kube.UpdateService(desired, s)
+	err = updateObject(s)
+	if err != nil {
+		return fmt.Errorf("failed to update service (%s): %v", s.Name, err)
 	}
-	// service doesnt exist: create it
-	return createService(e)
+	return nil
 }
 
-func syncPorts(e *api.Envoy, s *v1.Service) error {
-	setServicePorts(e, s)
-	return action.Update(s)
+func createService(e *api.Envoy, s *v1.Service) error {
+	addOwnerRefToObject(s, asOwner(&e.ObjectMeta))
+	err := createObject(s)
+	if err != nil {
+		return fmt.Errorf("failed to create service (%s): %v", s.Name, err)
+	}
+	return nil
 }
 
-func createService(e *api.Envoy) error {
-	s := &v1.Service{
+func emptyService(e *api.Envoy) *v1.Service {
+	return &v1.Service{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "Service",
 			APIVersion: "v1",
@@ -65,46 +81,5 @@ func createService(e *api.Envoy) error {
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      e.GetName(),
 			Namespace: e.GetNamespace(),
-			Labels:    labelsForEnvoy(e),
-		},
-		Spec: v1.ServiceSpec{
-			Selector: labelsForEnvoy(e),
-			Type:     v1.ServiceTypeLoadBalancer,
-		},
-	}
-
-	addOwnerRefToObject(s, asOwner(&e.ObjectMeta))
-	setServicePorts(e, s)
-	return action.Create(s)
-}
-
-func setServicePorts(e *api.Envoy, s *v1.Service) {
-	s.Spec.Ports = nil
-	for name, port := range e.Spec.ServicePorts {
-		// TODO: support IANA ports?
-		s.Spec.Ports = append(s.Spec.Ports, v1.ServicePort{
-			Name:     name,
-			Port:     port,
-			Protocol: v1.ProtocolTCP,
-		})
-	}
-}
-
-func needsUpdate(e *api.Envoy, s *v1.Service) bool {
-	if len(e.Spec.ServicePorts) != len(s.Spec.Ports) {
-		return true
-	}
-	for _, p := range s.Spec.Ports {
-		if p.Protocol != v1.ProtocolTCP {
-			return true
-		}
-		servicePort, ok := e.Spec.ServicePorts[p.Name]
-		if !ok {
-			return true
-		}
-		if servicePort != p.Port {
-			return true
-		}
-	}
-	return false
+		}}
 }
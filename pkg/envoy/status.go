@@ -0,0 +1,82 @@
+package envoy
+
+import (
+	"fmt"
+
+	"github.com/operator-framework/operator-sdk/pkg/util/k8sutil"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// deploymentStatus copies the replica counts of the envoy deployment to the status
+func deploymentStatus(e *api.Envoy, status *api.EnvoyStatus) error {
+	d := emptyDeployment(e)
+	err := getObject(d)
+	if err != nil {
+		return fmt.Errorf("failed to get deployment (%s): %v", d.Name, err)
+	}
+
+	var desired int32
+	if d.Spec.Replicas != nil {
+		desired = *d.Spec.Replicas
+	}
+	setReplicaStatus(status, desired, d.Status.ReadyReplicas, d.Status.UpdatedReplicas)
+	return nil
+}
+
+// setReplicaStatus sets the replica counts of the status, and the Available and Degraded
+// conditions accordingly
+func setReplicaStatus(status *api.EnvoyStatus, desired, ready, updated int32) {
+	status.Replicas = desired
+	status.ReadyReplicas = ready
+	status.UpdatedReplicas = updated
+
+	readyMsg := fmt.Sprintf("%d/%d replicas ready", ready, desired)
+	if ready > 0 {
+		status.SetCondition(api.EnvoyConditionAvailable, true, "MinimumReplicasAvailable", readyMsg)
+	} else {
+		status.SetCondition(api.EnvoyConditionAvailable, false, "NoReplicasAvailable", readyMsg)
+	}
+	if ready < desired {
+		status.SetCondition(api.EnvoyConditionDegraded, true, "ReplicasUnavailable", readyMsg)
+	} else {
+		status.SetCondition(api.EnvoyConditionDegraded, false, "AllReplicasReady", readyMsg)
+	}
+}
+
+// updateStatus writes the status back to the envoy if it changed
+func updateStatus(e *api.Envoy, status *api.EnvoyStatus) error {
+	if equality.Semantic.DeepEqual(&e.Status, status) {
+		return nil
+	}
+	e = e.DeepCopy()
+	e.Status = *status
+
+	// the sdk's dynamic client can't talk to sub resources, so the status sub resource has its
+	// own client.
+	var err error
+	if clients.Status != nil {
+		unstructObj := k8sutil.UnstructuredFromRuntimeObject(e)
+		result := new(unstructured.Unstructured)
+		err = clients.Status.Put().
+			Namespace(e.Namespace).
+			Resource("envoys").
+			Name(e.Name).
+			SubResource("status").
+			Body(unstructObj).
+			Do().
+			Into(result)
+	}
+	if clients.Status == nil || apierrors.IsNotFound(err) {
+		// the status sub resource is not enabled; fall back to updating the whole envoy.
+		err = updateObject(e)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update status of envoy (%s): %v", e.Name, err)
+	}
+	return nil
+}
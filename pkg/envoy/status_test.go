@@ -0,0 +1,139 @@
+package envoy_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	. "github.com/solo-io/envoy-operator/pkg/envoy"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Status", func() {
+
+	longAgo := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+
+	Context("SetCondition", func() {
+		It("should add a missing condition", func() {
+			var status api.EnvoyStatus
+			status.SetCondition(api.EnvoyConditionDeployed, true, "DeploymentSynced", "")
+			c := status.GetCondition(api.EnvoyConditionDeployed)
+			Expect(c).NotTo(BeNil())
+			Expect(c.Status).To(Equal(v1.ConditionTrue))
+			Expect(c.Reason).To(Equal("DeploymentSynced"))
+			Expect(c.LastTransitionTime.IsZero()).To(BeFalse())
+		})
+
+		It("should keep the transition time while the status stays the same", func() {
+			status := api.EnvoyStatus{Conditions: []api.EnvoyCondition{{
+				Type: api.EnvoyConditionDeployed, Status: v1.ConditionFalse, Reason: "DeployFailed", LastTransitionTime: longAgo,
+			}}}
+			status.SetCondition(api.EnvoyConditionDeployed, false, "DeployFailed", "still failing")
+			c := status.GetCondition(api.EnvoyConditionDeployed)
+			Expect(c.LastTransitionTime).To(Equal(longAgo))
+			Expect(c.Message).To(Equal("still failing"))
+		})
+
+		It("should update the transition time when the status changes", func() {
+			status := api.EnvoyStatus{Conditions: []api.EnvoyCondition{{
+				Type: api.EnvoyConditionDeployed, Status: v1.ConditionFalse, Reason: "DeployFailed", LastTransitionTime: longAgo,
+			}}}
+			status.SetCondition(api.EnvoyConditionDeployed, true, "DeploymentSynced", "")
+			Expect(status.Conditions).To(HaveLen(1))
+			c := status.GetCondition(api.EnvoyConditionDeployed)
+			Expect(c.Status).To(Equal(v1.ConditionTrue))
+			Expect(c.Reason).To(Equal("DeploymentSynced"))
+			Expect(c.LastTransitionTime.After(longAgo.Time)).To(BeTrue())
+		})
+	})
+
+	Context("replica status", func() {
+		It("should be unavailable and degraded without ready replicas", func() {
+			var status api.EnvoyStatus
+			SetReplicaStatus(&status, 3, 0, 3)
+			Expect(status.Replicas).To(BeEquivalentTo(3))
+			Expect(status.ReadyReplicas).To(BeEquivalentTo(0))
+			Expect(status.UpdatedReplicas).To(BeEquivalentTo(3))
+			Expect(status.GetCondition(api.EnvoyConditionAvailable).Status).To(Equal(v1.ConditionFalse))
+			Expect(status.GetCondition(api.EnvoyConditionAvailable).Reason).To(Equal("NoReplicasAvailable"))
+			Expect(status.GetCondition(api.EnvoyConditionDegraded).Status).To(Equal(v1.ConditionTrue))
+			Expect(status.GetCondition(api.EnvoyConditionDegraded).Message).To(Equal("0/3 replicas ready"))
+		})
+
+		It("should be available and degraded with some ready replicas", func() {
+			var status api.EnvoyStatus
+			SetReplicaStatus(&status, 3, 1, 3)
+			Expect(status.GetCondition(api.EnvoyConditionAvailable).Status).To(Equal(v1.ConditionTrue))
+			Expect(status.GetCondition(api.EnvoyConditionDegraded).Status).To(Equal(v1.ConditionTrue))
+			Expect(status.GetCondition(api.EnvoyConditionDegraded).Reason).To(Equal("ReplicasUnavailable"))
+		})
+
+		It("should transition to available once all replicas are ready", func() {
+			var status api.EnvoyStatus
+			SetReplicaStatus(&status, 3, 0, 3)
+			status.GetCondition(api.EnvoyConditionAvailable).LastTransitionTime = longAgo
+			status.GetCondition(api.EnvoyConditionDegraded).LastTransitionTime = longAgo
+
+			SetReplicaStatus(&status, 3, 3, 3)
+			available := status.GetCondition(api.EnvoyConditionAvailable)
+			Expect(available.Status).To(Equal(v1.ConditionTrue))
+			Expect(available.Reason).To(Equal("MinimumReplicasAvailable"))
+			Expect(available.LastTransitionTime.After(longAgo.Time)).To(BeTrue())
+			degraded := status.GetCondition(api.EnvoyConditionDegraded)
+			Expect(degraded.Status).To(Equal(v1.ConditionFalse))
+			Expect(degraded.Reason).To(Equal("AllReplicasReady"))
+			Expect(degraded.Message).To(Equal("3/3 replicas ready"))
+		})
+	})
+
+	Context("reconcile", func() {
+		var (
+			cluster *fakeCluster
+			e       *api.Envoy
+		)
+
+		BeforeEach(func() {
+			cluster = newFakeCluster("Certificate", "ServiceMonitor", "PodMonitor")
+			e = testEnvoy()
+			cluster.create(e)
+		})
+
+		It("should deploy the envoy and report the observed generation", func() {
+			Expect(Reconcile(e)).To(Succeed())
+
+			d := &appsv1.Deployment{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+				ObjectMeta: metav1.ObjectMeta{Name: e.Name, Namespace: e.Namespace},
+			}
+			Expect(cluster.get(d)).To(Succeed())
+
+			live := &api.Envoy{TypeMeta: e.TypeMeta, ObjectMeta: metav1.ObjectMeta{Name: e.Name, Namespace: e.Namespace}}
+			Expect(cluster.get(live)).To(Succeed())
+			Expect(live.Status.ObservedGeneration).To(BeEquivalentTo(1))
+			Expect(live.Status.ConfigHash).NotTo(BeEmpty())
+			Expect(live.Status.GetCondition(api.EnvoyConditionConfigRendered).Status).To(Equal(v1.ConditionTrue))
+			Expect(live.Status.GetCondition(api.EnvoyConditionDeployed).Status).To(Equal(v1.ConditionTrue))
+			Expect(live.Status.GetCondition(api.EnvoyConditionAvailable).Status).To(Equal(v1.ConditionFalse))
+		})
+
+		It("should not report the generation of a spec it failed to reconcile", func() {
+			Expect(Reconcile(e)).To(Succeed())
+			live := &api.Envoy{TypeMeta: e.TypeMeta, ObjectMeta: metav1.ObjectMeta{Name: e.Name, Namespace: e.Namespace}}
+			Expect(cluster.get(live)).To(Succeed())
+
+			live.Spec.NodeIdTemplate = "{{.PodName"
+			live.Generation = 2
+			Expect(Reconcile(live)).NotTo(Succeed())
+
+			Expect(cluster.get(live)).To(Succeed())
+			Expect(live.Status.ObservedGeneration).To(BeEquivalentTo(1))
+			Expect(live.Status.GetCondition(api.EnvoyConditionDeployed).Status).To(Equal(v1.ConditionFalse))
+			Expect(live.Status.GetCondition(api.EnvoyConditionDegraded).Reason).To(Equal("ReconcileFailed"))
+		})
+	})
+})
@@ -3,20 +3,49 @@ package envoy
 import (
 	"fmt"
 
-	"github.com/operator-framework/operator-sdk/pkg/sdk/action"
-	"github.com/operator-framework/operator-sdk/pkg/sdk/query"
+	"github.com/operator-framework/operator-sdk/pkg/sdk/types"
 
 	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	"github.com/solo-io/envoy-operator/pkg/kube"
 
 	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-func syncDeployment(e *api.Envoy) error {
+// syncDeployment updates the existing envoy deployment if it drifted from the desired one
+func syncDeployment(e *api.Envoy, desired *appsv1.Deployment) error {
 
 	// get the envoy deployment
 
-	d := &appsv1.Deployment{
+	d := emptyDeployment(e)
+	err := getObject(d)
+	if err != nil {
+		return fmt.Errorf("failed to get deployment (%s): %v", d.Name, err)
+	}
+	if err := checkOwner(e, d); err != nil {
+		return fmt.Errorf("failed to update deployment (%s): %v", d.Name, err)
+	}
+
+	needsUpdate, err := kube.DeploymentNeedsUpdate(desired, d)
+	if err != nil {
+		return fmt.Errorf("failed to compare deployment (%s): %v", d.Name, err)
+	}
+	if !needsUpdate {
+		return nil
+	}
+
+	kube.UpdateDeployment(desired, d)
+	err = updateObject(d)
+	if err != nil {
+		return fmt.Errorf("failed to update deployment (%s): %v", d.Name, err)
+	}
+
+	return nil
+}
+
+func emptyDeployment(e *api.Envoy) *appsv1.Deployment {
+	return &appsv1.Deployment{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "Deployment",
 			APIVersion: "apps/v1",
@@ -25,22 +54,43 @@ func syncDeployment(e *api.Envoy) error {
 			Name:      e.GetName(),
 			Namespace: e.GetNamespace(),
 		}}
+}
 
-	err := query.Get(d)
-	if err != nil {
-		return fmt.Errorf("failed to get deployment (%s): %v", d.Name, err)
-	}
+// notOwnedError is returned for an object with the name of one of the envoy's, which the envoy
+// isn't the controller of. The object is left alone.
+type notOwnedError struct {
+	kind, name string
+}
+
+func (err *notOwnedError) Error() string {
+	return fmt.Sprintf("%s %s isn't controlled by the envoy, and is left alone", err.kind, err.name)
+}
 
-	var reps int32
-	reps = int32(e.Spec.Deployment.Replicas)
+// checkOwner returns a notOwnedError unless the envoy is the controller of the object
+func checkOwner(e *api.Envoy, o types.Object) error {
+	m := o.(metav1.Object)
+	if ref := metav1.GetControllerOf(m); ref != nil && ref.UID == e.UID {
+		return nil
+	}
+	return &notOwnedError{kind: o.GetObjectKind().GroupVersionKind().Kind, name: m.GetName()}
+}
 
-	if *d.Spec.Replicas != reps {
-		d.Spec.Replicas = &reps
-		err = action.Update(d)
-		if err != nil {
-			return fmt.Errorf("failed to update size of deployment (%s): %v", d.Name, err)
+// deleteIfExists deletes the object if it exists and the envoy controls it, e.g. the workload of
+// a deployment mode the envoy no longer uses. It returns a notOwnedError for objects of others.
+func deleteIfExists(e *api.Envoy, o types.Object) error {
+	err := getObject(o)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err == nil {
+		if err := checkOwner(e, o); err != nil {
+			return err
 		}
+		err = deleteObject(o)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete %s (%s): %v", o.GetObjectKind().GroupVersionKind().Kind,
+			o.(metav1.Object).GetName(), err)
 	}
-
 	return nil
 }
@@ -0,0 +1,100 @@
+package envoy_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	. "github.com/solo-io/envoy-operator/pkg/envoy"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Ownership", func() {
+	var (
+		cluster *fakeCluster
+		e       *api.Envoy
+	)
+
+	BeforeEach(func() {
+		cluster = newFakeCluster("Certificate", "ServiceMonitor", "PodMonitor")
+		e = testEnvoy()
+		cluster.create(e)
+	})
+
+	degraded := func() *api.EnvoyCondition {
+		live := &api.Envoy{TypeMeta: e.TypeMeta, ObjectMeta: metav1.ObjectMeta{Name: e.Name, Namespace: e.Namespace}}
+		Expect(cluster.get(live)).To(Succeed())
+		return live.Status.GetCondition(api.EnvoyConditionDegraded)
+	}
+
+	// othersDeployment is a deployment of the envoy's name another controller manages
+	othersDeployment := func() *appsv1.Deployment {
+		d := deploymentOf(e)
+		controller := true
+		d.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "other", UID: "other-uid", Controller: &controller,
+		}}
+		d.Labels = map[string]string{"app": "other"}
+		return d
+	}
+
+	It("should not take over a deployment of others", func() {
+		cluster.create(othersDeployment())
+
+		Expect(Reconcile(e)).To(MatchError(ContainSubstring("isn't controlled by the envoy")))
+		Expect(cluster.actions("update", "deployments")).To(BeEmpty())
+		d := deploymentOf(e)
+		Expect(cluster.get(d)).To(Succeed())
+		Expect(d.Labels).To(Equal(map[string]string{"app": "other"}))
+		Expect(degraded().Reason).To(Equal("ReconcileFailed"))
+	})
+
+	It("should not delete a deployment of others", func() {
+		cluster.create(othersDeployment())
+		e.Spec.Deployment = nil
+		e.Spec.DaemonSet = &api.EnvoyDaemonSetSpec{}
+		e.SetDefaults()
+
+		Expect(Reconcile(e)).To(Succeed())
+		Expect(cluster.actions("delete", "deployments")).To(BeEmpty())
+		Expect(degraded().Status).To(Equal(v1.ConditionTrue))
+		Expect(degraded().Reason).To(Equal("NotOwned"))
+		Expect(degraded().Message).To(ContainSubstring("Deployment myenvoy"))
+	})
+
+	It("should delete its own deployment once it runs as a daemon set", func() {
+		Expect(Reconcile(e)).To(Succeed())
+		e.Spec.Deployment = nil
+		e.Spec.DaemonSet = &api.EnvoyDaemonSetSpec{}
+		e.SetDefaults()
+
+		Expect(Reconcile(e)).To(Succeed())
+		Expect(cluster.actions("delete", "deployments")).To(HaveLen(1))
+		Expect(degraded().Reason).NotTo(Equal("NotOwned"))
+	})
+
+	Context("with a service of others", func() {
+		BeforeEach(func() {
+			cluster.create(&v1.Service{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+				ObjectMeta: metav1.ObjectMeta{Name: e.Name, Namespace: e.Namespace},
+			})
+		})
+
+		It("should not take it over", func() {
+			e.Spec.ServicePorts = map[string]int32{"http": 8080}
+			Expect(Reconcile(e)).To(MatchError(ContainSubstring("Service myenvoy isn't controlled by the envoy")))
+			Expect(cluster.actions("update", "services")).To(BeEmpty())
+			Expect(cluster.actions("delete", "services")).To(BeEmpty())
+		})
+
+		It("should not delete it", func() {
+			Expect(Reconcile(e)).To(Succeed())
+			Expect(cluster.actions("delete", "services")).To(BeEmpty())
+			Expect(degraded().Reason).To(Equal("NotOwned"))
+		})
+	})
+})
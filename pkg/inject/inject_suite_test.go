@@ -0,0 +1,13 @@
+package inject_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestInject(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Inject Suite")
+}
@@ -0,0 +1,54 @@
+package inject
+
+import (
+	"sort"
+	"sync"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+)
+
+// Config is an envoy with injection enabled, along with its rendered bootstrap config
+type Config struct {
+	Envoy     *api.Envoy
+	Bootstrap string
+}
+
+// Registry holds the envoys that should be injected into pods
+type Registry struct {
+	lock    sync.RWMutex
+	configs map[string]Config
+}
+
+func NewRegistry() *Registry {
+	return &Registry{configs: map[string]Config{}}
+}
+
+func (r *Registry) Set(c Config) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.configs[key(c.Envoy)] = c
+}
+
+func (r *Registry) Delete(e *api.Envoy) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.configs, key(e))
+}
+
+// Configs returns the registered configs, sorted by the envoy's namespace and name
+func (r *Registry) Configs() []Config {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	keys := make([]string, 0, len(r.configs))
+	for k := range r.configs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	configs := make([]Config, 0, len(keys))
+	for _, k := range keys {
+		configs = append(configs, r.configs[k])
+	}
+	return configs
+}
+
+func key(e *api.Envoy) string { return e.Namespace + "/" + e.Name }
@@ -0,0 +1,30 @@
+package inject
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// ListenAndServeTLS serves the webhook on addr until the context is cancelled
+func ListenAndServeTLS(ctx context.Context, addr, certFile, keyFile string, webhook *Webhook) error {
+	mux := http.NewServeMux()
+	mux.Handle("/inject", webhook)
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: mux,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	err := srv.ListenAndServeTLS(certFile, keyFile)
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,228 @@
+package inject
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	"github.com/solo-io/envoy-operator/pkg/kube"
+	"github.com/solo-io/envoy-operator/pkg/metrics"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Webhook is a mutating admission webhook that injects the envoys in the registry into pods.
+// The admission.k8s.io v1 and v1beta1 reviews share the same wire format, so both are served
+// using the v1beta1 types, answering with the api version of the request.
+type Webhook struct {
+	registry *Registry
+}
+
+func NewWebhook(registry *Registry) *Webhook {
+	return &Webhook{registry: registry}
+}
+
+func (w *Webhook) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(rw, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	if ct := req.Header.Get("Content-Type"); ct != "application/json" {
+		http.Error(rw, fmt.Sprintf("unsupported content type %s", ct), http.StatusUnsupportedMediaType)
+		return
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var review admissionv1beta1.AdmissionReview
+	if err := json.Unmarshal(body, &review); err != nil {
+		http.Error(rw, fmt.Sprintf("failed to decode admission review: %v", err), http.StatusBadRequest)
+		return
+	}
+	if review.Request == nil {
+		http.Error(rw, "admission review has no request", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := json.Marshal(w.Review(&review))
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Write(resp)
+}
+
+// Review returns the response to the admission review
+func (w *Webhook) Review(review *admissionv1beta1.AdmissionReview) *admissionv1beta1.AdmissionReview {
+	resp := w.mutate(review.Request)
+	resp.UID = review.Request.UID
+	return &admissionv1beta1.AdmissionReview{
+		TypeMeta: review.TypeMeta,
+		Response: resp,
+	}
+}
+
+func (w *Webhook) mutate(req *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+	allowed := &admissionv1beta1.AdmissionResponse{Allowed: true}
+	if req.Kind.Kind != "Pod" || req.Operation != admissionv1beta1.Create {
+		return allowed
+	}
+
+	var pod v1.Pod
+	if err := json.Unmarshal(req.Object.Raw, &pod); err != nil {
+		return &admissionv1beta1.AdmissionResponse{
+			Result: &metav1.Status{
+				Status:  metav1.StatusFailure,
+				Message: fmt.Sprintf("failed to decode pod: %v", err),
+				Reason:  metav1.StatusReasonBadRequest,
+				Code:    http.StatusBadRequest,
+			},
+		}
+	}
+	if _, ok := pod.Annotations[kube.InjectedAnnotation]; ok {
+		return allowed
+	}
+
+	namespace := req.Namespace
+	if namespace == "" {
+		namespace = pod.Namespace
+	}
+	for _, c := range w.registry.Configs() {
+		if !shouldInject(c.Envoy.Spec.Injection, &pod, namespace) {
+			continue
+		}
+		patch, err := patchForEnvoy(c, &pod, namespace)
+		if err != nil {
+			log.Printf("failed to inject envoy (%s) into pod %s/%s: %v\n", c.Envoy.Name, namespace, pod.GenerateName+pod.Name, err)
+			return &admissionv1beta1.AdmissionResponse{
+				Result: &metav1.Status{
+					Status:  metav1.StatusFailure,
+					Message: fmt.Sprintf("failed to inject envoy (%s): %v", c.Envoy.Name, err),
+					Reason:  metav1.StatusReasonInternalError,
+					Code:    http.StatusInternalServerError,
+				},
+			}
+		}
+		metrics.Injections.Inc(c.Envoy.Name)
+		patchType := admissionv1beta1.PatchTypeJSONPatch
+		allowed.Patch = patch
+		allowed.PatchType = &patchType
+		return allowed
+	}
+	return allowed
+}
+
+// shouldInject returns true if the injection spec selects the pod
+func shouldInject(spec *api.InjectionSpec, pod *v1.Pod, namespace string) bool {
+	if spec == nil {
+		return false
+	}
+	if spec.Annotation != "" {
+		switch strings.ToLower(pod.Annotations[spec.Annotation]) {
+		case "true", "enabled":
+			return true
+		case "false", "disabled":
+			return false
+		}
+	}
+
+	listed := false
+	for _, ns := range spec.Namespaceslist {
+		if ns == namespace {
+			listed = true
+			break
+		}
+	}
+	switch spec.Mode {
+	case api.InjectionModeWhitelist:
+		return listed
+	case api.InjectionModeBlacklist:
+		return !listed
+	}
+	return false
+}
+
+type patchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// patchForEnvoy returns the json patch that injects the envoy into the pod of the namespace
+func patchForEnvoy(c Config, pod *v1.Pod, namespace string) ([]byte, error) {
+	sidecar, annotations, err := kube.SidecarForEnvoy(c.Envoy, c.Bootstrap, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var patch []patchOperation
+	// the envoy's init containers run before the pod's own, so that the traffic is intercepted
+	// before any of the pod's containers starts
+	for i, container := range sidecar.InitContainers {
+		patch = insertOp(patch, "/spec/initContainers", i, len(pod.Spec.InitContainers) == 0, container)
+		pod.Spec.InitContainers = append(pod.Spec.InitContainers[:i], append([]v1.Container{container}, pod.Spec.InitContainers[i:]...)...)
+	}
+	for _, container := range sidecar.Containers {
+		patch = appendOp(patch, "/spec/containers", len(pod.Spec.Containers) == 0, container)
+		pod.Spec.Containers = append(pod.Spec.Containers, container)
+	}
+	for _, volume := range sidecar.Volumes {
+		patch = appendOp(patch, "/spec/volumes", len(pod.Spec.Volumes) == 0, volume)
+		pod.Spec.Volumes = append(pod.Spec.Volumes, volume)
+	}
+	if period := sidecar.TerminationGracePeriodSeconds; period != nil {
+		// give the envoy time to drain, unless the pod already waits long enough
+		current := int64(v1.DefaultTerminationGracePeriodSeconds)
+		if pod.Spec.TerminationGracePeriodSeconds != nil {
+			current = *pod.Spec.TerminationGracePeriodSeconds
+		}
+		if current < *period {
+			patch = append(patch, patchOperation{Op: "add", Path: "/spec/terminationGracePeriodSeconds", Value: *period})
+		}
+	}
+	if pod.Annotations == nil {
+		patch = append(patch, patchOperation{Op: "add", Path: "/metadata/annotations", Value: annotations})
+	} else {
+		keys := make([]string, 0, len(annotations))
+		for k := range annotations {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			patch = append(patch, patchOperation{Op: "add", Path: "/metadata/annotations/" + escapeJsonPointer(k), Value: annotations[k]})
+		}
+	}
+	return json.Marshal(patch)
+}
+
+// appendOp adds an operation appending value to the list at path. When the list is empty, it
+// may not exist in the pod, so it is created instead.
+func appendOp(patch []patchOperation, path string, empty bool, value interface{}) []patchOperation {
+	if empty {
+		return append(patch, patchOperation{Op: "add", Path: path, Value: []interface{}{value}})
+	}
+	return append(patch, patchOperation{Op: "add", Path: path + "/-", Value: value})
+}
+
+// insertOp inserts the value at the index of the array at path
+func insertOp(patch []patchOperation, path string, index int, empty bool, value interface{}) []patchOperation {
+	if empty {
+		return append(patch, patchOperation{Op: "add", Path: path, Value: []interface{}{value}})
+	}
+	return append(patch, patchOperation{Op: "add", Path: fmt.Sprintf("%s/%d", path, index), Value: value})
+}
+
+func escapeJsonPointer(s string) string {
+	s = strings.Replace(s, "~", "~0", -1)
+	return strings.Replace(s, "/", "~1", -1)
+}
@@ -0,0 +1,245 @@
+package inject_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	. "github.com/solo-io/envoy-operator/pkg/inject"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const podReview = `{
+  "apiVersion": "admission.k8s.io/v1",
+  "kind": "AdmissionReview",
+  "request": {
+    "uid": "705ab4f5-6393-11e8-b7cc-42010a800002",
+    "kind": {"group": "", "version": "v1", "kind": "Pod"},
+    "resource": {"group": "", "version": "v1", "resource": "pods"},
+    "namespace": "apps",
+    "operation": "CREATE",
+    "userInfo": {"username": "admin"},
+    "object": {
+      "apiVersion": "v1",
+      "kind": "Pod",
+      "metadata": {"generateName": "app-", "annotations": %s},
+      "spec": {"containers": [{"name": "app", "image": "app:1.0"}]}
+    }
+  }
+}`
+
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+func review(annotations string) *admissionv1beta1.AdmissionReview {
+	var r admissionv1beta1.AdmissionReview
+	err := json.Unmarshal([]byte(fmt.Sprintf(podReview, annotations)), &r)
+	Expect(err).NotTo(HaveOccurred())
+	return &r
+}
+
+func patchOf(r *admissionv1beta1.AdmissionReview) map[string]patchOperation {
+	Expect(r.Response.Allowed).To(BeTrue())
+	if r.Response.Patch == nil {
+		return nil
+	}
+	Expect(*r.Response.PatchType).To(Equal(admissionv1beta1.PatchTypeJSONPatch))
+	var ops []patchOperation
+	err := json.Unmarshal(r.Response.Patch, &ops)
+	Expect(err).NotTo(HaveOccurred())
+	res := map[string]patchOperation{}
+	for _, op := range ops {
+		Expect(op.Op).To(Equal("add"))
+		res[op.Path] = op
+	}
+	return res
+}
+
+var _ = Describe("Webhook", func() {
+	var (
+		registry *Registry
+		webhook  *Webhook
+		envoy    *api.Envoy
+	)
+
+	BeforeEach(func() {
+		registry = NewRegistry()
+		webhook = NewWebhook(registry)
+		envoy = &api.Envoy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "sidecar",
+				Namespace: "envoy-operator",
+			},
+			Spec: api.EnvoySpec{
+				ADSServer:         "ads.solo.io",
+				ADSPort:           1234,
+				ClusterIdTemplate: "{{.PodNamespace}}",
+				NodeIdTemplate:    "{{.PodName}}",
+				Injection: &api.InjectionSpec{
+					Mode:           api.InjectionModeWhitelist,
+					Namespaceslist: []string{"apps"},
+					Annotation:     "envoy.solo.io/inject",
+				},
+			},
+		}
+		envoy.SetDefaults()
+		registry.Set(Config{Envoy: envoy, Bootstrap: `{"node":{}}`})
+	})
+
+	It("should inject pods in whitelisted namespaces", func() {
+		resp := webhook.Review(review(`{}`))
+		Expect(resp.Response.UID).To(BeEquivalentTo("705ab4f5-6393-11e8-b7cc-42010a800002"))
+		patch := patchOf(resp)
+		Expect(patch).To(HaveKey("/spec/initContainers"))
+		Expect(patch).To(HaveKey("/spec/containers/-"))
+		Expect(patch).To(HaveKey("/spec/volumes"))
+		Expect(patch).To(HaveKey("/metadata/annotations/envoy.solo.io~1bootstrap"))
+		Expect(patch).To(HaveKey("/metadata/annotations/envoy.solo.io~1injected"))
+
+		Expect(string(patch["/spec/initContainers"].Value)).To(ContainSubstring(`"name":"envoy-init"`))
+		Expect(string(patch["/spec/initContainers"].Value)).To(ContainSubstring(`"name":"POD_NAME"`))
+		Expect(string(patch["/spec/containers/-"].Value)).To(ContainSubstring(`"name":"envoy"`))
+		Expect(string(patch["/spec/volumes"].Value)).To(ContainSubstring(`metadata.annotations['envoy.solo.io/bootstrap']`))
+		Expect(string(patch["/metadata/annotations/envoy.solo.io~1bootstrap"].Value)).To(Equal(`"{\"node\":{}}"`))
+	})
+
+	It("should redirect traffic before the other containers start", func() {
+		envoy.Spec.Injection.Interception = &api.InterceptionSpec{}
+		envoy.SetDefaults()
+		patch := patchOf(webhook.Review(review(`{}`)))
+
+		var initContainers []v1.Container
+		err := json.Unmarshal(patch["/spec/initContainers"].Value, &initContainers)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(initContainers).To(HaveLen(1))
+		Expect(initContainers[0].Name).To(Equal("envoy-iptables"))
+		Expect(string(patch["/spec/initContainers/1"].Value)).To(ContainSubstring(`"name":"envoy-init"`))
+		Expect(initContainers[0].Args).To(ContainElement("-exclude-inbound-ports"))
+		Expect(initContainers[0].SecurityContext.Capabilities.Add).To(ContainElement(v1.Capability("NET_ADMIN")))
+
+		var envoyContainer v1.Container
+		err = json.Unmarshal(patch["/spec/containers/-"].Value, &envoyContainer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*envoyContainer.SecurityContext.RunAsUser).To(BeEquivalentTo(1337))
+		Expect(envoyContainer.SecurityContext.Capabilities).To(BeNil())
+	})
+
+	It("should redirect traffic before the pod's own init containers start", func() {
+		envoy.Spec.Injection.Interception = &api.InterceptionSpec{}
+		envoy.SetDefaults()
+		r := review(`{}`)
+		var pod v1.Pod
+		Expect(json.Unmarshal(r.Request.Object.Raw, &pod)).To(Succeed())
+		pod.Spec.InitContainers = []v1.Container{{Name: "migrate", Image: "app:1.0"}}
+		raw, err := json.Marshal(pod)
+		Expect(err).NotTo(HaveOccurred())
+		r.Request.Object.Raw = raw
+
+		patch := patchOf(webhook.Review(r))
+		Expect(patch).NotTo(HaveKey("/spec/initContainers"))
+		Expect(patch).NotTo(HaveKey("/spec/initContainers/-"))
+		Expect(string(patch["/spec/initContainers/0"].Value)).To(ContainSubstring(`"name":"envoy-iptables"`))
+		Expect(string(patch["/spec/initContainers/1"].Value)).To(ContainSubstring(`"name":"envoy-init"`))
+	})
+
+	It("should let the envoy bind transparent listeners in tproxy mode", func() {
+		envoy.Spec.Injection.Interception = &api.InterceptionSpec{Mode: api.InterceptionModeTProxy}
+		envoy.SetDefaults()
+		patch := patchOf(webhook.Review(review(`{}`)))
+
+		var envoyContainer v1.Container
+		err := json.Unmarshal(patch["/spec/containers/-"].Value, &envoyContainer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*envoyContainer.SecurityContext.RunAsUser).To(BeEquivalentTo(1337))
+		Expect(envoyContainer.SecurityContext.Capabilities.Add).To(ConsistOf(v1.Capability("NET_ADMIN")))
+	})
+
+	It("should give the envoy time to drain", func() {
+		patch := patchOf(webhook.Review(review(`{}`)))
+		Expect(patch).NotTo(HaveKey("/spec/terminationGracePeriodSeconds"))
+
+		envoy.Spec.Drain = &api.DrainSpec{DrainSeconds: 60}
+		patch = patchOf(webhook.Review(review(`{}`)))
+		Expect(string(patch["/spec/terminationGracePeriodSeconds"].Value)).To(Equal("70"))
+	})
+
+	It("should not inject pods in other namespaces", func() {
+		envoy.Spec.Injection.Namespaceslist = []string{"other"}
+		Expect(patchOf(webhook.Review(review(`{}`)))).To(BeNil())
+	})
+
+	It("should not inject pods in blacklisted namespaces", func() {
+		envoy.Spec.Injection.Mode = api.InjectionModeBlacklist
+		Expect(patchOf(webhook.Review(review(`{}`)))).To(BeNil())
+	})
+
+	It("should inject pods outside of blacklisted namespaces", func() {
+		envoy.Spec.Injection.Mode = api.InjectionModeBlacklist
+		envoy.Spec.Injection.Namespaceslist = []string{"other"}
+		Expect(patchOf(webhook.Review(review(`{}`)))).To(HaveKey("/spec/containers/-"))
+	})
+
+	It("should let the annotation opt out", func() {
+		Expect(patchOf(webhook.Review(review(`{"envoy.solo.io/inject": "false"}`)))).To(BeNil())
+	})
+
+	It("should let the annotation opt in", func() {
+		envoy.Spec.Injection.Namespaceslist = nil
+		Expect(patchOf(webhook.Review(review(`{"envoy.solo.io/inject": "true"}`)))).To(HaveKey("/spec/containers/-"))
+	})
+
+	It("should not inject twice", func() {
+		Expect(patchOf(webhook.Review(review(`{"envoy.solo.io/injected": "sidecar"}`)))).To(BeNil())
+	})
+
+	It("should not inject the secrets of another namespace", func() {
+		envoy.Spec.TLSSecretName = "certs"
+		resp := webhook.Review(review(`{}`))
+		Expect(resp.Response.Allowed).To(BeFalse())
+		Expect(resp.Response.Result.Message).To(ContainSubstring("can't use the secrets certs"))
+
+		envoy.Namespace = "apps"
+		Expect(patchOf(webhook.Review(review(`{}`)))).To(HaveKey("/spec/containers/-"))
+	})
+
+	It("should not inject when no envoy is registered", func() {
+		registry.Delete(envoy)
+		Expect(patchOf(webhook.Review(review(`{}`)))).To(BeNil())
+	})
+
+	It("should answer over http with the api version of the request", func() {
+		body := fmt.Sprintf(podReview, `{}`)
+		req := httptest.NewRequest(http.MethodPost, "/inject", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		webhook.ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+
+		var resp admissionv1beta1.AdmissionReview
+		err := json.Unmarshal(rec.Body.Bytes(), &resp)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.APIVersion).To(Equal("admission.k8s.io/v1"))
+		Expect(resp.Kind).To(Equal("AdmissionReview"))
+		Expect(patchOf(&resp)).To(HaveKey("/spec/containers/-"))
+	})
+
+	It("should reject malformed requests", func() {
+		req := httptest.NewRequest(http.MethodPost, "/inject", bytes.NewBufferString("{"))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		webhook.ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusBadRequest))
+	})
+})
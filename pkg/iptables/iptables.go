@@ -0,0 +1,156 @@
+package iptables
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strconv"
+)
+
+const (
+	ModeRedirect = "REDIRECT"
+	ModeTProxy   = "TPROXY"
+
+	// Packets diverted with TPROXY are marked, and routed locally with this routing table
+	tproxyMark  = 1337
+	tproxyTable = 133
+
+	inboundChain         = "ENVOY_INBOUND"
+	inboundRedirectChain = "ENVOY_IN_REDIRECT"
+	outputChain          = "ENVOY_OUTPUT"
+	redirectChain        = "ENVOY_REDIRECT"
+	divertChain          = "ENVOY_DIVERT"
+	tproxyChain          = "ENVOY_TPROXY"
+)
+
+// Config describes which traffic should be redirected to envoy
+type Config struct {
+	// REDIRECT or TPROXY, for inbound traffic
+	Mode string
+
+	OutboundPort uint32
+	InboundPort  uint32
+
+	// The uid envoy runs as; its traffic is never redirected
+	ProxyUID int64
+
+	IncludeOutboundCIDRs []string
+	ExcludeOutboundCIDRs []string
+	ExcludeOutboundPorts []uint32
+
+	IncludeInboundPorts []uint32
+	ExcludeInboundPorts []uint32
+
+	ExcludeUIDs []int64
+}
+
+// Rules are the rules that implement a Config
+type Rules struct {
+	// Input for iptables-restore
+	Restore string
+	// Extra commands that need to run after the rules are restored
+	Commands [][]string
+}
+
+// Generate returns the rules for the config
+func Generate(cfg Config) (*Rules, error) {
+	if cfg.OutboundPort == 0 || cfg.InboundPort == 0 {
+		return nil, fmt.Errorf("inbound and outbound ports must be set")
+	}
+	for _, cidrs := range [][]string{cfg.IncludeOutboundCIDRs, cfg.ExcludeOutboundCIDRs} {
+		for _, cidr := range cidrs {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				return nil, fmt.Errorf("invalid cidr %q: %v", cidr, err)
+			}
+		}
+	}
+
+	var rules Rules
+	var b bytes.Buffer
+	switch cfg.Mode {
+	case "", ModeRedirect:
+		writeNat(&b, cfg, true)
+	case ModeTProxy:
+		writeMangle(&b, cfg)
+		writeNat(&b, cfg, false)
+		rules.Commands = [][]string{
+			{"ip", "-f", "inet", "rule", "add", "fwmark", strconv.Itoa(tproxyMark), "lookup", strconv.Itoa(tproxyTable)},
+			{"ip", "-f", "inet", "route", "add", "local", "default", "dev", "lo", "table", strconv.Itoa(tproxyTable)},
+		}
+	default:
+		return nil, fmt.Errorf("unknown interception mode %q", cfg.Mode)
+	}
+	rules.Restore = b.String()
+	return &rules, nil
+}
+
+// writeNat writes the nat table, which redirects outbound traffic, and inbound traffic too
+// unless it is intercepted with TPROXY
+func writeNat(b *bytes.Buffer, cfg Config, inbound bool) {
+	fmt.Fprintln(b, "*nat")
+	if inbound {
+		fmt.Fprintf(b, ":%s - [0:0]\n", inboundChain)
+		fmt.Fprintf(b, ":%s - [0:0]\n", inboundRedirectChain)
+	}
+	fmt.Fprintf(b, ":%s - [0:0]\n", outputChain)
+	fmt.Fprintf(b, ":%s - [0:0]\n", redirectChain)
+
+	fmt.Fprintf(b, "-A %s -p tcp -j REDIRECT --to-ports %d\n", redirectChain, cfg.OutboundPort)
+
+	if inbound {
+		fmt.Fprintf(b, "-A %s -p tcp -j REDIRECT --to-ports %d\n", inboundRedirectChain, cfg.InboundPort)
+		fmt.Fprintf(b, "-A PREROUTING -p tcp -j %s\n", inboundChain)
+		writeInbound(b, cfg, inboundRedirectChain)
+	}
+
+	fmt.Fprintf(b, "-A OUTPUT -p tcp -j %s\n", outputChain)
+	fmt.Fprintf(b, "-A %s -m owner --uid-owner %d -j RETURN\n", outputChain, cfg.ProxyUID)
+	for _, uid := range cfg.ExcludeUIDs {
+		fmt.Fprintf(b, "-A %s -m owner --uid-owner %d -j RETURN\n", outputChain, uid)
+	}
+	fmt.Fprintf(b, "-A %s -d 127.0.0.1/32 -j RETURN\n", outputChain)
+	for _, port := range cfg.ExcludeOutboundPorts {
+		fmt.Fprintf(b, "-A %s -p tcp --dport %d -j RETURN\n", outputChain, port)
+	}
+	for _, cidr := range cfg.ExcludeOutboundCIDRs {
+		fmt.Fprintf(b, "-A %s -d %s -j RETURN\n", outputChain, cidr)
+	}
+	if len(cfg.IncludeOutboundCIDRs) == 0 {
+		fmt.Fprintf(b, "-A %s -j %s\n", outputChain, redirectChain)
+	}
+	for _, cidr := range cfg.IncludeOutboundCIDRs {
+		fmt.Fprintf(b, "-A %s -d %s -j %s\n", outputChain, cidr, redirectChain)
+	}
+	fmt.Fprintln(b, "COMMIT")
+}
+
+// writeMangle writes the mangle table, which intercepts inbound traffic with TPROXY
+func writeMangle(b *bytes.Buffer, cfg Config) {
+	fmt.Fprintln(b, "*mangle")
+	fmt.Fprintf(b, ":%s - [0:0]\n", inboundChain)
+	fmt.Fprintf(b, ":%s - [0:0]\n", divertChain)
+	fmt.Fprintf(b, ":%s - [0:0]\n", tproxyChain)
+
+	fmt.Fprintf(b, "-A %s -j MARK --set-xmark 0x%x/0xffffffff\n", divertChain, tproxyMark)
+	fmt.Fprintf(b, "-A %s -j ACCEPT\n", divertChain)
+	fmt.Fprintf(b, "-A %s ! -d 127.0.0.1/32 -p tcp -j TPROXY --on-port %d --on-ip 0.0.0.0 --tproxy-mark 0x%x/0xffffffff\n",
+		tproxyChain, cfg.InboundPort, tproxyMark)
+
+	fmt.Fprintf(b, "-A PREROUTING -p tcp -j %s\n", inboundChain)
+	// packets of connections that envoy already accepted go straight to it
+	fmt.Fprintf(b, "-A %s -p tcp -m socket -j %s\n", inboundChain, divertChain)
+	writeInbound(b, cfg, tproxyChain)
+	fmt.Fprintln(b, "COMMIT")
+}
+
+func writeInbound(b *bytes.Buffer, cfg Config, target string) {
+	for _, port := range cfg.ExcludeInboundPorts {
+		fmt.Fprintf(b, "-A %s -p tcp --dport %d -j RETURN\n", inboundChain, port)
+	}
+	if len(cfg.IncludeInboundPorts) == 0 {
+		fmt.Fprintf(b, "-A %s -p tcp -j %s\n", inboundChain, target)
+	}
+	for _, port := range cfg.IncludeInboundPorts {
+		fmt.Fprintf(b, "-A %s -p tcp --dport %d -j %s\n", inboundChain, port, target)
+	}
+}
@@ -0,0 +1,13 @@
+package iptables_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestIptables(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Iptables Suite")
+}
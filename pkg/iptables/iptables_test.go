@@ -0,0 +1,105 @@
+package iptables_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/solo-io/envoy-operator/pkg/iptables"
+)
+
+var _ = Describe("Iptables", func() {
+	var cfg Config
+
+	BeforeEach(func() {
+		cfg = Config{
+			OutboundPort: 15001,
+			InboundPort:  15006,
+			ProxyUID:     1337,
+		}
+	})
+
+	lines := func() []string {
+		rules, err := Generate(cfg)
+		Expect(err).NotTo(HaveOccurred())
+		return strings.Split(strings.TrimSpace(rules.Restore), "\n")
+	}
+
+	It("should redirect all traffic by default", func() {
+		Expect(lines()).To(Equal([]string{
+			"*nat",
+			":ENVOY_INBOUND - [0:0]",
+			":ENVOY_IN_REDIRECT - [0:0]",
+			":ENVOY_OUTPUT - [0:0]",
+			":ENVOY_REDIRECT - [0:0]",
+			"-A ENVOY_REDIRECT -p tcp -j REDIRECT --to-ports 15001",
+			"-A ENVOY_IN_REDIRECT -p tcp -j REDIRECT --to-ports 15006",
+			"-A PREROUTING -p tcp -j ENVOY_INBOUND",
+			"-A ENVOY_INBOUND -p tcp -j ENVOY_IN_REDIRECT",
+			"-A OUTPUT -p tcp -j ENVOY_OUTPUT",
+			"-A ENVOY_OUTPUT -m owner --uid-owner 1337 -j RETURN",
+			"-A ENVOY_OUTPUT -d 127.0.0.1/32 -j RETURN",
+			"-A ENVOY_OUTPUT -j ENVOY_REDIRECT",
+			"COMMIT",
+		}))
+	})
+
+	It("should only redirect included cidrs and ports", func() {
+		cfg.IncludeOutboundCIDRs = []string{"10.0.0.0/8"}
+		cfg.IncludeInboundPorts = []uint32{8080}
+		l := lines()
+		Expect(l).To(ContainElement("-A ENVOY_OUTPUT -d 10.0.0.0/8 -j ENVOY_REDIRECT"))
+		Expect(l).To(ContainElement("-A ENVOY_INBOUND -p tcp --dport 8080 -j ENVOY_IN_REDIRECT"))
+		Expect(l).NotTo(ContainElement("-A ENVOY_OUTPUT -j ENVOY_REDIRECT"))
+		Expect(l).NotTo(ContainElement("-A ENVOY_INBOUND -p tcp -j ENVOY_IN_REDIRECT"))
+	})
+
+	It("should exclude cidrs, ports and uids before redirecting", func() {
+		cfg.ExcludeOutboundCIDRs = []string{"169.254.169.254/32"}
+		cfg.ExcludeOutboundPorts = []uint32{5432}
+		cfg.ExcludeInboundPorts = []uint32{22}
+		cfg.ExcludeUIDs = []int64{1000}
+		l := lines()
+		redirect := indexOf(l, "-A ENVOY_OUTPUT -j ENVOY_REDIRECT")
+		Expect(indexOf(l, "-A ENVOY_OUTPUT -d 169.254.169.254/32 -j RETURN")).To(BeNumerically("<", redirect))
+		Expect(indexOf(l, "-A ENVOY_OUTPUT -p tcp --dport 5432 -j RETURN")).To(BeNumerically("<", redirect))
+		Expect(indexOf(l, "-A ENVOY_OUTPUT -m owner --uid-owner 1000 -j RETURN")).To(BeNumerically("<", redirect))
+		Expect(indexOf(l, "-A ENVOY_INBOUND -p tcp --dport 22 -j RETURN")).To(BeNumerically("<",
+			indexOf(l, "-A ENVOY_INBOUND -p tcp -j ENVOY_IN_REDIRECT")))
+	})
+
+	It("should intercept inbound traffic with tproxy", func() {
+		cfg.Mode = ModeTProxy
+		rules, err := Generate(cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rules.Restore).To(HavePrefix("*mangle\n"))
+		Expect(rules.Restore).To(ContainSubstring("-A ENVOY_TPROXY ! -d 127.0.0.1/32 -p tcp -j TPROXY --on-port 15006 --on-ip 0.0.0.0 --tproxy-mark 0x539/0xffffffff\n"))
+		Expect(rules.Restore).To(ContainSubstring("-A ENVOY_INBOUND -p tcp -j ENVOY_TPROXY\n"))
+		Expect(rules.Restore).NotTo(ContainSubstring("ENVOY_IN_REDIRECT"))
+		Expect(rules.Restore).To(ContainSubstring("-A ENVOY_OUTPUT -j ENVOY_REDIRECT\n"))
+		Expect(rules.Commands).To(ContainElement([]string{"ip", "-f", "inet", "rule", "add", "fwmark", "1337", "lookup", "133"}))
+	})
+
+	It("should reject invalid cidrs", func() {
+		cfg.ExcludeOutboundCIDRs = []string{"10.0.0.0"}
+		_, err := Generate(cfg)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject unknown modes", func() {
+		cfg.Mode = "NAT"
+		_, err := Generate(cfg)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+func indexOf(lines []string, line string) int {
+	for i, l := range lines {
+		if l == line {
+			return i
+		}
+	}
+	Fail("missing line: " + line)
+	return -1
+}
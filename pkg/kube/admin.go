@@ -0,0 +1,155 @@
+package kube
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/golang/protobuf/ptypes/duration"
+
+	envoy_config_bootstrap "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v3"
+	envoy_cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	envoy_listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	envoy_route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+)
+
+const (
+	defaultAdminBindAddress = "127.0.0.1"
+	defaultStatsPort        = 19001
+
+	statsPortName     = "stats"
+	statsListenerName = "stats"
+	adminClusterName  = "admin"
+	statsPathPrefix   = "/stats"
+)
+
+func adminBindAddress(e *api.Envoy) string {
+	if e.Spec.Admin != nil && e.Spec.Admin.BindAddress != "" {
+		return e.Spec.Admin.BindAddress
+	}
+	return defaultAdminBindAddress
+}
+
+// adminDialAddress returns the address the pod's own processes reach the admin interface at
+func adminDialAddress(e *api.Envoy) string {
+	address := adminBindAddress(e)
+	if ip := net.ParseIP(address); ip == nil || ip.IsUnspecified() {
+		return defaultAdminBindAddress
+	}
+	return address
+}
+
+// adminIsLocal returns true if the admin interface can't be reached from outside the pod
+func adminIsLocal(e *api.Envoy) bool {
+	ip := net.ParseIP(adminBindAddress(e))
+	return ip != nil && ip.IsLoopback()
+}
+
+// statsPort returns the port of the stats listener, or 0 if the envoy doesn't need one
+func statsPort(e *api.Envoy) uint32 {
+	if e.Spec.AdminPort == 0 {
+		return 0
+	}
+	if m := e.Spec.Metrics; m != nil && m.Port != 0 {
+		return m.Port
+	}
+	if !adminIsLocal(e) {
+		return 0
+	}
+	if e.Spec.Admin != nil && e.Spec.Admin.StatsPort != 0 {
+		return e.Spec.Admin.StatsPort
+	}
+	return defaultStatsPort
+}
+
+// addAdmin binds the admin interface, and adds the stats listener that exposes its read only
+// endpoints
+func addAdmin(e *api.Envoy, bootstrapConfig *envoy_config_bootstrap.Bootstrap) error {
+	bootstrapConfig.Admin = &envoy_config_bootstrap.Admin{}
+	bootstrapConfig.Admin.AccessLogPath = "/dev/stderr"
+	if e.Spec.AdminPort == 0 {
+		if m := e.Spec.Metrics; m != nil && m.Port != 0 {
+			return fmt.Errorf("the metrics port needs an admin port")
+		}
+		return nil
+	}
+	bootstrapConfig.Admin.Address = socketAddress(adminBindAddress(e), uint32(e.Spec.AdminPort))
+
+	port := statsPort(e)
+	if port == 0 {
+		return nil
+	}
+	if port == uint32(e.Spec.AdminPort) {
+		return fmt.Errorf("the stats listener and the admin interface can't share port %d", port)
+	}
+	filter, err := httpConnectionManager(statsListenerName, statsRouteConfig(), nil)
+	if err != nil {
+		return err
+	}
+	bootstrapConfig.StaticResources.Listeners = append(bootstrapConfig.StaticResources.Listeners, &envoy_listener.Listener{
+		Name:    statsListenerName,
+		Address: socketAddress("0.0.0.0", port),
+		FilterChains: []*envoy_listener.FilterChain{{
+			Filters: []*envoy_listener.Filter{filter},
+		}},
+	})
+	bootstrapConfig.StaticResources.Clusters = append(bootstrapConfig.StaticResources.Clusters, adminCluster(e))
+	return nil
+}
+
+// statsRouteConfig only routes GET requests for /ready and the stats to the admin interface;
+// everything else, like /quitquitquit or the POSTs that change the stats settings, gets a 404
+func statsRouteConfig() *envoy_route.RouteConfiguration {
+	get := []*envoy_route.HeaderMatcher{{
+		Name:                 ":method",
+		HeaderMatchSpecifier: &envoy_route.HeaderMatcher_ExactMatch{ExactMatch: "GET"},
+	}}
+	toAdmin := &envoy_route.Route_Route{
+		Route: &envoy_route.RouteAction{
+			ClusterSpecifier: &envoy_route.RouteAction_Cluster{Cluster: adminClusterName},
+		},
+	}
+	return &envoy_route.RouteConfiguration{
+		Name: statsListenerName,
+		VirtualHosts: []*envoy_route.VirtualHost{{
+			Name:    statsListenerName,
+			Domains: []string{"*"},
+			Routes: []*envoy_route.Route{{
+				Match: &envoy_route.RouteMatch{
+					PathSpecifier: &envoy_route.RouteMatch_Path{Path: readyPath},
+					Headers:       get,
+				},
+				Action: toAdmin,
+			}, {
+				Match: &envoy_route.RouteMatch{
+					PathSpecifier: &envoy_route.RouteMatch_Prefix{Prefix: statsPathPrefix},
+					Headers:       get,
+				},
+				Action: toAdmin,
+			}},
+		}},
+	}
+}
+
+// adminCluster returns the cluster of envoy's own admin interface
+func adminCluster(e *api.Envoy) *envoy_cluster.Cluster {
+	return &envoy_cluster.Cluster{
+		Name:                 adminClusterName,
+		ClusterDiscoveryType: &envoy_cluster.Cluster_Type{Type: envoy_cluster.Cluster_STATIC},
+		ConnectTimeout:       &duration.Duration{Seconds: 1},
+		LoadAssignment: &envoy_endpoint.ClusterLoadAssignment{
+			ClusterName: adminClusterName,
+			Endpoints: []*envoy_endpoint.LocalityLbEndpoints{{
+				LbEndpoints: []*envoy_endpoint.LbEndpoint{{
+					HostIdentifier: &envoy_endpoint.LbEndpoint_Endpoint{
+						Endpoint: &envoy_endpoint.Endpoint{
+							Address: socketAddress(adminDialAddress(e), uint32(e.Spec.AdminPort)),
+						},
+					},
+				}},
+			}},
+		},
+	}
+}
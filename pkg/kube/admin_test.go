@@ -0,0 +1,80 @@
+package kube_test
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	envoy_hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	. "github.com/solo-io/envoy-operator/pkg/kube"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Admin", func() {
+	var e *api.Envoy
+
+	BeforeEach(func() {
+		e = testEnvoy()
+	})
+
+	It("should bind the admin interface to localhost", func() {
+		b := generate(e, nil)
+		addr := b.Admin.Address.GetSocketAddress()
+		Expect(addr.Address).To(Equal("127.0.0.1"))
+		Expect(addr.GetPortValue()).To(BeEquivalentTo(19000))
+
+		ports := EnvoyContainer(e).Ports
+		Expect(ports).To(Equal([]v1.ContainerPort{{Name: "stats", ContainerPort: 19001}}))
+	})
+
+	It("should only proxy the read only endpoints to the admin interface", func() {
+		b := generate(e, nil)
+		Expect(b.StaticResources.Listeners).To(HaveLen(1))
+		l := b.StaticResources.Listeners[0]
+		Expect(l.Name).To(Equal("stats"))
+		Expect(l.Address.GetSocketAddress().Address).To(Equal("0.0.0.0"))
+		Expect(l.Address.GetSocketAddress().GetPortValue()).To(BeEquivalentTo(19001))
+
+		var hcm envoy_hcm.HttpConnectionManager
+		err := ptypes.UnmarshalAny(l.FilterChains[0].Filters[0].GetTypedConfig(), &hcm)
+		Expect(err).NotTo(HaveOccurred())
+		routes := hcm.GetRouteConfig().VirtualHosts[0].Routes
+		Expect(routes).To(HaveLen(2))
+		Expect(routes[0].Match.GetPath()).To(Equal("/ready"))
+		Expect(routes[1].Match.GetPrefix()).To(Equal("/stats"))
+		for _, r := range routes {
+			Expect(r.Match.Headers[0].Name).To(Equal(":method"))
+			Expect(r.Match.Headers[0].GetExactMatch()).To(Equal("GET"))
+			Expect(r.GetRoute().GetCluster()).To(Equal("admin"))
+		}
+
+		admin := clusterNamed(b, "admin")
+		addr := admin.LoadAssignment.Endpoints[0].LbEndpoints[0].GetEndpoint().Address.GetSocketAddress()
+		Expect(addr.Address).To(Equal("127.0.0.1"))
+		Expect(addr.GetPortValue()).To(BeEquivalentTo(19000))
+	})
+
+	It("should not need a stats listener when the admin interface is reachable", func() {
+		e.Spec.Admin.BindAddress = "0.0.0.0"
+		b := generate(e, nil)
+		Expect(b.Admin.Address.GetSocketAddress().Address).To(Equal("0.0.0.0"))
+		Expect(b.StaticResources.Listeners).To(BeEmpty())
+		Expect(clusterNamed(b, "admin")).To(BeNil())
+		Expect(EnvoyContainer(e).Ports).To(Equal([]v1.ContainerPort{{Name: "admin", ContainerPort: 19000}}))
+	})
+
+	It("should reject a stats port equal to the admin port", func() {
+		e.Spec.Admin.StatsPort = 19000
+		_, err := GenerateEnvoyConfig(e, nil)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should not intercept the stats port", func() {
+		e.Spec.Injection = &api.InjectionSpec{Interception: &api.InterceptionSpec{}}
+		e.SetDefaults()
+		Expect(InterceptionInitContainer(e).Args).To(ContainElement("19000,19001"))
+	})
+})
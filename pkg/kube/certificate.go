@@ -0,0 +1,146 @@
+package kube
+
+import (
+	"fmt"
+	"strings"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	CertManagerAPIVersion = "cert-manager.io/v1"
+	CertificateKind       = "Certificate"
+
+	certificateSuffix = "-client"
+)
+
+func certIssuerRef(e *api.Envoy) *api.CertIssuerRef {
+	if e.Spec.TLS == nil {
+		return nil
+	}
+	return e.Spec.TLS.IssuerRef
+}
+
+// TLSSecretNameForEnvoy returns the name of the secret holding the certs of the control plane
+// connection: the tls_secret_name, or the secret of the envoy's cert-manager Certificate
+func TLSSecretNameForEnvoy(e *api.Envoy) string {
+	if certIssuerRef(e) != nil {
+		return CertificateSecretName(e)
+	}
+	return e.Spec.TLSSecretName
+}
+
+func CertificateSecretName(e *api.Envoy) string {
+	return e.Name + certificateSuffix + "-tls"
+}
+
+// CertificateForEnvoy returns the cert-manager Certificate of the client certificate of the envoy.
+// Its names come from the node and cluster ids; ids that are templates differ between the pods,
+// which share the certificate, and are left out.
+func CertificateForEnvoy(e *api.Envoy) (*unstructured.Unstructured, error) {
+	ref := certIssuerRef(e)
+	if ref == nil {
+		return nil, fmt.Errorf("envoy has no issuerRef")
+	}
+	if e.Spec.TLSSecretName != "" {
+		return nil, fmt.Errorf("set either the tls_secret_name or the tls issuerRef")
+	}
+	if ref.Name == "" {
+		return nil, fmt.Errorf("the tls issuerRef needs a name")
+	}
+	kind := ref.Kind
+	if kind == "" {
+		kind = "Issuer"
+	}
+	group := ref.Group
+	if group == "" {
+		group = "cert-manager.io"
+	}
+
+	commonName := e.Name
+	var dnsNames, uris []interface{}
+	if id := e.Spec.ClusterIdTemplate; id != "" && !isTemplate(id) {
+		commonName = id
+		uris = append(uris, fmt.Sprintf("envoy://%s/%s", e.Namespace, id))
+		if len(validation.IsDNS1123Subdomain(id)) == 0 {
+			dnsNames = append(dnsNames, id)
+		}
+		if node := e.Spec.NodeIdTemplate; node != "" && !isTemplate(node) {
+			uris = append(uris, fmt.Sprintf("envoy://%s/%s/%s", e.Namespace, id, node))
+		}
+	}
+	if len(commonName) > 64 {
+		// the longest common name x509 allows
+		commonName = commonName[:64]
+	}
+
+	spec := map[string]interface{}{
+		"secretName": CertificateSecretName(e),
+		"commonName": commonName,
+		"usages":     []interface{}{"client auth", "digital signature", "key encipherment"},
+		"issuerRef": map[string]interface{}{
+			"name":  ref.Name,
+			"kind":  kind,
+			"group": group,
+		},
+	}
+	if len(dnsNames) != 0 {
+		spec["dnsNames"] = dnsNames
+	}
+	if len(uris) != 0 {
+		spec["uris"] = uris
+	}
+	if d := e.Spec.TLS.Duration; d != nil {
+		spec["duration"] = d.Duration.String()
+	}
+	if d := e.Spec.TLS.RenewBefore; d != nil {
+		spec["renewBefore"] = d.Duration.String()
+	}
+
+	u := EmptyCertificate(e)
+	u.SetLabels(LabelsForEnvoy(e))
+	u.Object["spec"] = spec
+	if err := setSpecHash(u, u.Object); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// EmptyCertificate returns a Certificate with only the type and name of the one of the envoy
+func EmptyCertificate(e *api.Envoy) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	u.SetAPIVersion(CertManagerAPIVersion)
+	u.SetKind(CertificateKind)
+	u.SetName(e.Name + certificateSuffix)
+	u.SetNamespace(e.Namespace)
+	return u
+}
+
+// CertificateNeedsUpdate returns true when the labels or the spec of the live Certificate differ
+// from the desired ones
+func CertificateNeedsUpdate(desired, live *unstructured.Unstructured) (bool, error) {
+	return unstructuredNeedsUpdate(desired, live)
+}
+
+// UpdateCertificate copies the managed fields of the desired Certificate to the live one
+func UpdateCertificate(desired, live *unstructured.Unstructured) {
+	updateUnstructured(desired, live)
+}
+
+// CertificateReady returns the status and message of the Ready condition of the Certificate
+func CertificateReady(u *unstructured.Unstructured) (bool, string) {
+	status, _ := u.Object["status"].(map[string]interface{})
+	conditions, _ := status["conditions"].([]interface{})
+	for _, c := range conditions {
+		condition, _ := c.(map[string]interface{})
+		if condition["type"] != "Ready" {
+			continue
+		}
+		message, _ := condition["message"].(string)
+		return strings.EqualFold(fmt.Sprint(condition["status"]), "true"), message
+	}
+	return false, "the certificate has not been issued yet"
+}
@@ -0,0 +1,136 @@
+package kube_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	. "github.com/solo-io/envoy-operator/pkg/kube"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("Certificate", func() {
+	var e *api.Envoy
+
+	BeforeEach(func() {
+		e = testEnvoy()
+		e.Spec.TLS = &api.TLSSpec{
+			IssuerRef: &api.CertIssuerRef{Name: "ca"},
+		}
+	})
+
+	It("should request a client certificate from the issuer", func() {
+		u, err := CertificateForEnvoy(e)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(u.GetAPIVersion()).To(Equal("cert-manager.io/v1"))
+		Expect(u.GetKind()).To(Equal("Certificate"))
+		Expect(u.GetName()).To(Equal("myenvoy-client"))
+		Expect(u.GetLabels()).To(Equal(LabelsForEnvoy(e)))
+
+		spec, _ := unstructured.NestedMap(u.Object, "spec")
+		Expect(spec).To(HaveKeyWithValue("secretName", "myenvoy-client-tls"))
+		Expect(spec).To(HaveKeyWithValue("commonName", "ingress"))
+		Expect(spec).To(HaveKeyWithValue("issuerRef", map[string]interface{}{
+			"name":  "ca",
+			"kind":  "Issuer",
+			"group": "cert-manager.io",
+		}))
+		Expect(spec["usages"]).To(ContainElement("client auth"))
+		Expect(spec).NotTo(HaveKey("duration"))
+	})
+
+	It("should leave templated ids out of the names", func() {
+		u, err := CertificateForEnvoy(e)
+		Expect(err).NotTo(HaveOccurred())
+		uris, _ := unstructured.NestedStringSlice(u.Object, "spec", "uris")
+		Expect(uris).To(Equal([]string{"envoy://default/ingress"}))
+		dnsNames, _ := unstructured.NestedStringSlice(u.Object, "spec", "dnsNames")
+		Expect(dnsNames).To(Equal([]string{"ingress"}))
+
+		e.Spec.NodeIdTemplate = "edge"
+		u, err = CertificateForEnvoy(e)
+		Expect(err).NotTo(HaveOccurred())
+		uris, _ = unstructured.NestedStringSlice(u.Object, "spec", "uris")
+		Expect(uris).To(ContainElement("envoy://default/ingress/edge"))
+
+		e.Spec.ClusterIdTemplate = "{{.PodName}}"
+		u, err = CertificateForEnvoy(e)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(u.Object["spec"]).NotTo(HaveKey("uris"))
+		Expect(u.Object["spec"]).To(HaveKeyWithValue("commonName", "myenvoy"))
+	})
+
+	It("should set the lifetime of the certificate", func() {
+		e.Spec.TLS.IssuerRef.Kind = "ClusterIssuer"
+		e.Spec.TLS.Duration = &metav1.Duration{Duration: 24 * time.Hour}
+		e.Spec.TLS.RenewBefore = &metav1.Duration{Duration: time.Hour}
+		u, err := CertificateForEnvoy(e)
+		Expect(err).NotTo(HaveOccurred())
+		spec, _ := unstructured.NestedMap(u.Object, "spec")
+		Expect(spec).To(HaveKeyWithValue("duration", "24h0m0s"))
+		Expect(spec).To(HaveKeyWithValue("renewBefore", "1h0m0s"))
+		kind, _ := unstructured.NestedString(u.Object, "spec", "issuerRef", "kind")
+		Expect(kind).To(Equal("ClusterIssuer"))
+	})
+
+	It("should reject a tls secret name with an issuer", func() {
+		e.Spec.TLSSecretName = "certs"
+		_, err := CertificateForEnvoy(e)
+		Expect(err).To(MatchError(ContainSubstring("tls_secret_name")))
+	})
+
+	It("should mount the secret of the certificate", func() {
+		Expect(TLSSecretNameForEnvoy(e)).To(Equal("myenvoy-client-tls"))
+		t, err := PodTemplateForEnvoy(e, nil)
+		Expect(err).NotTo(HaveOccurred())
+		var secretNames []string
+		for _, v := range t.Spec.Volumes {
+			if v.Secret != nil {
+				secretNames = append(secretNames, v.Secret.SecretName)
+			}
+		}
+		Expect(secretNames).To(ContainElement("myenvoy-client-tls"))
+	})
+
+	It("should detect changed certificates", func() {
+		live, err := CertificateForEnvoy(e)
+		Expect(err).NotTo(HaveOccurred())
+		e.Spec.TLS.Duration = &metav1.Duration{Duration: time.Hour}
+		desired, err := CertificateForEnvoy(e)
+		Expect(err).NotTo(HaveOccurred())
+
+		needsUpdate, err := CertificateNeedsUpdate(desired, live)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(needsUpdate).To(BeTrue())
+
+		UpdateCertificate(desired, live)
+		needsUpdate, err = CertificateNeedsUpdate(desired, live)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(needsUpdate).To(BeFalse())
+	})
+
+	It("should read the ready condition", func() {
+		u := EmptyCertificate(e)
+		ready, _ := CertificateReady(u)
+		Expect(ready).To(BeFalse())
+
+		u.Object["status"] = map[string]interface{}{
+			"conditions": []interface{}{map[string]interface{}{
+				"type":    "Ready",
+				"status":  "False",
+				"message": "issuer not ready",
+			}},
+		}
+		ready, message := CertificateReady(u)
+		Expect(ready).To(BeFalse())
+		Expect(message).To(Equal("issuer not ready"))
+
+		u.Object["status"].(map[string]interface{})["conditions"].([]interface{})[0].(map[string]interface{})["status"] = "True"
+		ready, _ = CertificateReady(u)
+		Expect(ready).To(BeTrue())
+	})
+})
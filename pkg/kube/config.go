@@ -1,81 +1,43 @@
 package kube
 
 import (
-	"path/filepath"
+	"fmt"
+	"net"
 
 	"github.com/golang/protobuf/ptypes"
 
 	"github.com/golang/protobuf/jsonpb"
-	"github.com/golang/protobuf/ptypes/duration"
 
 	envoy_config_bootstrap "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v3"
 	envoy_cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
 	envoy_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
-	envoy_endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
 	envoy_tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 
 	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
 	v1 "k8s.io/api/core/v1"
 )
 
-func controlPlaneCluster(e *api.Envoy, tlsSecret *v1.Secret) (*envoy_cluster.Cluster, error) {
+func controlPlaneCluster(e *api.Envoy, endpoints []api.ControlPlaneEndpoint, tlsSecret *v1.Secret) (*envoy_cluster.Cluster, error) {
 	var ret envoy_cluster.Cluster
-	ret.Name = "ads-control-plane"
-	ret.Http2ProtocolOptions = &envoy_core.Http2ProtocolOptions{}
-	ret.ConnectTimeout = &duration.Duration{
-		Seconds: 5,
-	}
-	hostAddress := &envoy_core.Address{
-		Address: &envoy_core.Address_SocketAddress{
-			SocketAddress: &envoy_core.SocketAddress{
-				Address: e.Spec.ADSServer,
-				PortSpecifier: &envoy_core.SocketAddress_PortValue{
-					PortValue: uint32(e.Spec.ADSPort),
-				},
-			},
-		},
+	ret.Name = controlPlaneClusterName
+	if xdsMode(e) != api.XDSModeREST {
+		ret.Http2ProtocolOptions = &envoy_core.Http2ProtocolOptions{}
 	}
-	ret.LoadAssignment = &envoy_endpoint.ClusterLoadAssignment{
-		Endpoints: []*envoy_endpoint.LocalityLbEndpoints{{
-			LbEndpoints: []*envoy_endpoint.LbEndpoint{{
-				HostIdentifier: &envoy_endpoint.LbEndpoint_Endpoint{
-					Endpoint: &envoy_endpoint.Endpoint{
-						Address: hostAddress,
-					},
-				},
-			}},
-		}},
+	if err := configureControlPlaneCluster(e, &ret, endpoints); err != nil {
+		return nil, err
 	}
 
-	if tlsSecret != nil {
-
-		// get the secret and see if we have a client certificate
-
-		TLSCAFile := filepath.Join(api.EnvoyTLSVolPath, api.TLSCA)
-		TLSCertFile := filepath.Join(api.EnvoyTLSVolPath, api.TLSCert)
-		TLSKeyFile := filepath.Join(api.EnvoyTLSVolPath, api.TLSKey)
-
-		// create client tls context
-		tlsContext := &envoy_tls.UpstreamTlsContext{
-			CommonTlsContext: &envoy_tls.CommonTlsContext{
-				ValidationContextType: &envoy_tls.CommonTlsContext_ValidationContext{
-					ValidationContext: &envoy_tls.CertificateValidationContext{
-						TrustedCa: toDataSource(TLSCAFile),
-					},
-				},
-			},
-			Sni: e.Spec.ADSServer,
+	if tlsSecret != nil || sdsServer(e) != nil {
+		// sni is a host name, so ip addresses aren't sent. The endpoints share the sni of the
+		// first, so failover endpoints need a certificate valid for its name.
+		sni := endpoints[0].Address
+		if net.ParseIP(sni) != nil {
+			sni = ""
 		}
-
-		needClientCert := hasKey(tlsSecret)
-		if needClientCert {
-
-			tlsContext.CommonTlsContext.TlsCertificates = []*envoy_tls.TlsCertificate{{
-				CertificateChain: toDataSource(TLSCertFile),
-				PrivateKey:       toDataSource(TLSKeyFile),
-			}}
+		tlsContext, err := controlPlaneTLSContext(e, sni, tlsSecret)
+		if err != nil {
+			return nil, err
 		}
-
 		tlsContextAny, err := ptypes.MarshalAny(tlsContext)
 		if err != nil {
 			return nil, err
@@ -86,21 +48,83 @@ func controlPlaneCluster(e *api.Envoy, tlsSecret *v1.Secret) (*envoy_cluster.Clu
 				TypedConfig: tlsContextAny,
 			},
 		}
-
+	} else if tlsParametersSet(e.Spec.TLS) {
+		return nil, fmt.Errorf("the tls parameters need a tls_secret_name or an sds server")
 	}
-	// TODO setup TLS
 
 	return &ret, nil
 }
 
-func hasKey(secret *v1.Secret) bool {
-	if _, ok := secret.Data[api.TLSKey]; !ok {
-		return false
+// controlPlaneTLSContext returns the tls context of the connection to the control plane. The
+// certs are read from the mounted tls secret, or delivered by an sds server.
+func controlPlaneTLSContext(e *api.Envoy, sni string, tlsSecret *v1.Secret) (*envoy_tls.UpstreamTlsContext, error) {
+	spec := e.Spec.TLS
+	if spec == nil {
+		spec = &api.TLSSpec{}
+	}
+	if spec.SNI != "" {
+		sni = spec.SNI
+	}
+	params, err := tlsParameters(spec)
+	if err != nil {
+		return nil, err
+	}
+	matchers, err := stringMatchers(spec.MatchSubjectAltNames)
+	if err != nil {
+		return nil, err
+	}
+	validation := &envoy_tls.CertificateValidationContext{MatchSubjectAltNames: matchers}
+	if spec.CRLKey != "" {
+		if tlsSecret == nil {
+			return nil, fmt.Errorf("the tls crlKey needs a tls_secret_name")
+		}
+		if _, ok := tlsSecret.Data[spec.CRLKey]; !ok {
+			return nil, fmt.Errorf("secret %s has no %s", tlsSecret.Name, spec.CRLKey)
+		}
+		validation.Crl = toDataSource(tlsFile(spec.CRLKey))
+	}
+
+	certFile, keyFile := clientCertFiles(e, tlsSecret)
+	// an sds server always has a client certificate to give
+	needClientCert := sdsServer(e) != nil || certFile != ""
+
+	common := &envoy_tls.CommonTlsContext{
+		TlsParams:     params,
+		AlpnProtocols: spec.ALPN,
 	}
-	if _, ok := secret.Data[api.TLSCert]; !ok {
-		return false
+	if server := sdsServer(e); server != nil {
+		certificate, validationContext := sdsNames(server)
+		common.ValidationContextType = &envoy_tls.CommonTlsContext_ValidationContextSdsSecretConfig{
+			ValidationContextSdsSecretConfig: sdsSecretConfig(validationContext),
+		}
+		if len(matchers) != 0 || validation.Crl != nil {
+			// sds delivers the ca, and the rest of the validation is combined with it
+			common.ValidationContextType = &envoy_tls.CommonTlsContext_CombinedValidationContext{
+				CombinedValidationContext: &envoy_tls.CommonTlsContext_CombinedCertificateValidationContext{
+					DefaultValidationContext:         validation,
+					ValidationContextSdsSecretConfig: sdsSecretConfig(validationContext),
+				},
+			}
+		}
+		if needClientCert {
+			common.TlsCertificateSdsSecretConfigs = []*envoy_tls.SdsSecretConfig{sdsSecretConfig(certificate)}
+		}
+	} else {
+		validation.TrustedCa = toDataSource(tlsFile(api.TLSCA))
+		common.ValidationContextType = &envoy_tls.CommonTlsContext_ValidationContext{
+			ValidationContext: validation,
+		}
+		if needClientCert {
+			common.TlsCertificates = []*envoy_tls.TlsCertificate{{
+				CertificateChain: toDataSource(certFile),
+				PrivateKey:       toDataSource(keyFile),
+			}}
+		}
 	}
-	return true
+	return &envoy_tls.UpstreamTlsContext{
+		CommonTlsContext: common,
+		Sni:              sni,
+	}, nil
 }
 
 func toDataSource(f string) *envoy_core.DataSource {
@@ -120,48 +144,61 @@ func GenerateEnvoyConfig(e *api.Envoy, tlsSecret *v1.Secret) (string, error) {
 		Cluster: e.Spec.ClusterIdTemplate,
 	}
 
-	cluster, err := controlPlaneCluster(e, tlsSecret)
+	if err := validateSPIFFE(e); err != nil {
+		return "", err
+	}
+	bootstrapConfig.StaticResources = &envoy_config_bootstrap.Bootstrap_StaticResources{}
+	endpoints, err := controlPlaneEndpoints(e)
 	if err != nil {
 		return "", err
 	}
-	bootstrapConfig.StaticResources = &envoy_config_bootstrap.Bootstrap_StaticResources{
-		Clusters: []*envoy_cluster.Cluster{cluster},
-	}
-	bootstrapConfig.DynamicResources = &envoy_config_bootstrap.Bootstrap_DynamicResources{
-		AdsConfig: &envoy_core.ApiConfigSource{
-			ApiType: envoy_core.ApiConfigSource_GRPC,
-			GrpcServices: []*envoy_core.GrpcService{{
-				TargetSpecifier: &envoy_core.GrpcService_EnvoyGrpc_{
-					EnvoyGrpc: &envoy_core.GrpcService_EnvoyGrpc{
-						ClusterName: bootstrapConfig.StaticResources.Clusters[0].Name,
-					},
-				},
-			}},
-		},
-		CdsConfig: &envoy_core.ConfigSource{
-			ConfigSourceSpecifier: &envoy_core.ConfigSource_Ads{
-				Ads: &envoy_core.AggregatedConfigSource{},
-			},
-		},
-		LdsConfig: &envoy_core.ConfigSource{
-			ConfigSourceSpecifier: &envoy_core.ConfigSource_Ads{
-				Ads: &envoy_core.AggregatedConfigSource{},
-			},
-		},
+	if len(endpoints) != 0 {
+		cluster, err := controlPlaneCluster(e, endpoints, tlsSecret)
+		if err != nil {
+			return "", err
+		}
+		bootstrapConfig.StaticResources.Clusters = append(bootstrapConfig.StaticResources.Clusters, cluster)
+		grpcService, err := controlPlaneGrpcService(e, endpoints, cluster.Name, tlsSecret)
+		if err != nil {
+			return "", err
+		}
+		bootstrapConfig.DynamicResources, err = dynamicResources(e, cluster.Name, grpcService)
+		if err != nil {
+			return "", err
+		}
+	} else if e.Spec.StaticResources == nil {
+		return "", fmt.Errorf("envoy needs an adsServer, control plane endpoints or static resources")
 	}
-	bootstrapConfig.Admin = &envoy_config_bootstrap.Admin{}
-	bootstrapConfig.Admin.AccessLogPath = "/dev/stderr"
-	if e.Spec.AdminPort != 0 {
-		bootstrapConfig.Admin.Address = &envoy_core.Address{
-			Address: &envoy_core.Address_SocketAddress{
-				SocketAddress: &envoy_core.SocketAddress{
-					Address: "0.0.0.0",
-					PortSpecifier: &envoy_core.SocketAddress_PortValue{
-						PortValue: uint32(e.Spec.AdminPort),
-					},
-				},
-			},
+
+	listeners, clusters, err := staticResources(e)
+	if err != nil {
+		return "", err
+	}
+	bootstrapConfig.StaticResources.Listeners = append(bootstrapConfig.StaticResources.Listeners, listeners...)
+	bootstrapConfig.StaticResources.Clusters = append(bootstrapConfig.StaticResources.Clusters, clusters...)
+
+	if ic := interceptionFor(e); ic != nil {
+		listeners, clusters, err := interceptionResources(ic)
+		if err != nil {
+			return "", err
 		}
+		bootstrapConfig.StaticResources.Listeners = append(bootstrapConfig.StaticResources.Listeners, listeners...)
+		bootstrapConfig.StaticResources.Clusters = append(bootstrapConfig.StaticResources.Clusters, clusters...)
+	}
+	if err := addStats(e, &bootstrapConfig); err != nil {
+		return "", err
+	}
+	if err := addTracing(e, &bootstrapConfig); err != nil {
+		return "", err
+	}
+	if err := addAdmin(e, &bootstrapConfig); err != nil {
+		return "", err
+	}
+	if err := addSDS(e, &bootstrapConfig); err != nil {
+		return "", err
+	}
+	if err := checkUniqueNames(bootstrapConfig.StaticResources); err != nil {
+		return "", err
 	}
 
 	// TODO: change to yaml?
@@ -173,3 +210,23 @@ func GenerateEnvoyConfig(e *api.Envoy, tlsSecret *v1.Secret) (string, error) {
 	}
 	return cfgData, nil
 }
+
+// checkUniqueNames returns an error if the static resources have listeners or clusters with the
+// same name, e.g. static clusters named like the generated ones
+func checkUniqueNames(resources *envoy_config_bootstrap.Bootstrap_StaticResources) error {
+	listeners := map[string]bool{}
+	for _, l := range resources.Listeners {
+		if listeners[l.Name] {
+			return fmt.Errorf("duplicate listener %q", l.Name)
+		}
+		listeners[l.Name] = true
+	}
+	clusters := map[string]bool{}
+	for _, c := range resources.Clusters {
+		if clusters[c.Name] {
+			return fmt.Errorf("duplicate cluster %q", c.Name)
+		}
+		clusters[c.Name] = true
+	}
+	return nil
+}
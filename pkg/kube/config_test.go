@@ -0,0 +1,79 @@
+package kube_test
+
+import (
+	"github.com/golang/protobuf/jsonpb"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	envoy_config_bootstrap "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v3"
+	envoy_cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	. "github.com/solo-io/envoy-operator/pkg/kube"
+	v1 "k8s.io/api/core/v1"
+)
+
+func generate(e *api.Envoy, tlsSecret *v1.Secret) *envoy_config_bootstrap.Bootstrap {
+	cfg, err := GenerateEnvoyConfig(e, tlsSecret)
+	Expect(err).NotTo(HaveOccurred())
+	var bootstrap envoy_config_bootstrap.Bootstrap
+	err = jsonpb.UnmarshalString(cfg, &bootstrap)
+	Expect(err).NotTo(HaveOccurred())
+	return &bootstrap
+}
+
+func clusterNamed(b *envoy_config_bootstrap.Bootstrap, name string) *envoy_cluster.Cluster {
+	for _, c := range b.StaticResources.Clusters {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+var _ = Describe("Config", func() {
+	var e *api.Envoy
+
+	BeforeEach(func() {
+		e = testEnvoy()
+	})
+
+	It("should generate the ads control plane cluster", func() {
+		b := generate(e, nil)
+		Expect(b.Node.Id).To(Equal("{{.PodName}}-ingress"))
+		Expect(b.Node.Cluster).To(Equal("ingress"))
+		Expect(b.StaticResources.Clusters[0].Name).To(Equal("ads-control-plane"))
+		Expect(b.DynamicResources.AdsConfig.GrpcServices[0].GetEnvoyGrpc().ClusterName).To(Equal("ads-control-plane"))
+	})
+
+	Context("interception", func() {
+		BeforeEach(func() {
+			e.Spec.Injection = &api.InjectionSpec{
+				Interception: &api.InterceptionSpec{},
+			}
+			e.SetDefaults()
+		})
+
+		It("should add original destination listeners", func() {
+			b := generate(e, nil)
+			Expect(b.StaticResources.Listeners).To(HaveLen(3))
+			for _, l := range b.StaticResources.Listeners[:2] {
+				Expect(l.ListenerFilters[0].Name).To(Equal("envoy.listener.original_dst"))
+				Expect(l.FilterChains[0].Filters[0].Name).To(Equal("envoy.tcp_proxy"))
+			}
+			Expect(b.StaticResources.Listeners[0].Address.GetSocketAddress().GetPortValue()).To(BeEquivalentTo(15001))
+			Expect(b.StaticResources.Listeners[1].Address.GetSocketAddress().GetPortValue()).To(BeEquivalentTo(15006))
+			Expect(b.StaticResources.Listeners[1].Transparent).To(BeNil())
+
+			passthrough := clusterNamed(b, "passthrough")
+			Expect(passthrough).NotTo(BeNil())
+			Expect(passthrough.GetType()).To(Equal(envoy_cluster.Cluster_ORIGINAL_DST))
+		})
+
+		It("should make the inbound listener transparent with tproxy", func() {
+			e.Spec.Injection.Interception.Mode = api.InterceptionModeTProxy
+			b := generate(e, nil)
+			Expect(b.StaticResources.Listeners[1].Transparent.GetValue()).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,175 @@
+package kube
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/duration"
+	"github.com/golang/protobuf/ptypes/wrappers"
+
+	envoy_cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const controlPlaneClusterName = "ads-control-plane"
+
+// controlPlaneEndpoints returns the ads servers of the envoy, from the control plane spec or else
+// from the adsServer and adsPort
+func controlPlaneEndpoints(e *api.Envoy) ([]api.ControlPlaneEndpoint, error) {
+	cp := e.Spec.ControlPlane
+	if cp != nil && len(cp.Endpoints) != 0 {
+		if e.Spec.ADSServer != "" {
+			return nil, fmt.Errorf("set either the adsServer or the control plane endpoints")
+		}
+		return cp.Endpoints, nil
+	}
+	if e.Spec.ADSServer == "" {
+		return nil, nil
+	}
+	return []api.ControlPlaneEndpoint{{Address: e.Spec.ADSServer, Port: uint32(e.Spec.ADSPort)}}, nil
+}
+
+// configureControlPlaneCluster sets how the ads-control-plane cluster finds, balances and
+// checks the ads servers
+func configureControlPlaneCluster(e *api.Envoy, cluster *envoy_cluster.Cluster, endpoints []api.ControlPlaneEndpoint) error {
+	var spec api.ControlPlaneSpec
+	if e.Spec.ControlPlane != nil {
+		spec = *e.Spec.ControlPlane
+	}
+
+	dnsType := spec.DNSType
+	if dnsType == "" {
+		dnsType = api.DNSTypeStrict
+	}
+	switch dnsType {
+	case api.DNSTypeStrict:
+		cluster.ClusterDiscoveryType = &envoy_cluster.Cluster_Type{Type: envoy_cluster.Cluster_STRICT_DNS}
+	case api.DNSTypeLogical:
+		if len(endpoints) != 1 {
+			return fmt.Errorf("a LOGICAL_DNS control plane needs exactly one endpoint")
+		}
+		cluster.ClusterDiscoveryType = &envoy_cluster.Cluster_Type{Type: envoy_cluster.Cluster_LOGICAL_DNS}
+	case api.DNSTypeStatic:
+		for _, ep := range endpoints {
+			if net.ParseIP(ep.Address) == nil {
+				return fmt.Errorf("control plane endpoint %q is not an ip address, as STATIC needs", ep.Address)
+			}
+		}
+		cluster.ClusterDiscoveryType = &envoy_cluster.Cluster_Type{Type: envoy_cluster.Cluster_STATIC}
+	default:
+		return fmt.Errorf("unknown control plane dns type %q", dnsType)
+	}
+
+	loadAssignment, err := controlPlaneLoadAssignment(endpoints)
+	if err != nil {
+		return err
+	}
+	cluster.LoadAssignment = loadAssignment
+
+	cluster.ConnectTimeout = durationOr(spec.ConnectTimeout, 5*time.Second)
+	if ka := spec.Keepalive; ka != nil {
+		keepalive := &envoy_core.TcpKeepalive{}
+		if ka.Probes != 0 {
+			keepalive.KeepaliveProbes = &wrappers.UInt32Value{Value: ka.Probes}
+		}
+		if ka.Time != nil {
+			seconds, err := keepaliveSeconds("time", ka.Time)
+			if err != nil {
+				return err
+			}
+			keepalive.KeepaliveTime = &wrappers.UInt32Value{Value: seconds}
+		}
+		if ka.Interval != nil {
+			seconds, err := keepaliveSeconds("interval", ka.Interval)
+			if err != nil {
+				return err
+			}
+			keepalive.KeepaliveInterval = &wrappers.UInt32Value{Value: seconds}
+		}
+		cluster.UpstreamConnectionOptions = &envoy_cluster.UpstreamConnectionOptions{TcpKeepalive: keepalive}
+	}
+	if hc := spec.HealthCheck; hc != nil {
+		if xdsMode(e) == api.XDSModeREST {
+			return fmt.Errorf("control plane health checks use grpc, and can't check REST servers")
+		}
+		cluster.HealthChecks = []*envoy_core.HealthCheck{{
+			Interval:           durationOr(hc.Interval, 10*time.Second),
+			Timeout:            durationOr(hc.Timeout, 2*time.Second),
+			UnhealthyThreshold: &wrappers.UInt32Value{Value: uint32Or(hc.UnhealthyThreshold, 3)},
+			HealthyThreshold:   &wrappers.UInt32Value{Value: uint32Or(hc.HealthyThreshold, 1)},
+			HealthChecker: &envoy_core.HealthCheck_GrpcHealthCheck_{
+				GrpcHealthCheck: &envoy_core.HealthCheck_GrpcHealthCheck{ServiceName: hc.ServiceName},
+			},
+		}}
+	}
+	return nil
+}
+
+// controlPlaneLoadAssignment groups the endpoints by priority. Envoy only fails over to the
+// next priority, so the priorities can't skip a level.
+func controlPlaneLoadAssignment(endpoints []api.ControlPlaneEndpoint) (*envoy_endpoint.ClusterLoadAssignment, error) {
+	byPriority := map[uint32][]*envoy_endpoint.LbEndpoint{}
+	for _, ep := range endpoints {
+		if ep.Address == "" || ep.Port == 0 {
+			return nil, fmt.Errorf("control plane endpoints need an address and a port")
+		}
+		lbEndpoint := &envoy_endpoint.LbEndpoint{
+			HostIdentifier: &envoy_endpoint.LbEndpoint_Endpoint{
+				Endpoint: &envoy_endpoint.Endpoint{
+					Address: socketAddress(ep.Address, ep.Port),
+				},
+			},
+		}
+		if ep.Weight != 0 {
+			lbEndpoint.LoadBalancingWeight = &wrappers.UInt32Value{Value: ep.Weight}
+		}
+		byPriority[ep.Priority] = append(byPriority[ep.Priority], lbEndpoint)
+	}
+
+	var priorities []uint32
+	for p := range byPriority {
+		priorities = append(priorities, p)
+	}
+	sort.Slice(priorities, func(i, j int) bool { return priorities[i] < priorities[j] })
+
+	loadAssignment := &envoy_endpoint.ClusterLoadAssignment{ClusterName: controlPlaneClusterName}
+	for i, p := range priorities {
+		if p != uint32(i) {
+			return nil, fmt.Errorf("control plane endpoint priorities must start at 0 and not skip any, missing %d", i)
+		}
+		loadAssignment.Endpoints = append(loadAssignment.Endpoints, &envoy_endpoint.LocalityLbEndpoints{
+			LbEndpoints: byPriority[p],
+			Priority:    p,
+		})
+	}
+	return loadAssignment, nil
+}
+
+func durationOr(d *metav1.Duration, def time.Duration) *duration.Duration {
+	if d != nil {
+		return ptypes.DurationProto(d.Duration)
+	}
+	return ptypes.DurationProto(def)
+}
+
+func uint32Or(value, def uint32) uint32 {
+	if value != 0 {
+		return value
+	}
+	return def
+}
+
+// keepaliveSeconds returns the keepalive duration in seconds, the resolution of tcp keepalives
+func keepaliveSeconds(name string, d *metav1.Duration) (uint32, error) {
+	if d.Duration < time.Second {
+		return 0, fmt.Errorf("control plane keepalive %s %s is below a second", name, d.Duration)
+	}
+	return uint32(d.Seconds()), nil
+}
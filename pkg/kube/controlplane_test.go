@@ -0,0 +1,165 @@
+package kube_test
+
+import (
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	envoy_cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	. "github.com/solo-io/envoy-operator/pkg/kube"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Control plane", func() {
+	var e *api.Envoy
+
+	BeforeEach(func() {
+		e = testEnvoy()
+	})
+
+	It("should resolve the adsServer with dns", func() {
+		b := generate(e, nil)
+		c := clusterNamed(b, "ads-control-plane")
+		Expect(c.GetType()).To(Equal(envoy_cluster.Cluster_STRICT_DNS))
+		Expect(c.ConnectTimeout.Seconds).To(BeEquivalentTo(5))
+		Expect(c.LoadAssignment.Endpoints).To(HaveLen(1))
+		addr := c.LoadAssignment.Endpoints[0].LbEndpoints[0].GetEndpoint().Address.GetSocketAddress()
+		Expect(addr.Address).To(Equal("ads.solo.io"))
+		Expect(addr.GetPortValue()).To(BeEquivalentTo(1234))
+		Expect(c.HealthChecks).To(BeEmpty())
+		Expect(c.UpstreamConnectionOptions).To(BeNil())
+	})
+
+	Context("with endpoints", func() {
+		BeforeEach(func() {
+			e.Spec.ADSServer = ""
+			e.Spec.ADSPort = 0
+			e.Spec.ControlPlane = &api.ControlPlaneSpec{
+				Endpoints: []api.ControlPlaneEndpoint{
+					{Address: "ads-backup.solo.io", Port: 1234, Priority: 1},
+					{Address: "ads-a.solo.io", Port: 1234, Weight: 3},
+					{Address: "ads-b.solo.io", Port: 1234, Weight: 1},
+				},
+			}
+		})
+
+		It("should group the endpoints by priority", func() {
+			b := generate(e, nil)
+			Expect(b.DynamicResources).NotTo(BeNil())
+			c := clusterNamed(b, "ads-control-plane")
+			Expect(c.LoadAssignment.Endpoints).To(HaveLen(2))
+
+			primary := c.LoadAssignment.Endpoints[0]
+			Expect(primary.Priority).To(BeEquivalentTo(0))
+			Expect(primary.LbEndpoints).To(HaveLen(2))
+			Expect(primary.LbEndpoints[0].GetEndpoint().Address.GetSocketAddress().Address).To(Equal("ads-a.solo.io"))
+			Expect(primary.LbEndpoints[0].LoadBalancingWeight.Value).To(BeEquivalentTo(3))
+			Expect(primary.LbEndpoints[1].LoadBalancingWeight.Value).To(BeEquivalentTo(1))
+
+			backup := c.LoadAssignment.Endpoints[1]
+			Expect(backup.Priority).To(BeEquivalentTo(1))
+			Expect(backup.LbEndpoints[0].GetEndpoint().Address.GetSocketAddress().Address).To(Equal("ads-backup.solo.io"))
+			Expect(backup.LbEndpoints[0].LoadBalancingWeight).To(BeNil())
+		})
+
+		It("should reject priorities that skip a level", func() {
+			e.Spec.ControlPlane.Endpoints[0].Priority = 2
+			_, err := GenerateEnvoyConfig(e, nil)
+			Expect(err).To(MatchError(ContainSubstring("priorities")))
+		})
+
+		It("should reject endpoints together with an adsServer", func() {
+			e.Spec.ADSServer = "ads.solo.io"
+			_, err := GenerateEnvoyConfig(e, nil)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should reject endpoints without a port", func() {
+			e.Spec.ControlPlane.Endpoints[0].Port = 0
+			_, err := GenerateEnvoyConfig(e, nil)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should set the dns type", func() {
+			e.Spec.ControlPlane.DNSType = api.DNSTypeStatic
+			_, err := GenerateEnvoyConfig(e, nil)
+			Expect(err).To(MatchError(ContainSubstring("not an ip address")))
+
+			e.Spec.ControlPlane.Endpoints = []api.ControlPlaneEndpoint{{Address: "10.0.0.1", Port: 1234}}
+			c := clusterNamed(generate(e, nil), "ads-control-plane")
+			Expect(c.GetType()).To(Equal(envoy_cluster.Cluster_STATIC))
+
+			e.Spec.ControlPlane.DNSType = api.DNSTypeLogical
+			c = clusterNamed(generate(e, nil), "ads-control-plane")
+			Expect(c.GetType()).To(Equal(envoy_cluster.Cluster_LOGICAL_DNS))
+		})
+
+		It("should only allow one endpoint with LOGICAL_DNS", func() {
+			e.Spec.ControlPlane.DNSType = api.DNSTypeLogical
+			_, err := GenerateEnvoyConfig(e, nil)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should set the connect timeout and keepalive", func() {
+			e.Spec.ControlPlane.ConnectTimeout = &metav1.Duration{Duration: time.Second}
+			e.Spec.ControlPlane.Keepalive = &api.KeepaliveSpec{
+				Time:     &metav1.Duration{Duration: time.Minute},
+				Interval: &metav1.Duration{Duration: 10 * time.Second},
+				Probes:   3,
+			}
+			c := clusterNamed(generate(e, nil), "ads-control-plane")
+			Expect(c.ConnectTimeout.Seconds).To(BeEquivalentTo(1))
+			keepalive := c.UpstreamConnectionOptions.TcpKeepalive
+			Expect(keepalive.KeepaliveTime.Value).To(BeEquivalentTo(60))
+			Expect(keepalive.KeepaliveInterval.Value).To(BeEquivalentTo(10))
+			Expect(keepalive.KeepaliveProbes.Value).To(BeEquivalentTo(3))
+		})
+
+		It("should reject sub-second keepalives", func() {
+			e.Spec.ControlPlane.Keepalive = &api.KeepaliveSpec{Interval: &metav1.Duration{Duration: 500 * time.Millisecond}}
+			_, err := GenerateEnvoyConfig(e, nil)
+			Expect(err).To(MatchError(ContainSubstring("below a second")))
+		})
+
+		It("should health check the servers with grpc", func() {
+			e.Spec.ControlPlane.HealthCheck = &api.HealthCheckSpec{
+				Timeout:     &metav1.Duration{Duration: time.Second},
+				ServiceName: "envoy.service.discovery.v3.AggregatedDiscoveryService",
+			}
+			c := clusterNamed(generate(e, nil), "ads-control-plane")
+			Expect(c.HealthChecks).To(HaveLen(1))
+			hc := c.HealthChecks[0]
+			Expect(hc.Interval.Seconds).To(BeEquivalentTo(10))
+			Expect(hc.Timeout.Seconds).To(BeEquivalentTo(1))
+			Expect(hc.UnhealthyThreshold.Value).To(BeEquivalentTo(3))
+			Expect(hc.HealthyThreshold.Value).To(BeEquivalentTo(1))
+			Expect(hc.GetGrpcHealthCheck().ServiceName).To(Equal("envoy.service.discovery.v3.AggregatedDiscoveryService"))
+		})
+
+		It("should use the first endpoint for the tls sni", func() {
+			b := generate(e, &v1.Secret{Data: map[string][]byte{api.TLSCA: []byte("ca")}})
+			c := clusterNamed(b, "ads-control-plane")
+			var tlsContext envoy_tls.UpstreamTlsContext
+			err := ptypes.UnmarshalAny(c.TransportSocket.GetTypedConfig(), &tlsContext)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tlsContext.Sni).To(Equal("ads-backup.solo.io"))
+		})
+
+		It("should not send ip addresses as sni", func() {
+			e.Spec.ControlPlane.Endpoints = []api.ControlPlaneEndpoint{{Address: "10.0.0.1", Port: 8081}}
+			b := generate(e, &v1.Secret{Data: map[string][]byte{api.TLSCA: []byte("ca")}})
+			c := clusterNamed(b, "ads-control-plane")
+			var tlsContext envoy_tls.UpstreamTlsContext
+			err := ptypes.UnmarshalAny(c.TransportSocket.GetTypedConfig(), &tlsContext)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tlsContext.Sni).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,109 @@
+package kube
+
+import (
+	"sort"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DaemonSetForEnvoy returns the desired daemon set for the envoy
+func DaemonSetForEnvoy(e *api.Envoy, podAnnotations map[string]string) (*appsv1.DaemonSet, error) {
+	podTempl, err := PodTemplateForEnvoy(e, podAnnotations)
+	if err != nil {
+		return nil, err
+	}
+
+	dsSpec := e.Spec.DaemonSet
+	podTempl.Spec.NodeSelector = dsSpec.NodeSelector
+	if dsSpec.HostNetwork {
+		podTempl.Spec.HostNetwork = true
+		// keep resolving cluster names from the node's network namespace
+		podTempl.Spec.DNSPolicy = v1.DNSClusterFirstWithHostNet
+	}
+
+	names := make([]string, 0, len(dsSpec.HostPorts))
+	for name := range dsSpec.HostPorts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	envoy := &podTempl.Spec.Containers[0]
+	for _, name := range names {
+		port := dsSpec.HostPorts[name]
+		envoy.Ports = append(envoy.Ports, v1.ContainerPort{
+			Name:          name,
+			ContainerPort: port,
+			HostPort:      port,
+		})
+	}
+
+	podTempl, err = MergePodTemplate(podTempl, dsSpec.PodTemplate)
+	if err != nil {
+		return nil, err
+	}
+	runSPIFFEAsEnvoy(&podTempl.Spec)
+
+	selector := LabelsForEnvoy(e)
+
+	ds := &appsv1.DaemonSet{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "DaemonSet",
+			APIVersion: "apps/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      e.GetName(),
+			Namespace: e.GetNamespace(),
+			Labels:    selector,
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector:       &metav1.LabelSelector{MatchLabels: selector},
+			Template:       podTempl,
+			UpdateStrategy: dsSpec.UpdateStrategy,
+		},
+	}
+	if err := setSpecHash(ds, managedDaemonSetFields(ds)); err != nil {
+		return nil, err
+	}
+	return ds, nil
+}
+
+// managedDaemonSet holds the parts of a daemon set that the operator owns
+type managedDaemonSet struct {
+	Labels         map[string]string              `json:"labels,omitempty"`
+	Template       v1.PodTemplateSpec             `json:"template"`
+	UpdateStrategy appsv1.DaemonSetUpdateStrategy `json:"updateStrategy"`
+}
+
+func managedDaemonSetFields(ds *appsv1.DaemonSet) managedDaemonSet {
+	return managedDaemonSet{
+		Labels:         ds.Labels,
+		Template:       ds.Spec.Template,
+		UpdateStrategy: ds.Spec.UpdateStrategy,
+	}
+}
+
+// DaemonSetNeedsUpdate returns true if the live daemon set differs from the desired one in any
+// of the fields the operator manages
+func DaemonSetNeedsUpdate(desired, live *appsv1.DaemonSet) (bool, error) {
+	if specHashDiffers(desired, live) {
+		return true, nil
+	}
+	return differs(managedDaemonSetFields(desired), managedDaemonSetFields(live))
+}
+
+// UpdateDaemonSet copies the fields the operator manages from the desired daemon set to the
+// live one
+func UpdateDaemonSet(desired, live *appsv1.DaemonSet) {
+	if live.Labels == nil {
+		live.Labels = map[string]string{}
+	}
+	for k, v := range desired.Labels {
+		live.Labels[k] = v
+	}
+	copySpecHash(desired, live)
+	live.Spec.Template = desired.Spec.Template
+	live.Spec.UpdateStrategy = desired.Spec.UpdateStrategy
+}
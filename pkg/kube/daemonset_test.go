@@ -0,0 +1,92 @@
+package kube_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	. "github.com/solo-io/envoy-operator/pkg/kube"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("DaemonSet", func() {
+	var e *api.Envoy
+
+	daemonSet := func() *appsv1.DaemonSet {
+		ds, err := DaemonSetForEnvoy(e, nil)
+		Expect(err).NotTo(HaveOccurred())
+		return ds
+	}
+
+	BeforeEach(func() {
+		e = testEnvoy()
+		e.Spec.Deployment = nil
+		e.Spec.NodeIdTemplate = "{{.NodeName}}"
+		e.Spec.ClusterIdTemplate = "{{.NodeIp}}"
+		e.Spec.DaemonSet = &api.EnvoyDaemonSetSpec{
+			HostNetwork:  true,
+			HostPorts:    map[string]int32{"http": 80, "https": 443},
+			NodeSelector: map[string]string{"role": "edge"},
+		}
+		e.SetDefaults()
+	})
+
+	It("should not default a deployment", func() {
+		Expect(e.Spec.Deployment).To(BeNil())
+		Expect(e.Spec.DaemonSet.UpdateStrategy.Type).To(Equal(appsv1.RollingUpdateDaemonSetStrategyType))
+	})
+
+	It("should run in the host network", func() {
+		ds := daemonSet()
+		Expect(ds.Spec.Template.Spec.HostNetwork).To(BeTrue())
+		Expect(ds.Spec.Template.Spec.DNSPolicy).To(Equal(v1.DNSClusterFirstWithHostNet))
+	})
+
+	It("should expose host ports", func() {
+		ports := daemonSet().Spec.Template.Spec.Containers[0].Ports
+		Expect(ports).To(ContainElement(v1.ContainerPort{Name: "http", ContainerPort: 80, HostPort: 80}))
+		Expect(ports).To(ContainElement(v1.ContainerPort{Name: "https", ContainerPort: 443, HostPort: 443}))
+	})
+
+	It("should select nodes", func() {
+		Expect(daemonSet().Spec.Template.Spec.NodeSelector).To(HaveKeyWithValue("role", "edge"))
+	})
+
+	It("should provide the node name and ip to the templates", func() {
+		env := daemonSet().Spec.Template.Spec.InitContainers[0].Env
+		Expect(env).To(HaveLen(2))
+		Expect(env[0].Name).To(Equal("NODE_NAME"))
+		Expect(env[0].ValueFrom.FieldRef.FieldPath).To(Equal("spec.nodeName"))
+		Expect(env[1].Name).To(Equal("NODE_IP"))
+		Expect(env[1].ValueFrom.FieldRef.FieldPath).To(Equal("status.hostIP"))
+	})
+
+	It("should leave the node templates for the initializer to fill", func() {
+		daemonSet()
+		Expect(e.Spec.NodeIdTemplate).To(Equal("{{.NodeName}}"))
+		Expect(e.Spec.ClusterIdTemplate).To(Equal("{{.NodeIp}}"))
+	})
+
+	It("should detect drift", func() {
+		live := daemonSet()
+		live.Spec.Template.Spec.HostNetwork = false
+		res, err := DaemonSetNeedsUpdate(daemonSet(), live)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(BeTrue())
+
+		UpdateDaemonSet(daemonSet(), live)
+		res, err = DaemonSetNeedsUpdate(daemonSet(), live)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(BeFalse())
+	})
+
+	It("should detect update strategy changes", func() {
+		live := daemonSet()
+		e.Spec.DaemonSet.UpdateStrategy.Type = appsv1.OnDeleteDaemonSetStrategyType
+		res, err := DaemonSetNeedsUpdate(daemonSet(), live)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(BeTrue())
+	})
+})
@@ -0,0 +1,98 @@
+package kube
+
+import (
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// DeploymentForEnvoy returns the desired deployment for the envoy
+func DeploymentForEnvoy(e *api.Envoy, podAnnotations map[string]string) (*appsv1.Deployment, error) {
+	podTempl, err := PodTemplateForEnvoy(e, podAnnotations)
+	if err != nil {
+		return nil, err
+	}
+	podTempl, err = MergePodTemplate(podTempl, e.Spec.Deployment.PodTemplate)
+	if err != nil {
+		return nil, err
+	}
+	runSPIFFEAsEnvoy(&podTempl.Spec)
+
+	selector := LabelsForEnvoy(e)
+
+	var reps int32
+	reps = int32(e.Spec.Deployment.Replicas)
+
+	d := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Deployment",
+			APIVersion: "apps/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      e.GetName(),
+			Namespace: e.GetNamespace(),
+			Labels:    selector,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &reps,
+			Selector: &metav1.LabelSelector{MatchLabels: selector},
+			Template: podTempl,
+			Strategy: appsv1.DeploymentStrategy{
+				Type: appsv1.RollingUpdateDeploymentStrategyType,
+				RollingUpdate: &appsv1.RollingUpdateDeployment{
+					MaxUnavailable: func(a intstr.IntOrString) *intstr.IntOrString { return &a }(intstr.FromInt(1)),
+					MaxSurge:       func(a intstr.IntOrString) *intstr.IntOrString { return &a }(intstr.FromInt(1)),
+				},
+			},
+		},
+	}
+	if err := setSpecHash(d, managedDeploymentFields(d)); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// managedDeployment holds the parts of a deployment that the operator owns
+type managedDeployment struct {
+	Labels   map[string]string         `json:"labels,omitempty"`
+	Replicas *int32                    `json:"replicas,omitempty"`
+	Template v1.PodTemplateSpec        `json:"template"`
+	Strategy appsv1.DeploymentStrategy `json:"strategy"`
+}
+
+func managedDeploymentFields(d *appsv1.Deployment) managedDeployment {
+	return managedDeployment{
+		Labels:   d.Labels,
+		Replicas: d.Spec.Replicas,
+		Template: d.Spec.Template,
+		Strategy: d.Spec.Strategy,
+	}
+}
+
+// DeploymentNeedsUpdate returns true if the live deployment differs from the desired one in any
+// of the fields the operator manages. Fields that are not set in the desired deployment are
+// defaulted by kube, and are ignored.
+func DeploymentNeedsUpdate(desired, live *appsv1.Deployment) (bool, error) {
+	if specHashDiffers(desired, live) {
+		return true, nil
+	}
+	return differs(managedDeploymentFields(desired), managedDeploymentFields(live))
+}
+
+// UpdateDeployment copies the fields the operator manages from the desired deployment to the
+// live one
+func UpdateDeployment(desired, live *appsv1.Deployment) {
+	if live.Labels == nil {
+		live.Labels = map[string]string{}
+	}
+	for k, v := range desired.Labels {
+		live.Labels[k] = v
+	}
+	copySpecHash(desired, live)
+	live.Spec.Replicas = desired.Spec.Replicas
+	live.Spec.Template = desired.Spec.Template
+	live.Spec.Strategy = desired.Spec.Strategy
+}
@@ -0,0 +1,236 @@
+package kube_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	. "github.com/solo-io/envoy-operator/pkg/kube"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testEnvoy() *api.Envoy {
+	e := &api.Envoy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myenvoy",
+			Namespace: "default",
+		},
+		Spec: api.EnvoySpec{
+			ADSServer:         "ads.solo.io",
+			ADSPort:           1234,
+			ClusterIdTemplate: "ingress",
+			NodeIdTemplate:    "{{.PodName}}-ingress",
+		},
+	}
+	e.SetDefaults()
+	return e
+}
+
+// simulate what the api server does to a deployment we create
+func applyKubeDefaults(d *appsv1.Deployment) *appsv1.Deployment {
+	d = d.DeepCopy()
+	revisionHistoryLimit := int32(10)
+	d.Spec.RevisionHistoryLimit = &revisionHistoryLimit
+	d.Spec.Template.Spec.RestartPolicy = v1.RestartPolicyAlways
+	d.Spec.Template.Spec.DNSPolicy = v1.DNSClusterFirst
+	d.Spec.Template.Spec.SchedulerName = "default-scheduler"
+	d.Spec.Template.Spec.SecurityContext = &v1.PodSecurityContext{}
+	for i := range d.Spec.Template.Spec.Containers {
+		c := &d.Spec.Template.Spec.Containers[i]
+		c.ImagePullPolicy = v1.PullIfNotPresent
+		c.TerminationMessagePath = "/dev/termination-log"
+		for j := range c.Ports {
+			c.Ports[j].Protocol = v1.ProtocolTCP
+		}
+		for _, p := range []*v1.Probe{c.ReadinessProbe, c.LivenessProbe} {
+			if p == nil {
+				continue
+			}
+			if p.HTTPGet != nil {
+				p.HTTPGet.Scheme = v1.URISchemeHTTP
+			}
+			if p.TimeoutSeconds == 0 {
+				p.TimeoutSeconds = 1
+			}
+			if p.SuccessThreshold == 0 {
+				p.SuccessThreshold = 1
+			}
+		}
+	}
+	for i := range d.Spec.Template.Spec.InitContainers {
+		c := &d.Spec.Template.Spec.InitContainers[i]
+		c.ImagePullPolicy = v1.PullIfNotPresent
+		for j := range c.Env {
+			c.Env[j].ValueFrom.FieldRef.APIVersion = "v1"
+		}
+	}
+	defaultMode := int32(420)
+	for i := range d.Spec.Template.Spec.Volumes {
+		if cm := d.Spec.Template.Spec.Volumes[i].ConfigMap; cm != nil {
+			cm.DefaultMode = &defaultMode
+		}
+	}
+	return d
+}
+
+var _ = Describe("Deployment", func() {
+	var (
+		e              *api.Envoy
+		podAnnotations map[string]string
+		live           *appsv1.Deployment
+	)
+
+	desired := func() *appsv1.Deployment {
+		d, err := DeploymentForEnvoy(e, podAnnotations)
+		Expect(err).NotTo(HaveOccurred())
+		return d
+	}
+	needsUpdate := func() bool {
+		res, err := DeploymentNeedsUpdate(desired(), live)
+		Expect(err).NotTo(HaveOccurred())
+		return res
+	}
+
+	BeforeEach(func() {
+		e = testEnvoy()
+		podAnnotations = map[string]string{"envoy.solo.io/config-checksum": "abc"}
+		live = applyKubeDefaults(desired())
+	})
+
+	It("should not need an update when only defaulted fields differ", func() {
+		Expect(needsUpdate()).To(BeFalse())
+	})
+
+	It("should not need an update when the live deployment has extra labels", func() {
+		live.Labels["extra"] = "label"
+		Expect(needsUpdate()).To(BeFalse())
+	})
+
+	It("should detect a replicas change", func() {
+		e.Spec.Deployment.Replicas = 3
+		Expect(needsUpdate()).To(BeTrue())
+	})
+
+	It("should detect an image change", func() {
+		e.Spec.Image = "envoyproxy/envoy:v1.14.1"
+		Expect(needsUpdate()).To(BeTrue())
+	})
+
+	It("should detect a command change", func() {
+		e.Spec.ImageCommand = []string{"envoy"}
+		Expect(needsUpdate()).To(BeTrue())
+	})
+
+	It("should detect an admin port change", func() {
+		e.Spec.AdminPort = 9901
+		Expect(needsUpdate()).To(BeTrue())
+	})
+
+	It("should detect tls volumes", func() {
+		e.Spec.TLSSecretName = "certs"
+		Expect(needsUpdate()).To(BeTrue())
+	})
+
+	It("should detect env changes", func() {
+		e.Spec.NodeIdTemplate = "{{.NodeName}}"
+		Expect(needsUpdate()).To(BeTrue())
+	})
+
+	It("should detect downward volume changes", func() {
+		e.Spec.ClusterIdTemplate = "{{.PodLabels.app}}"
+		Expect(needsUpdate()).To(BeTrue())
+	})
+
+	It("should detect pod annotation changes", func() {
+		podAnnotations["envoy.solo.io/config-checksum"] = "def"
+		Expect(needsUpdate()).To(BeTrue())
+	})
+
+	It("should detect a removed label", func() {
+		delete(live.Labels, "app")
+		Expect(needsUpdate()).To(BeTrue())
+	})
+
+	It("should detect a changed container field", func() {
+		live.Spec.Template.Spec.Containers[0].Args = append(live.Spec.Template.Spec.Containers[0].Args, "-l", "debug")
+		Expect(needsUpdate()).To(BeTrue())
+	})
+
+	It("should detect a probe change", func() {
+		e.Spec.Probes = &api.ProbesSpec{Liveness: api.ProbeSpec{FailureThreshold: 8}}
+		Expect(needsUpdate()).To(BeTrue())
+	})
+
+	It("should detect a changed strategy", func() {
+		live.Spec.Strategy.Type = appsv1.RecreateDeploymentStrategyType
+		live.Spec.Strategy.RollingUpdate = nil
+		Expect(needsUpdate()).To(BeTrue())
+	})
+
+	Context("when the live deployment was edited", func() {
+		// kubectl edit keeps the spec hash annotation, so only the diff of the managed fields
+		// notices the edit
+		edited := func(edit func(*appsv1.Deployment)) bool {
+			edit(live)
+			Expect(live.Annotations).To(HaveKeyWithValue("envoy.solo.io/spec-hash", desired().Annotations["envoy.solo.io/spec-hash"]))
+			return needsUpdate()
+		}
+
+		It("should detect scaled replicas", func() {
+			Expect(edited(func(d *appsv1.Deployment) {
+				replicas := int32(5)
+				d.Spec.Replicas = &replicas
+			})).To(BeTrue())
+		})
+
+		It("should detect a changed image", func() {
+			Expect(edited(func(d *appsv1.Deployment) {
+				d.Spec.Template.Spec.Containers[0].Image = "envoyproxy/envoy:latest"
+			})).To(BeTrue())
+		})
+
+		It("should detect a removed volume", func() {
+			Expect(edited(func(d *appsv1.Deployment) {
+				d.Spec.Template.Spec.Volumes = d.Spec.Template.Spec.Volumes[1:]
+			})).To(BeTrue())
+		})
+
+		It("should detect a changed pod annotation", func() {
+			Expect(edited(func(d *appsv1.Deployment) {
+				d.Spec.Template.Annotations["envoy.solo.io/config-checksum"] = "def"
+			})).To(BeTrue())
+		})
+
+		It("should detect a removed probe", func() {
+			Expect(edited(func(d *appsv1.Deployment) {
+				d.Spec.Template.Spec.Containers[0].ReadinessProbe = nil
+			})).To(BeTrue())
+		})
+
+		It("should ignore edits of fields it doesn't manage", func() {
+			Expect(edited(func(d *appsv1.Deployment) {
+				d.Spec.Template.Spec.Containers[0].TerminationMessagePath = "/tmp/termination-log"
+				d.Annotations["deployment.kubernetes.io/revision"] = "2"
+			})).To(BeFalse())
+		})
+	})
+
+	It("should update the managed fields", func() {
+		e.Spec.Image = "envoyproxy/envoy:v1.14.1"
+		e.Spec.Deployment.Replicas = 3
+		live.Labels["extra"] = "label"
+		d := desired()
+
+		UpdateDeployment(d, live)
+		Expect(*live.Spec.Replicas).To(BeEquivalentTo(3))
+		Expect(live.Spec.Template.Spec.Containers[0].Image).To(Equal("envoyproxy/envoy:v1.14.1"))
+		Expect(live.Labels).To(HaveKeyWithValue("extra", "label"))
+
+		res, err := DeploymentNeedsUpdate(d, live)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(BeFalse())
+	})
+})
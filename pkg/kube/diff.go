@@ -0,0 +1,148 @@
+package kube
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// The hash of the managed fields of the desired object, as of the last time the operator wrote
+// it. Comparing hashes catches fields removed from the desired object, which the subset
+// comparison can't tell apart from fields defaulted by kube.
+const specHashAnnotation = "envoy.solo.io/spec-hash"
+
+func setSpecHash(o metav1.Object, managed interface{}) error {
+	data, err := json.Marshal(managed)
+	if err != nil {
+		return err
+	}
+	annotations := o.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[specHashAnnotation] = fmt.Sprintf("%x", sha256.Sum256(data))
+	o.SetAnnotations(annotations)
+	return nil
+}
+
+func copySpecHash(desired, live metav1.Object) {
+	annotations := live.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[specHashAnnotation] = desired.GetAnnotations()[specHashAnnotation]
+	live.SetAnnotations(annotations)
+}
+
+func specHashDiffers(desired, live metav1.Object) bool {
+	return desired.GetAnnotations()[specHashAnnotation] != live.GetAnnotations()[specHashAnnotation]
+}
+
+// differs returns true if desired is not a subset of live, when both are viewed as json.
+// Fields missing from desired are assumed to be defaulted by kube and are ignored; lists must
+// have the same length and their items are compared in order.
+func differs(desired, live interface{}) (bool, error) {
+	desiredObj, err := toJsonObject(desired)
+	if err != nil {
+		return false, err
+	}
+	liveObj, err := toJsonObject(live)
+	if err != nil {
+		return false, err
+	}
+	return !isSubset(desiredObj, liveObj), nil
+}
+
+func toJsonObject(in interface{}) (interface{}, error) {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	err = json.Unmarshal(data, &out)
+	return out, err
+}
+
+func isSubset(desired, live interface{}) bool {
+	switch d := desired.(type) {
+	case map[string]interface{}:
+		l, ok := live.(map[string]interface{})
+		if !ok {
+			return len(d) == 0 && live == nil
+		}
+		for k, dv := range d {
+			lv, ok := l[k]
+			if !ok {
+				if isEmpty(dv) {
+					continue
+				}
+				return false
+			}
+			if !isSubset(dv, lv) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		l, ok := live.([]interface{})
+		if !ok {
+			return len(d) == 0 && live == nil
+		}
+		if len(d) != len(l) {
+			return false
+		}
+		for i := range d {
+			if !isSubset(d[i], l[i]) {
+				return false
+			}
+		}
+		return true
+	case nil:
+		return true
+	default:
+		return reflect.DeepEqual(desired, live)
+	}
+}
+
+func isEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	switch t := v.(type) {
+	case map[string]interface{}:
+		return len(t) == 0
+	case []interface{}:
+		return len(t) == 0
+	}
+	return false
+}
+
+// unstructuredNeedsUpdate returns true when the labels or the spec of the live object differ
+// from the desired ones
+func unstructuredNeedsUpdate(desired, live *unstructured.Unstructured) (bool, error) {
+	if specHashDiffers(desired, live) {
+		return true, nil
+	}
+	if differ, err := differs(desired.GetLabels(), live.GetLabels()); err != nil || differ {
+		return differ, err
+	}
+	return differs(desired.Object["spec"], live.Object["spec"])
+}
+
+// updateUnstructured copies the labels and the spec of the desired object to the live one
+func updateUnstructured(desired, live *unstructured.Unstructured) {
+	labels := live.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for k, v := range desired.GetLabels() {
+		labels[k] = v
+	}
+	live.SetLabels(labels)
+	live.Object["spec"] = desired.Object["spec"]
+	copySpecHash(desired, live)
+}
@@ -0,0 +1,82 @@
+package kube
+
+import (
+	"strconv"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	defaultDrainSeconds = 15
+	// Envoy may hot restart, and its parent has to be shut down after draining
+	parentShutdownMargin = 5
+	// Kubelet kills the pod after the grace period; leave the preStop hook time to finish
+	terminationGraceMargin = 10
+)
+
+type drainSettings struct {
+	path                  string
+	drainSeconds          int32
+	parentShutdownSeconds int32
+}
+
+// drainFor returns how the envoy drains, or nil if it doesn't
+func drainFor(e *api.Envoy) *drainSettings {
+	var spec api.DrainSpec
+	if e.Spec.Drain != nil {
+		spec = *e.Spec.Drain
+	}
+	if spec.Disabled {
+		return nil
+	}
+
+	d := &drainSettings{
+		path:                  "/drain_listeners?graceful",
+		drainSeconds:          spec.DrainSeconds,
+		parentShutdownSeconds: spec.ParentShutdownSeconds,
+	}
+	if spec.Strategy == api.DrainStrategyFailHealthcheck {
+		d.path = "/healthcheck/fail"
+	}
+	if d.drainSeconds == 0 {
+		d.drainSeconds = defaultDrainSeconds
+	}
+	if d.parentShutdownSeconds == 0 {
+		d.parentShutdownSeconds = d.drainSeconds + parentShutdownMargin
+	}
+	return d
+}
+
+// args returns the envoy flags for the drain settings
+func (d *drainSettings) args() []string {
+	return []string{
+		"--drain-time-s", strconv.Itoa(int(d.drainSeconds)),
+		"--parent-shutdown-time-s", strconv.Itoa(int(d.parentShutdownSeconds)),
+	}
+}
+
+// lifecycle returns the preStop hook that tells envoy to drain, and waits for it to. Without an
+// admin port, the hook only waits, which still gives load balancers time to stop sending
+// new requests.
+func (d *drainSettings) lifecycle(adminAddress string, adminPort int32) *v1.Lifecycle {
+	return &v1.Lifecycle{
+		PreStop: &v1.Handler{
+			Exec: &v1.ExecAction{
+				Command: []string{
+					initializerBinaryPath, "drain",
+					"-admin-address", adminAddress,
+					"-admin-port", strconv.Itoa(int(adminPort)),
+					"-path", d.path,
+					"-wait", strconv.Itoa(int(d.drainSeconds)) + "s",
+				},
+			},
+		},
+	}
+}
+
+func (d *drainSettings) terminationGracePeriod() *int64 {
+	period := int64(d.drainSeconds + terminationGraceMargin)
+	return &period
+}
@@ -0,0 +1,83 @@
+package kube_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	. "github.com/solo-io/envoy-operator/pkg/kube"
+)
+
+var _ = Describe("Drain", func() {
+	var e *api.Envoy
+
+	podSpec := func() (spec struct {
+		grace   int64
+		args    []string
+		preStop []string
+		init    []string
+	}) {
+		templ, err := PodTemplateForEnvoy(e, nil)
+		Expect(err).NotTo(HaveOccurred())
+		if templ.Spec.TerminationGracePeriodSeconds != nil {
+			spec.grace = *templ.Spec.TerminationGracePeriodSeconds
+		}
+		envoy := templ.Spec.Containers[0]
+		spec.args = envoy.Args
+		if envoy.Lifecycle != nil {
+			spec.preStop = envoy.Lifecycle.PreStop.Exec.Command
+		}
+		spec.init = templ.Spec.InitContainers[0].Args
+		return spec
+	}
+
+	BeforeEach(func() {
+		e = testEnvoy()
+	})
+
+	It("should drain the listeners by default", func() {
+		spec := podSpec()
+		Expect(spec.preStop).To(Equal([]string{
+			"/etc/envoy/envoy-operator-init", "drain",
+			"-admin-address", "127.0.0.1",
+			"-admin-port", "19000",
+			"-path", "/drain_listeners?graceful",
+			"-wait", "15s",
+		}))
+		Expect(spec.args).To(ContainElement("--drain-time-s"))
+		Expect(spec.args).To(ContainElement("15"))
+		Expect(spec.args).To(ContainElement("--parent-shutdown-time-s"))
+		Expect(spec.args).To(ContainElement("20"))
+		Expect(spec.grace).To(BeEquivalentTo(25))
+		Expect(spec.init).To(ContainElement("-install"))
+	})
+
+	It("should fail the health checks", func() {
+		e.Spec.Drain = &api.DrainSpec{
+			Strategy:              api.DrainStrategyFailHealthcheck,
+			DrainSeconds:          40,
+			ParentShutdownSeconds: 50,
+		}
+		spec := podSpec()
+		Expect(spec.preStop).To(ContainElement("/healthcheck/fail"))
+		Expect(spec.preStop).To(ContainElement("40s"))
+		Expect(spec.args).To(ContainElement("50"))
+		Expect(spec.grace).To(BeEquivalentTo(50))
+	})
+
+	It("should drain through the address the admin interface is bound to", func() {
+		e.Spec.Admin.BindAddress = "10.0.0.1"
+		Expect(podSpec().preStop).To(ContainElement("10.0.0.1"))
+		e.Spec.Admin.BindAddress = "0.0.0.0"
+		Expect(podSpec().preStop).To(ContainElement("127.0.0.1"))
+	})
+
+	It("should stop right away when disabled", func() {
+		e.Spec.Drain = &api.DrainSpec{Disabled: true}
+		spec := podSpec()
+		Expect(spec.preStop).To(BeNil())
+		Expect(spec.args).NotTo(ContainElement("--drain-time-s"))
+		Expect(spec.grace).To(BeZero())
+		Expect(spec.init).NotTo(ContainElement("-install"))
+	})
+})
@@ -0,0 +1,254 @@
+package kube
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/golang/protobuf/ptypes/empty"
+
+	envoy_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	"github.com/solo-io/envoy-operator/pkg/downward"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	grpcSecretEnvPrefix  = downward.SecretEnvPrefix
+	grpcSecretVolPrefix  = "grpc-secret-"
+	grpcSecretsMountPath = "/etc/grpc-secrets/"
+)
+
+func grpcClientSpec(e *api.Envoy) *api.GRPCClientSpec {
+	if e.Spec.ControlPlane == nil {
+		return nil
+	}
+	return e.Spec.ControlPlane.GRPC
+}
+
+// controlPlaneGrpcService returns the grpc service of the xds streams: envoy's grpc client
+// talking to the control plane cluster, or the google grpc client with its credentials
+func controlPlaneGrpcService(e *api.Envoy, endpoints []api.ControlPlaneEndpoint, cluster string, tlsSecret *v1.Secret) (*envoy_core.GrpcService, error) {
+	service := &envoy_core.GrpcService{
+		TargetSpecifier: &envoy_core.GrpcService_EnvoyGrpc_{
+			EnvoyGrpc: &envoy_core.GrpcService_EnvoyGrpc{
+				ClusterName: cluster,
+			},
+		},
+	}
+	spec := grpcClientSpec(e)
+	if spec == nil {
+		return service, nil
+	}
+
+	for _, h := range spec.InitialMetadata {
+		value := h.Value
+		if h.ValueFrom != nil {
+			if h.Value != "" {
+				return nil, fmt.Errorf("header %q has both a value and a valueFrom", h.Key)
+			}
+			value = secretTemplate(e, *h.ValueFrom)
+		}
+		service.InitialMetadata = append(service.InitialMetadata, &envoy_core.HeaderValue{Key: h.Key, Value: value})
+	}
+
+	if spec.Google == nil {
+		return service, nil
+	}
+	if len(endpoints) != 1 {
+		return nil, fmt.Errorf("the google grpc client connects to a single control plane endpoint")
+	}
+	google, err := googleGrpc(e, spec.Google, endpoints[0], tlsSecret)
+	if err != nil {
+		return nil, err
+	}
+	service.TargetSpecifier = &envoy_core.GrpcService_GoogleGrpc_{GoogleGrpc: google}
+	return service, nil
+}
+
+func googleGrpc(e *api.Envoy, spec *api.GoogleGRPCSpec, endpoint api.ControlPlaneEndpoint, tlsSecret *v1.Secret) (*envoy_core.GrpcService_GoogleGrpc, error) {
+	if tlsParametersSet(e.Spec.TLS) {
+		// its ssl credentials have none of the tls parameters, and its target uri is the sni
+		return nil, fmt.Errorf("the google grpc client doesn't support the tls parameters")
+	}
+	// call credentials are only sent over secure channels
+	ssl := &envoy_core.GrpcService_GoogleGrpc_SslCredentials{}
+	if tlsSecret != nil {
+		ssl.RootCerts = toDataSource(tlsFile(api.TLSCA))
+		if certFile, keyFile := clientCertFiles(e, tlsSecret); certFile != "" {
+			ssl.CertChain = toDataSource(certFile)
+			ssl.PrivateKey = toDataSource(keyFile)
+		}
+	}
+	statPrefix := spec.StatPrefix
+	if statPrefix == "" {
+		statPrefix = "ads"
+	}
+	google := &envoy_core.GrpcService_GoogleGrpc{
+		TargetUri:  fmt.Sprintf("%s:%d", endpoint.Address, endpoint.Port),
+		StatPrefix: statPrefix,
+		ChannelCredentials: &envoy_core.GrpcService_GoogleGrpc_ChannelCredentials{
+			CredentialSpecifier: &envoy_core.GrpcService_GoogleGrpc_ChannelCredentials_SslCredentials{
+				SslCredentials: ssl,
+			},
+		},
+	}
+
+	for i, c := range spec.CallCredentials {
+		var creds []*envoy_core.GrpcService_GoogleGrpc_CallCredentials
+		if c.AccessToken != nil {
+			creds = append(creds, &envoy_core.GrpcService_GoogleGrpc_CallCredentials{
+				CredentialSpecifier: &envoy_core.GrpcService_GoogleGrpc_CallCredentials_AccessToken{
+					AccessToken: secretTemplate(e, *c.AccessToken),
+				},
+			})
+		}
+		if jwt := c.ServiceAccountJWT; jwt != nil {
+			creds = append(creds, &envoy_core.GrpcService_GoogleGrpc_CallCredentials{
+				CredentialSpecifier: &envoy_core.GrpcService_GoogleGrpc_CallCredentials_ServiceAccountJwtAccess{
+					ServiceAccountJwtAccess: &envoy_core.GrpcService_GoogleGrpc_CallCredentials_ServiceAccountJWTAccessCredentials{
+						JsonKey:              secretTemplate(e, jwt.JSONKey),
+						TokenLifetimeSeconds: jwt.TokenLifetimeSeconds,
+					},
+				},
+			})
+		}
+		if sts := c.STS; sts != nil {
+			if sts.TokenExchangeServiceURI == "" || sts.SubjectTokenType == "" {
+				return nil, fmt.Errorf("sts call credentials need a tokenExchangeServiceUri and a subjectTokenType")
+			}
+			creds = append(creds, &envoy_core.GrpcService_GoogleGrpc_CallCredentials{
+				CredentialSpecifier: &envoy_core.GrpcService_GoogleGrpc_CallCredentials_StsService_{
+					StsService: &envoy_core.GrpcService_GoogleGrpc_CallCredentials_StsService{
+						TokenExchangeServiceUri: sts.TokenExchangeServiceURI,
+						Resource:                sts.Resource,
+						Audience:                sts.Audience,
+						Scope:                   sts.Scope,
+						RequestedTokenType:      sts.RequestedTokenType,
+						SubjectTokenPath:        secretPath(sts.SubjectToken),
+						SubjectTokenType:        sts.SubjectTokenType,
+					},
+				},
+			})
+		}
+		if c.GoogleComputeEngine {
+			creds = append(creds, &envoy_core.GrpcService_GoogleGrpc_CallCredentials{
+				CredentialSpecifier: &envoy_core.GrpcService_GoogleGrpc_CallCredentials_GoogleComputeEngine{
+					GoogleComputeEngine: &empty.Empty{},
+				},
+			})
+		}
+		if len(creds) != 1 {
+			return nil, fmt.Errorf("call credentials %d must set exactly one kind of credentials", i)
+		}
+		google.CallCredentials = append(google.CallCredentials, creds[0])
+	}
+	return google, nil
+}
+
+// GRPCSecretRefs returns the secrets whose values are written in the bootstrap config. The init
+// container reads them from env vars, so that they don't end up in the config map.
+func GRPCSecretRefs(e *api.Envoy) []v1.SecretKeySelector {
+	spec := grpcClientSpec(e)
+	if spec == nil {
+		return nil
+	}
+	var refs []v1.SecretKeySelector
+	add := func(ref v1.SecretKeySelector) {
+		for _, r := range refs {
+			if r.Name == ref.Name && r.Key == ref.Key {
+				return
+			}
+		}
+		refs = append(refs, ref)
+	}
+	for _, h := range spec.InitialMetadata {
+		if h.ValueFrom != nil {
+			add(*h.ValueFrom)
+		}
+	}
+	if spec.Google != nil {
+		for _, c := range spec.Google.CallCredentials {
+			if c.AccessToken != nil {
+				add(*c.AccessToken)
+			}
+			if c.ServiceAccountJWT != nil {
+				add(c.ServiceAccountJWT.JSONKey)
+			}
+		}
+	}
+	return refs
+}
+
+// secretTemplate returns the template the initializer replaces with the value of the secret
+func secretTemplate(e *api.Envoy, ref v1.SecretKeySelector) string {
+	for i, r := range GRPCSecretRefs(e) {
+		if r.Name == ref.Name && r.Key == ref.Key {
+			return fmt.Sprintf(`{{env "%s%d"}}`, grpcSecretEnvPrefix, i)
+		}
+	}
+	return ""
+}
+
+// grpcSecretEnv returns the env vars of the init container holding the values of the secrets
+func grpcSecretEnv(e *api.Envoy) []v1.EnvVar {
+	var env []v1.EnvVar
+	for i, ref := range GRPCSecretRefs(e) {
+		ref := ref
+		env = append(env, v1.EnvVar{
+			Name:      fmt.Sprintf("%s%d", grpcSecretEnvPrefix, i),
+			ValueFrom: &v1.EnvVarSource{SecretKeyRef: &ref},
+		})
+	}
+	return env
+}
+
+// grpcSecretFiles returns the secrets envoy reads from files, like sts subject tokens
+func grpcSecretFiles(e *api.Envoy) []v1.SecretKeySelector {
+	spec := grpcClientSpec(e)
+	if spec == nil || spec.Google == nil {
+		return nil
+	}
+	var files []v1.SecretKeySelector
+	for _, c := range spec.Google.CallCredentials {
+		if c.STS != nil {
+			files = append(files, c.STS.SubjectToken)
+		}
+	}
+	return files
+}
+
+func secretPath(ref v1.SecretKeySelector) string {
+	return filepath.Join(grpcSecretsMountPath, ref.Name, ref.Key)
+}
+
+// grpcSecretVolumes returns a volume per secret envoy reads files from, and their mounts. The
+// volumes are numbered, as secret names may be longer than volume names can be.
+func grpcSecretVolumes(e *api.Envoy) ([]v1.Volume, []v1.VolumeMount) {
+	var (
+		volumes []v1.Volume
+		mounts  []v1.VolumeMount
+		seen    = map[string]bool{}
+	)
+	for _, ref := range grpcSecretFiles(e) {
+		if seen[ref.Name] {
+			continue
+		}
+		seen[ref.Name] = true
+		name := grpcSecretVolPrefix + strconv.Itoa(len(volumes))
+		volumes = append(volumes, v1.Volume{
+			Name: name,
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{SecretName: ref.Name},
+			},
+		})
+		mounts = append(mounts, v1.VolumeMount{
+			Name:      name,
+			MountPath: filepath.Join(grpcSecretsMountPath, ref.Name),
+			ReadOnly:  true,
+		})
+	}
+	return volumes, mounts
+}
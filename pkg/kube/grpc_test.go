@@ -0,0 +1,147 @@
+package kube_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	. "github.com/solo-io/envoy-operator/pkg/kube"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func secretKey(name, key string) v1.SecretKeySelector {
+	return v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: name}, Key: key}
+}
+
+var _ = Describe("Control plane grpc client", func() {
+	var e *api.Envoy
+
+	BeforeEach(func() {
+		e = testEnvoy()
+		token := secretKey("ads-auth", "token")
+		e.Spec.ControlPlane = &api.ControlPlaneSpec{
+			GRPC: &api.GRPCClientSpec{
+				InitialMetadata: []api.GRPCHeader{
+					{Key: "x-tenant", Value: "team-a"},
+					{Key: "authorization", ValueFrom: &token},
+				},
+			},
+		}
+	})
+
+	It("should send the initial metadata with envoy's client", func() {
+		b := generate(e, nil)
+		service := b.DynamicResources.AdsConfig.GrpcServices[0]
+		Expect(service.GetEnvoyGrpc().ClusterName).To(Equal("ads-control-plane"))
+		Expect(service.InitialMetadata).To(HaveLen(2))
+		Expect(service.InitialMetadata[0].Value).To(Equal("team-a"))
+		Expect(service.InitialMetadata[1].Value).To(Equal(`{{env "GRPC_SECRET_0"}}`))
+	})
+
+	It("should give the secrets to the init container", func() {
+		template, err := PodTemplateForEnvoy(e, nil)
+		Expect(err).NotTo(HaveOccurred())
+		env := template.Spec.InitContainers[0].Env
+		Expect(env).To(ContainElement(v1.EnvVar{
+			Name:      "GRPC_SECRET_0",
+			ValueFrom: &v1.EnvVarSource{SecretKeyRef: e.Spec.ControlPlane.GRPC.InitialMetadata[1].ValueFrom},
+		}))
+	})
+
+	It("should reject a header with a value and a valueFrom", func() {
+		e.Spec.ControlPlane.GRPC.InitialMetadata[1].Value = "token"
+		_, err := GenerateEnvoyConfig(e, nil)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject grpc settings with REST", func() {
+		e.Spec.XDS = &api.XDSSpec{Mode: api.XDSModeREST}
+		_, err := GenerateEnvoyConfig(e, nil)
+		Expect(err).To(HaveOccurred())
+	})
+
+	Context("google grpc", func() {
+		BeforeEach(func() {
+			e.Spec.ControlPlane.GRPC.Google = &api.GoogleGRPCSpec{
+				CallCredentials: []api.CallCredentialsSpec{{
+					ServiceAccountJWT: &api.ServiceAccountJWTSpec{JSONKey: secretKey("ads-auth", "key.json")},
+				}, {
+					STS: &api.STSSpec{
+						TokenExchangeServiceURI: "https://sts.example.com/token",
+						SubjectToken:            secretKey("ads-subject", "token"),
+						SubjectTokenType:        "urn:ietf:params:oauth:token-type:jwt",
+					},
+				}},
+			}
+		})
+
+		It("should use the google grpc client with call credentials", func() {
+			b := generate(e, nil)
+			google := b.DynamicResources.AdsConfig.GrpcServices[0].GetGoogleGrpc()
+			Expect(google.TargetUri).To(Equal("ads.solo.io:1234"))
+			Expect(google.StatPrefix).To(Equal("ads"))
+			Expect(google.ChannelCredentials.GetSslCredentials()).NotTo(BeNil())
+			Expect(google.CallCredentials).To(HaveLen(2))
+			Expect(google.CallCredentials[0].GetServiceAccountJwtAccess().JsonKey).To(Equal(`{{env "GRPC_SECRET_1"}}`))
+			Expect(google.CallCredentials[1].GetStsService().SubjectTokenPath).To(Equal("/etc/grpc-secrets/ads-subject/token"))
+		})
+
+		It("should use the tls secret for the channel", func() {
+			b := generate(e, &v1.Secret{Data: map[string][]byte{api.TLSCA: []byte("ca")}})
+			ssl := b.DynamicResources.AdsConfig.GrpcServices[0].GetGoogleGrpc().ChannelCredentials.GetSslCredentials()
+			Expect(ssl.RootCerts.GetFilename()).To(HaveSuffix(api.TLSCA))
+			Expect(ssl.CertChain).To(BeNil())
+		})
+
+		It("should mount the sts subject token in the envoy container", func() {
+			template, err := PodTemplateForEnvoy(e, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(template.Spec.Volumes).To(ContainElement(v1.Volume{
+				Name: "grpc-secret-0",
+				VolumeSource: v1.VolumeSource{
+					Secret: &v1.SecretVolumeSource{SecretName: "ads-subject"},
+				},
+			}))
+			Expect(template.Spec.Containers[0].VolumeMounts).To(ContainElement(v1.VolumeMount{
+				Name:      "grpc-secret-0",
+				MountPath: "/etc/grpc-secrets/ads-subject",
+				ReadOnly:  true,
+			}))
+		})
+
+		It("should name the volumes of long secret names validly", func() {
+			name := strings.Repeat("subject", 10)
+			e.Spec.ControlPlane.GRPC.Google.CallCredentials[1].STS.SubjectToken = secretKey(name, "token")
+			template, err := PodTemplateForEnvoy(e, nil)
+			Expect(err).NotTo(HaveOccurred())
+			for _, v := range template.Spec.Volumes {
+				Expect(validation.IsDNS1123Label(v.Name)).To(BeEmpty())
+			}
+			Expect(template.Spec.Containers[0].VolumeMounts).To(ContainElement(v1.VolumeMount{
+				Name:      "grpc-secret-0",
+				MountPath: "/etc/grpc-secrets/" + name,
+				ReadOnly:  true,
+			}))
+		})
+
+		It("should need a single endpoint", func() {
+			e.Spec.ADSServer = ""
+			e.Spec.ControlPlane.Endpoints = []api.ControlPlaneEndpoint{
+				{Address: "ads-a.solo.io", Port: 1234},
+				{Address: "ads-b.solo.io", Port: 1234},
+			}
+			_, err := GenerateEnvoyConfig(e, nil)
+			Expect(err).To(MatchError(ContainSubstring("single control plane endpoint")))
+		})
+
+		It("should reject call credentials of several kinds", func() {
+			e.Spec.ControlPlane.GRPC.Google.CallCredentials[0].GoogleComputeEngine = true
+			_, err := GenerateEnvoyConfig(e, nil)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,156 @@
+package kube
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/duration"
+	"github.com/golang/protobuf/ptypes/wrappers"
+
+	envoy_cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	envoy_original_dst "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/listener/original_dst/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// The uid envoy runs as when traffic is intercepted, so that its own traffic is not
+	ProxyUID = 1337
+
+	passthroughClusterName = "passthrough"
+)
+
+func interceptionFor(e *api.Envoy) *api.InterceptionSpec {
+	if e.Spec.Injection == nil {
+		return nil
+	}
+	return e.Spec.Injection.Interception
+}
+
+// InterceptionInitContainer returns the init container that redirects the pod's traffic to envoy
+func InterceptionInitContainer(e *api.Envoy) v1.Container {
+	ic := interceptionFor(e)
+	excludeInboundPorts := ic.ExcludeInboundPorts
+	if e.Spec.AdminPort != 0 {
+		excludeInboundPorts = append(excludeInboundPorts, uint32(e.Spec.AdminPort))
+	}
+	if port := statsPort(e); port != 0 {
+		excludeInboundPorts = append(excludeInboundPorts, port)
+	}
+
+	args := []string{
+		"iptables",
+		"-mode", string(ic.Mode),
+		"-outbound-port", strconv.Itoa(int(ic.OutboundPort)),
+		"-inbound-port", strconv.Itoa(int(ic.InboundPort)),
+		"-proxy-uid", strconv.Itoa(ProxyUID),
+	}
+	args = appendListArg(args, "-include-outbound-cidrs", ic.IncludeOutboundCIDRs)
+	args = appendListArg(args, "-exclude-outbound-cidrs", ic.ExcludeOutboundCIDRs)
+	args = appendListArg(args, "-exclude-outbound-ports", uintsToStrings(ic.ExcludeOutboundPorts))
+	args = appendListArg(args, "-include-inbound-ports", uintsToStrings(ic.IncludeInboundPorts))
+	args = appendListArg(args, "-exclude-inbound-ports", uintsToStrings(excludeInboundPorts))
+	var uids []string
+	for _, uid := range ic.ExcludeUIDs {
+		uids = append(uids, strconv.FormatInt(uid, 10))
+	}
+	args = appendListArg(args, "-exclude-uids", uids)
+
+	root := int64(0)
+	return v1.Container{
+		Name:  "envoy-iptables",
+		Image: initContainerImage,
+		Args:  args,
+		SecurityContext: &v1.SecurityContext{
+			RunAsUser: &root,
+			Capabilities: &v1.Capabilities{
+				Add: []v1.Capability{"NET_ADMIN", "NET_RAW"},
+			},
+		},
+	}
+}
+
+func appendListArg(args []string, name string, values []string) []string {
+	if len(values) == 0 {
+		return args
+	}
+	return append(args, name, strings.Join(values, ","))
+}
+
+func uintsToStrings(in []uint32) []string {
+	var out []string
+	for _, v := range in {
+		out = append(out, strconv.FormatUint(uint64(v), 10))
+	}
+	return out
+}
+
+// interceptionResources returns the listeners that accept the intercepted traffic, and the
+// cluster that forwards it to its original destination
+func interceptionResources(ic *api.InterceptionSpec) ([]*envoy_listener.Listener, []*envoy_cluster.Cluster, error) {
+	outbound, err := interceptionListener("virtual_outbound", ic.OutboundPort, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	inbound, err := interceptionListener("virtual_inbound", ic.InboundPort, ic.Mode == api.InterceptionModeTProxy)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	passthrough := &envoy_cluster.Cluster{
+		Name:                 passthroughClusterName,
+		ClusterDiscoveryType: &envoy_cluster.Cluster_Type{Type: envoy_cluster.Cluster_ORIGINAL_DST},
+		LbPolicy:             envoy_cluster.Cluster_CLUSTER_PROVIDED,
+		ConnectTimeout:       &duration.Duration{Seconds: 5},
+	}
+	return []*envoy_listener.Listener{outbound, inbound}, []*envoy_cluster.Cluster{passthrough}, nil
+}
+
+func interceptionListener(name string, port uint32, transparent bool) (*envoy_listener.Listener, error) {
+	if port == 0 {
+		return nil, fmt.Errorf("listener %s has no port", name)
+	}
+	originalDst, err := ptypes.MarshalAny(&envoy_original_dst.OriginalDst{})
+	if err != nil {
+		return nil, err
+	}
+	tcpProxy, err := tcpProxyFilter(name, passthroughClusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &envoy_listener.Listener{
+		Name:    name,
+		Address: socketAddress("0.0.0.0", port),
+		ListenerFilters: []*envoy_listener.ListenerFilter{{
+			Name:       wellknown.OriginalDestination,
+			ConfigType: &envoy_listener.ListenerFilter_TypedConfig{TypedConfig: originalDst},
+		}},
+		FilterChains: []*envoy_listener.FilterChain{{
+			Filters: []*envoy_listener.Filter{tcpProxy},
+		}},
+	}
+	if transparent {
+		l.Transparent = &wrappers.BoolValue{Value: true}
+	}
+	return l, nil
+}
+
+func socketAddress(address string, port uint32) *envoy_core.Address {
+	return &envoy_core.Address{
+		Address: &envoy_core.Address_SocketAddress{
+			SocketAddress: &envoy_core.SocketAddress{
+				Address: address,
+				PortSpecifier: &envoy_core.SocketAddress_PortValue{
+					PortValue: port,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,142 @@
+package kube
+
+import (
+	"strconv"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	PrometheusPath = "/stats/prometheus"
+
+	// The api version of the prometheus-operator monitors
+	MonitoringAPIVersion = "monitoring.coreos.com/v1"
+
+	metricsServiceSuffix = "-metrics"
+)
+
+// metricsPort returns the port prometheus scrapes, and the name of the container port
+func metricsPort(e *api.Envoy) (int32, string) {
+	if port := statsPort(e); port != 0 {
+		return int32(port), statsPortName
+	}
+	return e.Spec.AdminPort, "admin"
+}
+
+// MetricsPodAnnotations returns the annotations that let prometheus discover the envoy pods
+func MetricsPodAnnotations(e *api.Envoy) map[string]string {
+	port, _ := metricsPort(e)
+	return map[string]string{
+		"prometheus.io/scrape": "true",
+		"prometheus.io/port":   strconv.Itoa(int(port)),
+		"prometheus.io/path":   PrometheusPath,
+	}
+}
+
+// MetricsServiceForEnvoy returns the headless service a ServiceMonitor selects the envoy pods with
+func MetricsServiceForEnvoy(e *api.Envoy) (*v1.Service, error) {
+	port, name := metricsPort(e)
+	s := &v1.Service{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Service",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      MetricsServiceName(e),
+			Namespace: e.Namespace,
+			Labels:    metricsServiceLabels(e),
+		},
+		Spec: v1.ServiceSpec{
+			ClusterIP: v1.ClusterIPNone,
+			Selector:  LabelsForEnvoy(e),
+			Ports: []v1.ServicePort{{
+				Name:       name,
+				Port:       port,
+				TargetPort: intstr.FromInt(int(port)),
+				Protocol:   v1.ProtocolTCP,
+			}},
+		},
+	}
+	if err := setSpecHash(s, managedServiceFields(s)); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func MetricsServiceName(e *api.Envoy) string {
+	return e.Name + metricsServiceSuffix
+}
+
+func metricsServiceLabels(e *api.Envoy) map[string]string {
+	labels := LabelsForEnvoy(e)
+	labels["envoy.solo.io/metrics"] = "true"
+	return labels
+}
+
+// MonitorForEnvoy returns the prometheus-operator PodMonitor or ServiceMonitor of the envoy
+func MonitorForEnvoy(e *api.Envoy, kind api.MetricsMonitor) (*unstructured.Unstructured, error) {
+	m := e.Spec.Metrics
+	_, portName := metricsPort(e)
+	endpoint := map[string]interface{}{
+		"port": portName,
+		"path": PrometheusPath,
+	}
+	if m.Interval != "" {
+		endpoint["interval"] = m.Interval
+	}
+
+	var spec map[string]interface{}
+	switch kind {
+	case api.MetricsMonitorPodMonitor:
+		spec = map[string]interface{}{
+			"selector":            map[string]interface{}{"matchLabels": stringMap(LabelsForEnvoy(e))},
+			"podMetricsEndpoints": []interface{}{endpoint},
+		}
+	case api.MetricsMonitorServiceMonitor:
+		spec = map[string]interface{}{
+			"selector":  map[string]interface{}{"matchLabels": stringMap(metricsServiceLabels(e))},
+			"endpoints": []interface{}{endpoint},
+		}
+	}
+
+	u := EmptyMonitor(e, kind)
+	u.SetLabels(m.MonitorLabels)
+	u.Object["spec"] = spec
+	if err := setSpecHash(u, u.Object); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// EmptyMonitor returns a monitor of the given kind with only the type and name of the one of the envoy
+func EmptyMonitor(e *api.Envoy, kind api.MetricsMonitor) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	u.SetAPIVersion(MonitoringAPIVersion)
+	u.SetKind(string(kind))
+	u.SetName(e.Name)
+	u.SetNamespace(e.Namespace)
+	return u
+}
+
+// MonitorNeedsUpdate returns true when the labels or the spec of the live monitor differ from the desired ones
+func MonitorNeedsUpdate(desired, live *unstructured.Unstructured) (bool, error) {
+	return unstructuredNeedsUpdate(desired, live)
+}
+
+// UpdateMonitor copies the managed fields of the desired monitor to the live one
+func UpdateMonitor(desired, live *unstructured.Unstructured) {
+	updateUnstructured(desired, live)
+}
+
+// stringMap converts labels to the map type of unstructured objects
+func stringMap(m map[string]string) map[string]interface{} {
+	res := map[string]interface{}{}
+	for k, v := range m {
+		res[k] = v
+	}
+	return res
+}
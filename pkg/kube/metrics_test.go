@@ -0,0 +1,112 @@
+package kube_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	. "github.com/solo-io/envoy-operator/pkg/kube"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("Metrics", func() {
+	var e *api.Envoy
+
+	BeforeEach(func() {
+		e = testEnvoy()
+		e.Spec.Metrics = &api.MetricsSpec{}
+		e.SetDefaults()
+	})
+
+	It("should scrape the stats listener by default", func() {
+		Expect(e.Spec.Metrics.Monitor).To(Equal(api.MetricsMonitorAuto))
+		Expect(MetricsPodAnnotations(e)).To(Equal(map[string]string{
+			"prometheus.io/scrape": "true",
+			"prometheus.io/port":   "19001",
+			"prometheus.io/path":   "/stats/prometheus",
+		}))
+	})
+
+	It("should scrape the admin port when it is not bound to localhost", func() {
+		e.Spec.Admin.BindAddress = "0.0.0.0"
+		Expect(MetricsPodAnnotations(e)).To(HaveKeyWithValue("prometheus.io/port", "19000"))
+		b := generate(e, nil)
+		Expect(b.StaticResources.Listeners).To(BeEmpty())
+	})
+
+	Context("with a metrics port", func() {
+		BeforeEach(func() {
+			e.Spec.Admin.BindAddress = "0.0.0.0"
+			e.Spec.Metrics.Port = 9102
+		})
+
+		It("should serve the stats on the metrics port", func() {
+			b := generate(e, nil)
+			Expect(b.StaticResources.Listeners).To(HaveLen(1))
+			l := b.StaticResources.Listeners[0]
+			Expect(l.Name).To(Equal("stats"))
+			Expect(l.Address.GetSocketAddress().GetPortValue()).To(BeEquivalentTo(9102))
+		})
+
+		It("should expose the metrics port", func() {
+			Expect(EnvoyContainer(e).Ports).To(ContainElement(v1.ContainerPort{Name: "stats", ContainerPort: 9102}))
+			Expect(MetricsPodAnnotations(e)).To(HaveKeyWithValue("prometheus.io/port", "9102"))
+		})
+
+		It("should select the metrics port in monitors", func() {
+			e.Spec.Metrics.Interval = "15s"
+			e.Spec.Metrics.MonitorLabels = map[string]string{"release": "prometheus"}
+			m, err := MonitorForEnvoy(e, api.MetricsMonitorPodMonitor)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(m.GetAPIVersion()).To(Equal("monitoring.coreos.com/v1"))
+			Expect(m.GetKind()).To(Equal("PodMonitor"))
+			Expect(m.GetLabels()).To(Equal(map[string]string{"release": "prometheus"}))
+
+			endpoints, _ := unstructured.NestedSlice(m.Object, "spec", "podMetricsEndpoints")
+			Expect(endpoints).To(Equal([]interface{}{map[string]interface{}{
+				"port":     "stats",
+				"path":     "/stats/prometheus",
+				"interval": "15s",
+			}}))
+			selector, _ := unstructured.NestedStringMap(m.Object, "spec", "selector", "matchLabels")
+			Expect(selector).To(Equal(LabelsForEnvoy(e)))
+		})
+	})
+
+	It("should select a headless metrics service with a ServiceMonitor", func() {
+		m, err := MonitorForEnvoy(e, api.MetricsMonitorServiceMonitor)
+		Expect(err).NotTo(HaveOccurred())
+		s, err := MetricsServiceForEnvoy(e)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Name).To(Equal("myenvoy-metrics"))
+		Expect(s.Spec.ClusterIP).To(Equal(v1.ClusterIPNone))
+		Expect(s.Spec.Ports[0].Name).To(Equal("stats"))
+
+		selector, _ := unstructured.NestedStringMap(m.Object, "spec", "selector", "matchLabels")
+		for k, v := range selector {
+			Expect(s.Labels).To(HaveKeyWithValue(k, v))
+		}
+	})
+
+	It("should detect removed monitor fields", func() {
+		e.Spec.Metrics.Interval = "15s"
+		live, err := MonitorForEnvoy(e, api.MetricsMonitorPodMonitor)
+		Expect(err).NotTo(HaveOccurred())
+		e.Spec.Metrics.Interval = ""
+		desired, err := MonitorForEnvoy(e, api.MetricsMonitorPodMonitor)
+		Expect(err).NotTo(HaveOccurred())
+
+		needsUpdate, err := MonitorNeedsUpdate(desired, live)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(needsUpdate).To(BeTrue())
+
+		UpdateMonitor(desired, live)
+		needsUpdate, err = MonitorNeedsUpdate(desired, live)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(needsUpdate).To(BeFalse())
+		endpoints, _ := unstructured.NestedSlice(live.Object, "spec", "podMetricsEndpoints")
+		Expect(endpoints[0]).NotTo(HaveKey("interval"))
+	})
+})
@@ -0,0 +1,40 @@
+package kube
+
+import (
+	"fmt"
+
+	"github.com/ghodss/yaml"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+
+	envoy_config_bootstrap "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v3"
+)
+
+// ApplyBootstrapOverlay merges the overlay, a partial bootstrap config in yaml or json, onto the
+// generated bootstrap config. Fields set in the overlay replace the generated ones, messages are
+// merged, and lists are appended to.
+func ApplyBootstrapOverlay(config, overlay string) (string, error) {
+	if overlay == "" {
+		return config, nil
+	}
+	overlayJson, err := yaml.YAMLToJSON([]byte(overlay))
+	if err != nil {
+		return "", fmt.Errorf("invalid bootstrap overlay: %v", err)
+	}
+	var overlayConfig envoy_config_bootstrap.Bootstrap
+	if err := jsonpb.UnmarshalString(string(overlayJson), &overlayConfig); err != nil {
+		return "", fmt.Errorf("invalid bootstrap overlay: %v", err)
+	}
+
+	var bootstrapConfig envoy_config_bootstrap.Bootstrap
+	if err := jsonpb.UnmarshalString(config, &bootstrapConfig); err != nil {
+		return "", err
+	}
+	proto.Merge(&bootstrapConfig, &overlayConfig)
+	if err := bootstrapConfig.Validate(); err != nil {
+		return "", fmt.Errorf("invalid bootstrap config with overlay: %v", err)
+	}
+
+	var marshaller jsonpb.Marshaler
+	return marshaller.MarshalToString(&bootstrapConfig)
+}
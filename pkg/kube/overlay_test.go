@@ -0,0 +1,105 @@
+package kube_test
+
+import (
+	"github.com/golang/protobuf/jsonpb"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	envoy_config_bootstrap "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v3"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	. "github.com/solo-io/envoy-operator/pkg/kube"
+)
+
+var _ = Describe("Bootstrap overlay", func() {
+	var config string
+
+	overlay := func(o string) *envoy_config_bootstrap.Bootstrap {
+		cfg, err := ApplyBootstrapOverlay(config, o)
+		Expect(err).NotTo(HaveOccurred())
+		var bootstrap envoy_config_bootstrap.Bootstrap
+		err = jsonpb.UnmarshalString(cfg, &bootstrap)
+		Expect(err).NotTo(HaveOccurred())
+		return &bootstrap
+	}
+
+	BeforeEach(func() {
+		var err error
+		config, err = GenerateEnvoyConfig(testEnvoy(), nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should leave the config alone without an overlay", func() {
+		cfg, err := ApplyBootstrapOverlay(config, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).To(Equal(config))
+	})
+
+	It("should merge a yaml overlay", func() {
+		b := overlay(`
+node:
+  locality:
+    zone: us-east-1a
+layered_runtime:
+  layers:
+  - name: static
+    static_layer:
+      overload.global_downstream_max_connections: 50000
+overload_manager:
+  refresh_interval: 0.25s
+  resource_monitors:
+  - name: envoy.resource_monitors.fixed_heap
+    typed_config:
+      "@type": type.googleapis.com/envoy.config.resource_monitor.fixed_heap.v2alpha.FixedHeapConfig
+      max_heap_size_bytes: 1073741824
+`)
+		Expect(b.Node.Id).To(Equal("{{.PodName}}-ingress"))
+		Expect(b.Node.Locality.Zone).To(Equal("us-east-1a"))
+		Expect(b.LayeredRuntime.Layers).To(HaveLen(1))
+		Expect(b.OverloadManager.ResourceMonitors).To(HaveLen(1))
+		Expect(b.StaticResources.Clusters[0].Name).To(Equal("ads-control-plane"))
+	})
+
+	It("should merge a json overlay", func() {
+		b := overlay(`{"node": {"id": "fixed"}, "stats_flush_interval": "10s"}`)
+		Expect(b.Node.Id).To(Equal("fixed"))
+		Expect(b.Node.Cluster).To(Equal("ingress"))
+		Expect(b.StatsFlushInterval.Seconds).To(BeEquivalentTo(10))
+	})
+
+	It("should append to lists", func() {
+		b := overlay(`
+static_resources:
+  clusters:
+  - name: extra
+    connect_timeout: 1s
+`)
+		last := len(b.StaticResources.Clusters) - 1
+		Expect(b.StaticResources.Clusters[last].Name).To(Equal("extra"))
+	})
+
+	It("should reject unknown fields", func() {
+		_, err := ApplyBootstrapOverlay(config, `{"no_such_field": 1}`)
+		Expect(err).To(MatchError(ContainSubstring("invalid bootstrap overlay")))
+	})
+
+	It("should reject malformed yaml", func() {
+		_, err := ApplyBootstrapOverlay(config, "node: [")
+		Expect(err).To(MatchError(ContainSubstring("invalid bootstrap overlay")))
+	})
+
+	It("should reject overlays that make the config invalid", func() {
+		_, err := ApplyBootstrapOverlay(config, `{"static_resources": {"clusters": [{"connect_timeout": "1s"}]}}`)
+		Expect(err).To(MatchError(ContainSubstring("invalid bootstrap config with overlay")))
+	})
+
+	It("should keep the generated config valid", func() {
+		e := testEnvoy()
+		e.Spec.Injection = &api.InjectionSpec{Interception: &api.InterceptionSpec{}}
+		e.SetDefaults()
+		cfg, err := GenerateEnvoyConfig(e, nil)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = ApplyBootstrapOverlay(cfg, `{}`)
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
@@ -0,0 +1,319 @@
+package kube
+
+import (
+	"path/filepath"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+
+	"github.com/solo-io/envoy-operator/pkg/downward"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	initContainerImage = "soloio/envoy-operator-init:0.1"
+
+	downwardVolName = "downward-api-volume"
+	downwardVolPath = "/etc/podinfo/"
+
+	envoyConfigVolName = "envoy-config"
+	envoyConfigPath    = "/etc/tmp-envoy/"
+
+	envoyConfigTmpVolName = "envoy-tmp-config"
+	envoyConfigTmpPath    = "/etc/envoy/"
+
+	// Config map mounts are readonly, so we have to move the transformed config to a different place...
+	EnvoyConfigFilePath       = "/etc/envoy/envoy.json"
+	envoySourceConfigFilePath = "/etc/tmp-envoy/envoy.json"
+
+	envoyTLSVolName = "tls-certs"
+
+	envoyContainerName = "envoy"
+
+	// The config init container copies itself to the volume it shares with envoy, so that the
+	// envoy image doesn't need any tools to be told to drain, or to check its certificate.
+	initializerBinaryPath = envoyConfigTmpPath + "envoy-operator-init"
+)
+
+// InitDownward returns the volumes and env vars needed to provide the downward api values
+// that the envoy's templates reference
+func InitDownward(e *api.Envoy) ([]v1.Volume, []v1.EnvVar, error) {
+
+	whatsNeeded := downward.TestNeededDownwardAPI()
+	interpolate := downward.NewInterpolator()
+	for _, template := range templatesOf(e) {
+		if err := interpolate.InterpolateString(&template, whatsNeeded); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var volumes []v1.Volume
+	downwardVolNeeded := whatsNeeded.IsPodAnnotations || whatsNeeded.IsPodLabels
+	if downwardVolNeeded {
+		volumes = append(volumes, addVolumes(whatsNeeded.IsPodLabels, whatsNeeded.IsPodAnnotations))
+	}
+	var env []v1.EnvVar
+	if whatsNeeded.IsPodName {
+		env = append(env, addEnv("POD_NAME", "metadata.name"))
+	}
+	if whatsNeeded.IsPodNamespace {
+		env = append(env, addEnv("POD_NAMESPACE", "metadata.namespace"))
+	}
+	if whatsNeeded.IsPodIp {
+		env = append(env, addEnv("POD_IP", "status.podIp"))
+	}
+	if whatsNeeded.IsPodSvcAccount {
+		env = append(env, addEnv("POD_SVCACCNT", "spec.serviceAccountName"))
+	}
+	if whatsNeeded.IsPodNamespace {
+		env = append(env, addEnv("POD_UID", "metadata.uid"))
+	}
+	if whatsNeeded.IsNodeName {
+		env = append(env, addEnv("NODE_NAME", "spec.nodeName"))
+	}
+	if whatsNeeded.IsNodeIp {
+		env = append(env, addEnv("NODE_IP", "status.hostIP"))
+	}
+	return volumes, env, nil
+}
+
+// PodTemplateForEnvoy returns the template of the pods running the envoy
+func PodTemplateForEnvoy(e *api.Envoy, podAnnotations map[string]string) (v1.PodTemplateSpec, error) {
+	spec, err := envoyPodSpec(e, v1.VolumeSource{
+		ConfigMap: &v1.ConfigMapVolumeSource{
+			LocalObjectReference: v1.LocalObjectReference{
+				Name: ConfigMapNameForEnvoy(e),
+			},
+		},
+	})
+	if err != nil {
+		return v1.PodTemplateSpec{}, err
+	}
+
+	return v1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        e.GetName(),
+			Namespace:   e.GetNamespace(),
+			Labels:      LabelsForEnvoy(e),
+			Annotations: podAnnotations,
+		},
+		Spec: spec,
+	}, nil
+}
+
+// envoyPodSpec returns the containers and volumes needed to run the envoy, with the bootstrap
+// config read from configSource
+func envoyPodSpec(e *api.Envoy, configSource v1.VolumeSource) (v1.PodSpec, error) {
+
+	volumes := []v1.Volume{{
+		Name:         envoyConfigVolName,
+		VolumeSource: configSource,
+	}, {
+		Name: envoyConfigTmpVolName,
+		VolumeSource: v1.VolumeSource{
+			EmptyDir: &v1.EmptyDirVolumeSource{},
+		},
+	},
+	}
+
+	if TLSSecretNameForEnvoy(e) != "" {
+		volumes = append(volumes, v1.Volume{
+			Name: envoyTLSVolName,
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{
+					SecretName: TLSSecretNameForEnvoy(e),
+				},
+			},
+		})
+
+	}
+
+	downvols, env, err := InitDownward(e)
+	if err != nil {
+		return v1.PodSpec{}, err
+	}
+	volumes = append(volumes, downvols...)
+	downwardVolNeeded := len(downvols) != 0
+	env = append(env, grpcSecretEnv(e)...)
+	grpcVols, _ := grpcSecretVolumes(e)
+	volumes = append(volumes, grpcVols...)
+
+	spec := v1.PodSpec{
+		InitContainers: []v1.Container{ConfigInitContainer(e, env, volumes, downwardVolNeeded)},
+		Containers:     []v1.Container{EnvoyContainer(e)},
+		Volumes:        volumes,
+	}
+	if c := SPIFFEInitContainer(e); c != nil {
+		spec.InitContainers = append(spec.InitContainers, *c)
+	}
+	if drain := drainFor(e); drain != nil {
+		spec.TerminationGracePeriodSeconds = drain.terminationGracePeriod()
+	}
+	return spec, nil
+}
+
+// templatesOf returns the fields of the envoy spec the initializer interpolates
+func templatesOf(e *api.Envoy) []string {
+	templates := []string{e.Spec.NodeIdTemplate, e.Spec.ClusterIdTemplate}
+	if stats := e.Spec.Stats; stats != nil {
+		if stats.Statsd != nil {
+			templates = append(templates, stats.Statsd.Address)
+		}
+		if stats.DogStatsd != nil {
+			templates = append(templates, stats.DogStatsd.Address)
+		}
+		if stats.MetricsService != nil {
+			templates = append(templates, stats.MetricsService.Address)
+		}
+	}
+	if tracing := e.Spec.Tracing; tracing != nil {
+		if tracing.Zipkin != nil {
+			templates = append(templates, tracing.Zipkin.Collector.Address)
+		}
+		if tracing.OpenCensus != nil {
+			templates = append(templates, tracing.OpenCensus.Collector.Address)
+		}
+		if tracing.Datadog != nil {
+			templates = append(templates, tracing.Datadog.Collector.Address)
+		}
+	}
+	return templates
+}
+
+func LabelsForEnvoy(e *api.Envoy) map[string]string {
+	return map[string]string{"app": "envoy", "envoy_cluster": e.Name}
+}
+
+func addEnv(name, ref string) v1.EnvVar {
+	return v1.EnvVar{
+		Name: name,
+		ValueFrom: &v1.EnvVarSource{
+			FieldRef: &v1.ObjectFieldSelector{
+				FieldPath: ref,
+			},
+		},
+	}
+}
+
+func addVolumes(isPodLabels, isPodAnnotations bool) v1.Volume {
+
+	var items []v1.DownwardAPIVolumeFile
+	if isPodLabels {
+		items = append(items, v1.DownwardAPIVolumeFile{
+			Path: "labels",
+			FieldRef: &v1.ObjectFieldSelector{
+				FieldPath: "metadata.labels",
+			},
+		})
+	}
+	if isPodAnnotations {
+		items = append(items, v1.DownwardAPIVolumeFile{
+			Path: "annotations",
+			FieldRef: &v1.ObjectFieldSelector{
+				FieldPath: "metadata.annotations",
+			},
+		})
+	}
+
+	return v1.Volume{
+		Name: downwardVolName,
+		VolumeSource: v1.VolumeSource{
+			DownwardAPI: &v1.DownwardAPIVolumeSource{
+				Items: items,
+			},
+		},
+	}
+}
+
+func EnvoyContainer(e *api.Envoy) v1.Container {
+
+	vmounts := []v1.VolumeMount{{
+		Name:      envoyConfigTmpVolName,
+		MountPath: filepath.Dir(envoyConfigTmpPath),
+	}}
+
+	var ports []v1.ContainerPort
+	if e.Spec.AdminPort != 0 && !adminIsLocal(e) {
+		ports = append(ports, v1.ContainerPort{
+			ContainerPort: e.Spec.AdminPort,
+			Name:          "admin",
+		})
+	}
+	if port := statsPort(e); port != 0 {
+		ports = append(ports, v1.ContainerPort{
+			ContainerPort: int32(port),
+			Name:          statsPortName,
+		})
+	}
+
+	if TLSSecretNameForEnvoy(e) != "" {
+		vmounts = append(vmounts, v1.VolumeMount{
+			Name:      envoyTLSVolName,
+			MountPath: filepath.Dir(api.EnvoyTLSVolPath),
+		})
+	}
+
+	_, grpcMounts := grpcSecretVolumes(e)
+	vmounts = append(vmounts, grpcMounts...)
+
+	readiness, liveness := probesForEnvoy(e)
+
+	args := []string{
+		"-c", EnvoyConfigFilePath, "--v2-config-only",
+	}
+	var lifecycle *v1.Lifecycle
+	if drain := drainFor(e); drain != nil {
+		args = append(args, drain.args()...)
+		lifecycle = drain.lifecycle(adminDialAddress(e), e.Spec.AdminPort)
+	}
+
+	return v1.Container{
+		Name:           envoyContainerName,
+		Image:          e.Spec.Image,
+		Command:        e.Spec.ImageCommand,
+		Args:           args,
+		VolumeMounts:   vmounts,
+		Ports:          ports,
+		ReadinessProbe: readiness,
+		LivenessProbe:  liveness,
+		Lifecycle:      lifecycle,
+	}
+}
+
+func ConfigInitContainer(v *api.Envoy, env []v1.EnvVar, volumes []v1.Volume, downwardvol bool) v1.Container {
+
+	vmounts := []v1.VolumeMount{{
+		Name:      envoyConfigVolName,
+		MountPath: filepath.Dir(envoyConfigPath),
+	}, {
+		Name:      envoyConfigTmpVolName,
+		MountPath: filepath.Dir(envoyConfigTmpPath),
+	}}
+
+	if downwardvol {
+		vmounts = append(vmounts, v1.VolumeMount{
+			Name:      downwardVolName,
+			MountPath: filepath.Dir(downwardVolPath),
+		})
+	}
+
+	args := []string{
+		"-input",
+		envoySourceConfigFilePath,
+		"-output",
+		EnvoyConfigFilePath,
+	}
+	if drainFor(v) != nil || spiffeFor(v) != nil {
+		args = append(args, "-install", initializerBinaryPath)
+	}
+
+	return v1.Container{
+		Name:         "envoy-init",
+		Image:        initContainerImage,
+		Args:         args,
+		Env:          env,
+		VolumeMounts: vmounts,
+	}
+}
+
+func ConfigMapNameForEnvoy(e *api.Envoy) string { return e.Name }
@@ -0,0 +1,82 @@
+package kube
+
+import (
+	"encoding/json"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// MergePodTemplate applies the override onto the generated pod template as a strategic merge
+// patch, so lists like containers and volumes are merged by name. The labels and annotations
+// of the generated template win, as the selectors and the pod rolling depend on them.
+func MergePodTemplate(generated v1.PodTemplateSpec, override *v1.PodTemplateSpec) (v1.PodTemplateSpec, error) {
+	if override == nil {
+		return generated, nil
+	}
+	original, err := json.Marshal(generated)
+	if err != nil {
+		return generated, err
+	}
+	patch, err := overridePatch(override)
+	if err != nil {
+		return generated, err
+	}
+	merged, err := strategicpatch.StrategicMergePatch(original, patch, v1.PodTemplateSpec{})
+	if err != nil {
+		return generated, fmt.Errorf("failed to merge pod template: %v", err)
+	}
+	var ret v1.PodTemplateSpec
+	if err := json.Unmarshal(merged, &ret); err != nil {
+		return generated, fmt.Errorf("failed to merge pod template: %v", err)
+	}
+	ret.Labels = mergeMaps(ret.Labels, generated.Labels)
+	ret.Annotations = mergeMaps(ret.Annotations, generated.Annotations)
+	return ret, nil
+}
+
+// overridePatch marshals the override without its null fields; fields without omitempty, like
+// the containers, would otherwise delete the generated ones.
+func overridePatch(override *v1.PodTemplateSpec) ([]byte, error) {
+	data, err := json.Marshal(override)
+	if err != nil {
+		return nil, err
+	}
+	var patch map[string]interface{}
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil, err
+	}
+	return json.Marshal(dropNulls(patch))
+}
+
+func dropNulls(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			if val == nil {
+				delete(v, k)
+				continue
+			}
+			v[k] = dropNulls(val)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = dropNulls(v[i])
+		}
+	}
+	return v
+}
+
+func mergeMaps(dst, src map[string]string) map[string]string {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = map[string]string{}
+	}
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
@@ -0,0 +1,133 @@
+package kube_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	. "github.com/solo-io/envoy-operator/pkg/kube"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("PodTemplate", func() {
+	var e *api.Envoy
+
+	podTemplate := func() v1.PodTemplateSpec {
+		d, err := DeploymentForEnvoy(e, nil)
+		Expect(err).NotTo(HaveOccurred())
+		return d.Spec.Template
+	}
+
+	BeforeEach(func() {
+		e = testEnvoy()
+	})
+
+	It("should leave the pods alone without an override", func() {
+		generated, err := PodTemplateForEnvoy(e, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(podTemplate()).To(Equal(generated))
+	})
+
+	It("should merge containers by name", func() {
+		e.Spec.Deployment.PodTemplate = &v1.PodTemplateSpec{
+			Spec: v1.PodSpec{
+				Containers: []v1.Container{{
+					Name: "envoy",
+					Resources: v1.ResourceRequirements{
+						Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("256Mi")},
+					},
+				}, {
+					Name:  "sidecar",
+					Image: "busybox",
+				}},
+			},
+		}
+		containers := podTemplate().Spec.Containers
+		Expect(containers).To(HaveLen(2))
+		Expect(containers[0].Name).To(Equal("envoy"))
+		Expect(containers[0].Image).To(Equal(e.Spec.Image))
+		Expect(containers[0].Args).To(ContainElement(EnvoyConfigFilePath))
+		Expect(containers[0].Resources.Limits.Memory().String()).To(Equal("256Mi"))
+		Expect(containers[1].Name).To(Equal("sidecar"))
+	})
+
+	It("should set scheduling fields", func() {
+		e.Spec.Deployment.PodTemplate = &v1.PodTemplateSpec{
+			Spec: v1.PodSpec{
+				Tolerations:        []v1.Toleration{{Key: "dedicated", Operator: v1.TolerationOpExists}},
+				NodeSelector:       map[string]string{"role": "edge"},
+				PriorityClassName:  "high",
+				ServiceAccountName: "envoy",
+				ImagePullSecrets:   []v1.LocalObjectReference{{Name: "registry"}},
+			},
+		}
+		spec := podTemplate().Spec
+		Expect(spec.Tolerations).To(HaveLen(1))
+		Expect(spec.NodeSelector).To(HaveKeyWithValue("role", "edge"))
+		Expect(spec.PriorityClassName).To(Equal("high"))
+		Expect(spec.ServiceAccountName).To(Equal("envoy"))
+		Expect(spec.ImagePullSecrets).To(ConsistOf(v1.LocalObjectReference{Name: "registry"}))
+		Expect(spec.Volumes).NotTo(BeEmpty())
+		Expect(spec.InitContainers).To(HaveLen(1))
+		Expect(spec.Containers).To(HaveLen(1))
+	})
+
+	It("should keep the selector labels", func() {
+		e.Spec.Deployment.PodTemplate = &v1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels:      map[string]string{"team": "edge", "app": "other"},
+				Annotations: map[string]string{"prometheus.io/scrape": "true"},
+			},
+		}
+		d, err := DeploymentForEnvoy(e, map[string]string{"checksum": "abc"})
+		Expect(err).NotTo(HaveOccurred())
+		meta := d.Spec.Template.ObjectMeta
+		Expect(meta.Labels).To(HaveKeyWithValue("team", "edge"))
+		for k, v := range LabelsForEnvoy(e) {
+			Expect(meta.Labels).To(HaveKeyWithValue(k, v))
+		}
+		Expect(meta.Annotations).To(HaveKeyWithValue("prometheus.io/scrape", "true"))
+		Expect(meta.Annotations).To(HaveKeyWithValue("checksum", "abc"))
+	})
+
+	It("should detect a removed override", func() {
+		e.Spec.Deployment.PodTemplate = &v1.PodTemplateSpec{
+			Spec: v1.PodSpec{PriorityClassName: "high"},
+		}
+		d, err := DeploymentForEnvoy(e, nil)
+		Expect(err).NotTo(HaveOccurred())
+		live := applyKubeDefaults(d)
+
+		e.Spec.Deployment.PodTemplate = nil
+		desired, err := DeploymentForEnvoy(e, nil)
+		Expect(err).NotTo(HaveOccurred())
+		res, err := DeploymentNeedsUpdate(desired, live)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(BeTrue())
+
+		UpdateDeployment(desired, live)
+		Expect(live.Spec.Template.Spec.PriorityClassName).To(BeEmpty())
+		res, err = DeploymentNeedsUpdate(desired, live)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(BeFalse())
+	})
+
+	It("should override the daemonset pods", func() {
+		e.Spec.Deployment = nil
+		e.Spec.DaemonSet = &api.EnvoyDaemonSetSpec{
+			PodTemplate: &v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Tolerations: []v1.Toleration{{Operator: v1.TolerationOpExists}},
+				},
+			},
+		}
+		e.SetDefaults()
+		ds, err := DaemonSetForEnvoy(e, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ds.Spec.Template.Spec.Tolerations).To(HaveLen(1))
+		Expect(ds.Spec.Template.Spec.Containers[0].Image).To(Equal(e.Spec.Image))
+	})
+})
@@ -0,0 +1,96 @@
+package kube
+
+import (
+	"fmt"
+	"path/filepath"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	"github.com/solo-io/envoy-operator/pkg/spiffe"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	readyPath      = "/ready"
+	serverInfoPath = "/server_info"
+	// the stats listener doesn't serve /server_info; any stat tells envoy is alive
+	uptimeStatPath = "/stats?filter=^server\\.uptime$"
+)
+
+// Envoy only turns ready once it received its listeners, which may take a while; be patient
+// before killing it.
+var (
+	defaultReadinessProbe = api.ProbeSpec{PeriodSeconds: 5}
+	defaultLivenessProbe  = api.ProbeSpec{InitialDelaySeconds: 15, FailureThreshold: 5}
+)
+
+// probesForEnvoy returns the readiness and liveness probes of the envoy container, or nil if
+// they are disabled. The admin port is always set, as it defaults to 19000. There is no startup
+// probe, as the vendored k8s.io/api predates StartupProbe.
+func probesForEnvoy(e *api.Envoy) (readiness *v1.Probe, liveness *v1.Probe) {
+	var spec api.ProbesSpec
+	if e.Spec.Probes != nil {
+		spec = *e.Spec.Probes
+	}
+	if spec.Disabled {
+		return nil, nil
+	}
+
+	if port := statsPort(e); port != 0 {
+		// the stats listener binds all addresses
+		ready := readyCheck(e, httpGet(readyPath, int32(port)), fmt.Sprintf("http://%s:%d%s", defaultAdminBindAddress, port, readyPath))
+		readiness = probe(ready, defaultReadinessProbe, spec.Readiness)
+		liveness = probe(httpGet(uptimeStatPath, int32(port)), defaultLivenessProbe, spec.Liveness)
+		return readiness, liveness
+	}
+	// the admin endpoint isn't bound to localhost
+	ready := readyCheck(e, httpGet(readyPath, e.Spec.AdminPort), fmt.Sprintf("http://%s:%d%s", adminDialAddress(e), e.Spec.AdminPort, readyPath))
+	readiness = probe(ready, defaultReadinessProbe, spec.Readiness)
+	liveness = probe(httpGet(serverInfoPath, e.Spec.AdminPort), defaultLivenessProbe, spec.Liveness)
+	return readiness, liveness
+}
+
+// readyCheck returns the handler of the readiness probe. The pod's own spiffe certificate isn't
+// renewed, so the pod also turns unready once it expires; the installed initializer checks both.
+func readyCheck(e *api.Envoy, handler v1.Handler, url string) v1.Handler {
+	if spiffeFor(e) == nil {
+		return handler
+	}
+	return v1.Handler{
+		Exec: &v1.ExecAction{
+			Command: []string{
+				initializerBinaryPath, "ready",
+				"-cert", filepath.Join(spiffeIdentityPath, spiffe.CertFile),
+				"-url", url,
+			},
+		},
+	}
+}
+
+func httpGet(path string, port int32) v1.Handler {
+	return v1.Handler{
+		HTTPGet: &v1.HTTPGetAction{
+			Path: path,
+			Port: intstr.FromInt(int(port)),
+		},
+	}
+}
+
+// probe returns a probe with the thresholds set in spec, or else in defaults
+func probe(handler v1.Handler, defaults, spec api.ProbeSpec) *v1.Probe {
+	pick := func(value, def int32) int32 {
+		if value != 0 {
+			return value
+		}
+		return def
+	}
+	return &v1.Probe{
+		Handler:             handler,
+		InitialDelaySeconds: pick(spec.InitialDelaySeconds, defaults.InitialDelaySeconds),
+		TimeoutSeconds:      pick(spec.TimeoutSeconds, defaults.TimeoutSeconds),
+		PeriodSeconds:       pick(spec.PeriodSeconds, defaults.PeriodSeconds),
+		SuccessThreshold:    pick(spec.SuccessThreshold, defaults.SuccessThreshold),
+		FailureThreshold:    pick(spec.FailureThreshold, defaults.FailureThreshold),
+	}
+}
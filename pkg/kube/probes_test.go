@@ -0,0 +1,66 @@
+package kube_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	. "github.com/solo-io/envoy-operator/pkg/kube"
+
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+var _ = Describe("Probes", func() {
+	var e *api.Envoy
+
+	BeforeEach(func() {
+		e = testEnvoy()
+	})
+
+	It("should probe the stats listener", func() {
+		c := EnvoyContainer(e)
+		Expect(c.ReadinessProbe.HTTPGet.Path).To(Equal("/ready"))
+		Expect(c.ReadinessProbe.HTTPGet.Port).To(Equal(intstr.FromInt(19001)))
+		Expect(c.LivenessProbe.HTTPGet.Path).To(HavePrefix("/stats?"))
+		Expect(c.LivenessProbe.HTTPGet.Port).To(Equal(intstr.FromInt(19001)))
+	})
+
+	It("should probe the admin endpoint when it is not bound to localhost", func() {
+		e.Spec.Admin.BindAddress = "0.0.0.0"
+		c := EnvoyContainer(e)
+		Expect(c.ReadinessProbe.HTTPGet.Path).To(Equal("/ready"))
+		Expect(c.ReadinessProbe.HTTPGet.Port).To(Equal(intstr.FromInt(int(e.Spec.AdminPort))))
+		Expect(c.LivenessProbe.HTTPGet.Path).To(Equal("/server_info"))
+		Expect(c.LivenessProbe.HTTPGet.Port).To(Equal(intstr.FromInt(int(e.Spec.AdminPort))))
+		Expect(c.LivenessProbe.InitialDelaySeconds).To(BeNumerically(">", 0))
+	})
+
+	It("should use the thresholds of the spec", func() {
+		e.Spec.Probes = &api.ProbesSpec{
+			Readiness: api.ProbeSpec{PeriodSeconds: 2, FailureThreshold: 10},
+			Liveness:  api.ProbeSpec{InitialDelaySeconds: 60},
+		}
+		c := EnvoyContainer(e)
+		Expect(c.ReadinessProbe.PeriodSeconds).To(BeEquivalentTo(2))
+		Expect(c.ReadinessProbe.FailureThreshold).To(BeEquivalentTo(10))
+		Expect(c.LivenessProbe.InitialDelaySeconds).To(BeEquivalentTo(60))
+		Expect(c.LivenessProbe.FailureThreshold).To(BeEquivalentTo(5))
+	})
+
+	It("should probe the default admin port", func() {
+		e.Spec.AdminPort = 0
+		e.Spec.Admin = nil
+		e.SetDefaults()
+		c := EnvoyContainer(e)
+		Expect(c.ReadinessProbe.HTTPGet.Port).To(Equal(intstr.FromInt(19001)))
+		Expect(c.LivenessProbe.HTTPGet.Port).To(Equal(intstr.FromInt(19001)))
+	})
+
+	It("should not probe when disabled", func() {
+		e.Spec.Probes = &api.ProbesSpec{Disabled: true}
+		c := EnvoyContainer(e)
+		Expect(c.ReadinessProbe).To(BeNil())
+		Expect(c.LivenessProbe).To(BeNil())
+	})
+
+})
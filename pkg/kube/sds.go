@@ -0,0 +1,76 @@
+package kube
+
+import (
+	"fmt"
+	"net"
+
+	envoy_config_bootstrap "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v3"
+	envoy_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+)
+
+const sdsServerClusterName = "sds-server"
+
+func sdsServer(e *api.Envoy) *api.SDSServerSpec {
+	if e.Spec.SDS == nil {
+		return nil
+	}
+	return e.Spec.SDS.Server
+}
+
+// sdsNames returns the names of the client certificate and ca secrets envoy asks sds for
+func sdsNames(server *api.SDSServerSpec) (certificate, validationContext string) {
+	certificate, validationContext = server.CertificateName, server.ValidationContextName
+	if certificate == "" {
+		certificate = "default"
+	}
+	if validationContext == "" {
+		validationContext = "ROOTCA"
+	}
+	return certificate, validationContext
+}
+
+// sdsSecretConfig returns the config that fetches the named secret from the sds server
+func sdsSecretConfig(name string) *envoy_tls.SdsSecretConfig {
+	return &envoy_tls.SdsSecretConfig{
+		Name: name,
+		SdsConfig: &envoy_core.ConfigSource{
+			ConfigSourceSpecifier: &envoy_core.ConfigSource_ApiConfigSource{
+				ApiConfigSource: &envoy_core.ApiConfigSource{
+					ApiType: envoy_core.ApiConfigSource_GRPC,
+					GrpcServices: []*envoy_core.GrpcService{{
+						TargetSpecifier: &envoy_core.GrpcService_EnvoyGrpc_{
+							EnvoyGrpc: &envoy_core.GrpcService_EnvoyGrpc{
+								ClusterName: sdsServerClusterName,
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// addSDS adds the cluster of the sds server
+func addSDS(e *api.Envoy, b *envoy_config_bootstrap.Bootstrap) error {
+	if e.Spec.SDS == nil {
+		return nil
+	}
+	server := sdsServer(e)
+	if server == nil {
+		// envoy doesn't see kubernetes swap the files of a mounted secret, so file based sds
+		// wouldn't pick up rotated certs; the pods are rolled instead
+		return fmt.Errorf("sds needs a server")
+	}
+	if server.Address == "" || server.Port == 0 {
+		return fmt.Errorf("the sds server needs an address and a port")
+	}
+	// the private keys are sent in plaintext, so they must not leave the pod
+	if ip := net.ParseIP(server.Address); server.Address != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return fmt.Errorf("the sds server address %s isn't a loopback address", server.Address)
+	}
+	b.StaticResources.Clusters = append(b.StaticResources.Clusters, dnsCluster(sdsServerClusterName, server.Address, server.Port, true))
+	return nil
+}
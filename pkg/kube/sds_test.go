@@ -0,0 +1,93 @@
+package kube_test
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	envoy_cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	. "github.com/solo-io/envoy-operator/pkg/kube"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func upstreamTLSContext(c *envoy_cluster.Cluster) *envoy_tls.UpstreamTlsContext {
+	var tlsContext envoy_tls.UpstreamTlsContext
+	err := ptypes.UnmarshalAny(c.TransportSocket.GetTypedConfig(), &tlsContext)
+	Expect(err).NotTo(HaveOccurred())
+	return &tlsContext
+}
+
+var _ = Describe("SDS", func() {
+	var (
+		e      *api.Envoy
+		secret *v1.Secret
+	)
+
+	BeforeEach(func() {
+		e = testEnvoy()
+		e.Spec.TLSSecretName = "certs"
+		secret = &v1.Secret{Data: map[string][]byte{
+			api.TLSCA:   []byte("ca"),
+			api.TLSCert: []byte("cert"),
+			api.TLSKey:  []byte("key"),
+		}}
+	})
+
+	It("should read the cert files without sds", func() {
+		tlsContext := upstreamTLSContext(clusterNamed(generate(e, secret), "ads-control-plane"))
+		Expect(tlsContext.CommonTlsContext.GetValidationContext().TrustedCa.GetFilename()).To(Equal("/etc/certs/ca.crt"))
+		Expect(tlsContext.CommonTlsContext.TlsCertificates).To(HaveLen(1))
+		Expect(tlsContext.CommonTlsContext.TlsCertificateSdsSecretConfigs).To(BeEmpty())
+	})
+
+	It("should need a server", func() {
+		e.Spec.SDS = &api.SDSSpec{}
+		_, err := GenerateEnvoyConfig(e, secret)
+		Expect(err).To(MatchError(ContainSubstring("sds needs a server")))
+	})
+
+	Context("from a server", func() {
+		BeforeEach(func() {
+			e.Spec.TLSSecretName = ""
+			e.Spec.SDS = &api.SDSSpec{Server: &api.SDSServerSpec{Address: "127.0.0.1", Port: 8234}}
+		})
+
+		It("should fetch the certs from the sds server", func() {
+			b := generate(e, nil)
+			tlsContext := upstreamTLSContext(clusterNamed(b, "ads-control-plane"))
+			validation := tlsContext.CommonTlsContext.GetValidationContextSdsSecretConfig()
+			Expect(validation.Name).To(Equal("ROOTCA"))
+			cluster := validation.SdsConfig.GetApiConfigSource().GrpcServices[0].GetEnvoyGrpc().ClusterName
+			Expect(cluster).To(Equal("sds-server"))
+			Expect(tlsContext.CommonTlsContext.TlsCertificateSdsSecretConfigs[0].Name).To(Equal("default"))
+
+			sds := clusterNamed(b, "sds-server")
+			Expect(sds).NotTo(BeNil())
+			Expect(sds.Http2ProtocolOptions).NotTo(BeNil())
+		})
+
+		It("should need an address", func() {
+			e.Spec.SDS.Server.Address = ""
+			_, err := GenerateEnvoyConfig(e, nil)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should accept localhost", func() {
+			e.Spec.SDS.Server.Address = "localhost"
+			_, err := GenerateEnvoyConfig(e, nil)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should reject a server outside of the pod", func() {
+			for _, address := range []string{"sds.solo.io", "10.0.0.1"} {
+				e.Spec.SDS.Server.Address = address
+				_, err := GenerateEnvoyConfig(e, nil)
+				Expect(err).To(MatchError(ContainSubstring("loopback")))
+			}
+		})
+	})
+})
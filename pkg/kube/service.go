@@ -0,0 +1,224 @@
+package kube
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// The keys of the annotations of the service spec, as of the last time the operator wrote the
+// service, so that the ones removed from the spec are removed from the service. Other
+// annotations of the service, e.g. the ones of cloud controllers, are kept.
+const managedAnnotationsAnnotation = "envoy.solo.io/managed-annotations"
+
+// ServicePortsForEnvoy returns the ports of the envoy's service: the ports of the service spec,
+// followed by the servicePorts sorted by name
+func ServicePortsForEnvoy(e *api.Envoy) []v1.ServicePort {
+	var ports []v1.ServicePort
+	if e.Spec.Service != nil {
+		for _, p := range e.Spec.Service.Ports {
+			ports = append(ports, v1.ServicePort{
+				Name:       p.Name,
+				Port:       p.Port,
+				TargetPort: p.TargetPort,
+				NodePort:   p.NodePort,
+				Protocol:   p.Protocol,
+			})
+		}
+	}
+
+	names := make([]string, 0, len(e.Spec.ServicePorts))
+	for name := range e.Spec.ServicePorts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ports = append(ports, v1.ServicePort{
+			Name: name,
+			Port: e.Spec.ServicePorts[name],
+		})
+	}
+	return ports
+}
+
+// ServiceForEnvoy returns the desired service for the envoy, or nil if it has no ports to expose
+func ServiceForEnvoy(e *api.Envoy) (*v1.Service, error) {
+	ports := ServicePortsForEnvoy(e)
+	if len(ports) == 0 {
+		return nil, nil
+	}
+	var spec api.ServiceSpec
+	if e.Spec.Service != nil {
+		spec = *e.Spec.Service
+	}
+
+	annotations := map[string]string{}
+	keys := make([]string, 0, len(spec.Annotations))
+	for k, v := range spec.Annotations {
+		annotations[k] = v
+		keys = append(keys, k)
+	}
+	if len(keys) != 0 {
+		sort.Strings(keys)
+		annotations[managedAnnotationsAnnotation] = strings.Join(keys, ",")
+	}
+
+	s := &v1.Service{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Service",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        e.GetName(),
+			Namespace:   e.GetNamespace(),
+			Labels:      LabelsForEnvoy(e),
+			Annotations: annotations,
+		},
+		Spec: v1.ServiceSpec{
+			Selector:                 LabelsForEnvoy(e),
+			Type:                     spec.Type,
+			Ports:                    ports,
+			ExternalTrafficPolicy:    spec.ExternalTrafficPolicy,
+			LoadBalancerSourceRanges: spec.LoadBalancerSourceRanges,
+		},
+	}
+	if spec.Headless {
+		if s.Spec.Type == "" {
+			s.Spec.Type = v1.ServiceTypeClusterIP
+		}
+		if s.Spec.Type != v1.ServiceTypeClusterIP {
+			return nil, fmt.Errorf("only ClusterIP services can be headless, not %s", s.Spec.Type)
+		}
+		s.Spec.ClusterIP = v1.ClusterIPNone
+	}
+	if s.Spec.Type == "" {
+		s.Spec.Type = v1.ServiceTypeLoadBalancer
+	}
+	if err := setSpecHash(s, managedServiceFields(s)); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// managedService holds the parts of a service that the operator owns
+type managedService struct {
+	Labels                   map[string]string                   `json:"labels,omitempty"`
+	Annotations              map[string]string                   `json:"annotations,omitempty"`
+	Selector                 map[string]string                   `json:"selector,omitempty"`
+	Type                     v1.ServiceType                      `json:"type,omitempty"`
+	Headless                 bool                                `json:"headless,omitempty"`
+	Ports                    []v1.ServicePort                    `json:"ports"`
+	ExternalTrafficPolicy    v1.ServiceExternalTrafficPolicyType `json:"externalTrafficPolicy,omitempty"`
+	LoadBalancerSourceRanges []string                            `json:"loadBalancerSourceRanges,omitempty"`
+}
+
+func managedServiceFields(s *v1.Service) managedService {
+	annotations := map[string]string{}
+	for k, v := range s.Annotations {
+		if k != specHashAnnotation {
+			annotations[k] = v
+		}
+	}
+	// compare the fields kube defaults as defaulted, so that changing them back to their
+	// default is noticed
+	ports := make([]v1.ServicePort, len(s.Spec.Ports))
+	for i, p := range s.Spec.Ports {
+		if p.TargetPort == (intstr.IntOrString{}) {
+			p.TargetPort = intstr.FromInt(int(p.Port))
+		}
+		if p.Protocol == "" {
+			p.Protocol = v1.ProtocolTCP
+		}
+		ports[i] = p
+	}
+	trafficPolicy := s.Spec.ExternalTrafficPolicy
+	if trafficPolicy == "" && hasNodePorts(s) {
+		trafficPolicy = v1.ServiceExternalTrafficPolicyTypeCluster
+	}
+	return managedService{
+		Labels:                   s.Labels,
+		Annotations:              annotations,
+		Selector:                 s.Spec.Selector,
+		Type:                     s.Spec.Type,
+		Headless:                 s.Spec.ClusterIP == v1.ClusterIPNone,
+		Ports:                    ports,
+		ExternalTrafficPolicy:    trafficPolicy,
+		LoadBalancerSourceRanges: s.Spec.LoadBalancerSourceRanges,
+	}
+}
+
+func hasNodePorts(s *v1.Service) bool {
+	return s.Spec.Type == v1.ServiceTypeNodePort || s.Spec.Type == v1.ServiceTypeLoadBalancer
+}
+
+// ServiceNeedsUpdate returns true if the live service differs from the desired one in any of the
+// fields the operator manages. Fields that are not set in the desired service, like the node
+// ports, are allocated or defaulted by kube, and are ignored.
+func ServiceNeedsUpdate(desired, live *v1.Service) (bool, error) {
+	if specHashDiffers(desired, live) {
+		return true, nil
+	}
+	if ServiceNeedsRecreate(desired, live) {
+		return true, nil
+	}
+	return differs(managedServiceFields(desired), managedServiceFields(live))
+}
+
+// ServiceNeedsRecreate returns true if the live service can't be updated to the desired one, as
+// the cluster ip of a service is immutable
+func ServiceNeedsRecreate(desired, live *v1.Service) bool {
+	return (desired.Spec.ClusterIP == v1.ClusterIPNone) != (live.Spec.ClusterIP == v1.ClusterIPNone)
+}
+
+// UpdateService copies the fields the operator manages from the desired service to the live one.
+// Node ports kube allocated are kept, unless the desired service has none, and annotations
+// removed from the spec are removed.
+func UpdateService(desired, live *v1.Service) {
+	if live.Labels == nil {
+		live.Labels = map[string]string{}
+	}
+	for k, v := range desired.Labels {
+		live.Labels[k] = v
+	}
+	if live.Annotations == nil {
+		live.Annotations = map[string]string{}
+	}
+	if managed := live.Annotations[managedAnnotationsAnnotation]; managed != "" {
+		for _, k := range strings.Split(managed, ",") {
+			if _, ok := desired.Annotations[k]; !ok {
+				delete(live.Annotations, k)
+			}
+		}
+		delete(live.Annotations, managedAnnotationsAnnotation)
+	}
+	for k, v := range desired.Annotations {
+		live.Annotations[k] = v
+	}
+
+	liveNodePorts := map[string]int32{}
+	for _, p := range live.Spec.Ports {
+		liveNodePorts[p.Name] = p.NodePort
+	}
+	ports := make([]v1.ServicePort, len(desired.Spec.Ports))
+	for i, p := range desired.Spec.Ports {
+		if p.NodePort == 0 && hasNodePorts(desired) {
+			p.NodePort = liveNodePorts[p.Name]
+		}
+		if p.TargetPort == (intstr.IntOrString{}) {
+			p.TargetPort = intstr.FromInt(int(p.Port))
+		}
+		ports[i] = p
+	}
+
+	live.Spec.Selector = desired.Spec.Selector
+	live.Spec.Type = desired.Spec.Type
+	live.Spec.Ports = ports
+	live.Spec.ExternalTrafficPolicy = desired.Spec.ExternalTrafficPolicy
+	live.Spec.LoadBalancerSourceRanges = desired.Spec.LoadBalancerSourceRanges
+}
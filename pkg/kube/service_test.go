@@ -0,0 +1,211 @@
+package kube_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	. "github.com/solo-io/envoy-operator/pkg/kube"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// simulate what the api server does to a service we create
+func applyServiceDefaults(s *v1.Service) *v1.Service {
+	s = s.DeepCopy()
+	if s.Spec.ClusterIP == "" {
+		s.Spec.ClusterIP = "10.0.0.10"
+	}
+	s.Spec.SessionAffinity = v1.ServiceAffinityNone
+	hasNodePorts := s.Spec.Type == v1.ServiceTypeNodePort || s.Spec.Type == v1.ServiceTypeLoadBalancer
+	if hasNodePorts && s.Spec.ExternalTrafficPolicy == "" {
+		s.Spec.ExternalTrafficPolicy = v1.ServiceExternalTrafficPolicyTypeCluster
+	}
+	for i := range s.Spec.Ports {
+		p := &s.Spec.Ports[i]
+		if p.Protocol == "" {
+			p.Protocol = v1.ProtocolTCP
+		}
+		if p.TargetPort == (intstr.IntOrString{}) {
+			p.TargetPort = intstr.FromInt(int(p.Port))
+		}
+		if hasNodePorts && p.NodePort == 0 {
+			p.NodePort = 30000 + int32(i)
+		}
+	}
+	return s
+}
+
+var _ = Describe("Service", func() {
+	var (
+		e    *api.Envoy
+		live *v1.Service
+	)
+
+	desired := func() *v1.Service {
+		s, err := ServiceForEnvoy(e)
+		Expect(err).NotTo(HaveOccurred())
+		return s
+	}
+	needsUpdate := func() bool {
+		res, err := ServiceNeedsUpdate(desired(), live)
+		Expect(err).NotTo(HaveOccurred())
+		return res
+	}
+
+	BeforeEach(func() {
+		e = testEnvoy()
+		e.Spec.ServicePorts = map[string]int32{"https": 443, "http": 80}
+		live = applyServiceDefaults(desired())
+	})
+
+	It("should not create a service without ports", func() {
+		e.Spec.ServicePorts = nil
+		Expect(desired()).To(BeNil())
+	})
+
+	It("should default to a load balancer", func() {
+		s := desired()
+		Expect(s.Spec.Type).To(Equal(v1.ServiceTypeLoadBalancer))
+		Expect(s.Spec.Ports).To(HaveLen(2))
+		Expect(s.Spec.Ports[0].Name).To(Equal("http"))
+		Expect(s.Spec.Ports[1].Name).To(Equal("https"))
+	})
+
+	It("should configure the service", func() {
+		e.Spec.Service = &api.ServiceSpec{
+			Type:                     v1.ServiceTypeNodePort,
+			Annotations:              map[string]string{"service.beta.kubernetes.io/aws-load-balancer-type": "nlb"},
+			ExternalTrafficPolicy:    v1.ServiceExternalTrafficPolicyTypeLocal,
+			LoadBalancerSourceRanges: []string{"10.0.0.0/8"},
+			Ports: []api.ServicePortSpec{{
+				Name:       "dns",
+				Port:       53,
+				TargetPort: intstr.FromInt(5353),
+				NodePort:   30053,
+				Protocol:   v1.ProtocolUDP,
+			}},
+		}
+		s := desired()
+		Expect(s.Spec.Type).To(Equal(v1.ServiceTypeNodePort))
+		Expect(s.Annotations).To(HaveKeyWithValue("service.beta.kubernetes.io/aws-load-balancer-type", "nlb"))
+		Expect(s.Spec.ExternalTrafficPolicy).To(Equal(v1.ServiceExternalTrafficPolicyTypeLocal))
+		Expect(s.Spec.LoadBalancerSourceRanges).To(ConsistOf("10.0.0.0/8"))
+		Expect(s.Spec.Ports).To(HaveLen(3))
+		Expect(s.Spec.Ports[0]).To(Equal(v1.ServicePort{
+			Name:       "dns",
+			Port:       53,
+			TargetPort: intstr.FromInt(5353),
+			NodePort:   30053,
+			Protocol:   v1.ProtocolUDP,
+		}))
+	})
+
+	It("should create headless services", func() {
+		e.Spec.Service = &api.ServiceSpec{Type: v1.ServiceTypeClusterIP, Headless: true}
+		Expect(desired().Spec.ClusterIP).To(Equal(v1.ClusterIPNone))
+		Expect(needsUpdate()).To(BeTrue())
+		Expect(ServiceNeedsRecreate(desired(), live)).To(BeTrue())
+	})
+
+	It("should default headless services to ClusterIP", func() {
+		e.Spec.Service = &api.ServiceSpec{Headless: true}
+		s := desired()
+		Expect(s.Spec.Type).To(Equal(v1.ServiceTypeClusterIP))
+		Expect(s.Spec.ClusterIP).To(Equal(v1.ClusterIPNone))
+	})
+
+	It("should only make ClusterIP services headless", func() {
+		e.Spec.Service = &api.ServiceSpec{Type: v1.ServiceTypeLoadBalancer, Headless: true}
+		_, err := ServiceForEnvoy(e)
+		Expect(err).To(MatchError(ContainSubstring("only ClusterIP services can be headless")))
+	})
+
+	It("should not need an update when only defaulted fields differ", func() {
+		Expect(needsUpdate()).To(BeFalse())
+	})
+
+	It("should not need an update when the live service has extra annotations", func() {
+		live.Annotations["cloud.example.com/id"] = "lb-1"
+		Expect(needsUpdate()).To(BeFalse())
+	})
+
+	It("should detect a port change", func() {
+		e.Spec.ServicePorts["http"] = 8080
+		Expect(needsUpdate()).To(BeTrue())
+	})
+
+	It("should detect a protocol change", func() {
+		live.Spec.Ports[0].Protocol = v1.ProtocolUDP
+		Expect(needsUpdate()).To(BeTrue())
+	})
+
+	It("should detect a type change", func() {
+		live.Spec.Type = v1.ServiceTypeClusterIP
+		Expect(needsUpdate()).To(BeTrue())
+	})
+
+	It("should detect an annotation change", func() {
+		e.Spec.Service = &api.ServiceSpec{Annotations: map[string]string{"a": "b"}}
+		Expect(needsUpdate()).To(BeTrue())
+	})
+
+	It("should detect a traffic policy change", func() {
+		live.Spec.ExternalTrafficPolicy = v1.ServiceExternalTrafficPolicyTypeLocal
+		Expect(needsUpdate()).To(BeTrue())
+	})
+
+	It("should detect a source ranges change", func() {
+		e.Spec.Service = &api.ServiceSpec{LoadBalancerSourceRanges: []string{"10.0.0.0/8"}}
+		Expect(needsUpdate()).To(BeTrue())
+	})
+
+	It("should detect a node port change", func() {
+		e.Spec.Service = &api.ServiceSpec{Ports: []api.ServicePortSpec{{Name: "admin", Port: 19000, NodePort: 30190}}}
+		Expect(needsUpdate()).To(BeTrue())
+	})
+
+	It("should keep the allocated node ports when updating", func() {
+		e.Spec.Service = &api.ServiceSpec{Annotations: map[string]string{"a": "b"}}
+		UpdateService(desired(), live)
+		Expect(live.Spec.Ports[0].NodePort).To(BeEquivalentTo(30000))
+		Expect(live.Spec.ClusterIP).To(Equal("10.0.0.10"))
+		Expect(needsUpdate()).To(BeFalse())
+	})
+
+	It("should remove the annotations removed from the spec", func() {
+		e.Spec.Service = &api.ServiceSpec{Annotations: map[string]string{"a": "b", "c": "d"}}
+		live = applyServiceDefaults(desired())
+		live.Annotations["cloud.example.com/id"] = "lb-1"
+
+		e.Spec.Service.Annotations = map[string]string{"a": "b"}
+		Expect(needsUpdate()).To(BeTrue())
+		UpdateService(desired(), live)
+		Expect(live.Annotations).To(HaveKeyWithValue("a", "b"))
+		Expect(live.Annotations).NotTo(HaveKey("c"))
+		Expect(live.Annotations).To(HaveKeyWithValue("cloud.example.com/id", "lb-1"))
+		Expect(needsUpdate()).To(BeFalse())
+
+		e.Spec.Service.Annotations = nil
+		Expect(needsUpdate()).To(BeTrue())
+		UpdateService(desired(), live)
+		Expect(live.Annotations).NotTo(HaveKey("a"))
+		Expect(live.Annotations).To(HaveKeyWithValue("cloud.example.com/id", "lb-1"))
+		Expect(needsUpdate()).To(BeFalse())
+	})
+
+	It("should not write the spec hash into the envoy spec", func() {
+		e.Spec.Service = &api.ServiceSpec{Annotations: map[string]string{"a": "b"}}
+		desired()
+		Expect(e.Spec.Service.Annotations).To(Equal(map[string]string{"a": "b"}))
+	})
+
+	It("should drop the node ports of cluster ip services", func() {
+		e.Spec.Service = &api.ServiceSpec{Type: v1.ServiceTypeClusterIP}
+		UpdateService(desired(), live)
+		Expect(live.Spec.Ports[0].NodePort).To(BeZero())
+		Expect(live.Spec.ExternalTrafficPolicy).To(BeEmpty())
+		Expect(needsUpdate()).To(BeFalse())
+	})
+})
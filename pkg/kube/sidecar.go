@@ -0,0 +1,88 @@
+package kube
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// Injected pods may live in a different namespace than the envoy's config map, so the
+	// bootstrap config is carried in a pod annotation and projected with the downward api.
+	BootstrapAnnotation = "envoy.solo.io/bootstrap"
+	// Set on pods that had the envoy sidecar injected
+	InjectedAnnotation = "envoy.solo.io/injected"
+)
+
+// SidecarForEnvoy returns the containers and volumes to inject into a pod of the namespace to
+// run the envoy as a sidecar, and the annotations the pod needs for them to work
+func SidecarForEnvoy(e *api.Envoy, bootstrap, namespace string) (v1.PodSpec, map[string]string, error) {
+	spec, err := envoyPodSpec(e, v1.VolumeSource{
+		DownwardAPI: &v1.DownwardAPIVolumeSource{
+			Items: []v1.DownwardAPIVolumeFile{{
+				Path: filepath.Base(envoySourceConfigFilePath),
+				FieldRef: &v1.ObjectFieldSelector{
+					FieldPath: fmt.Sprintf("metadata.annotations['%s']", BootstrapAnnotation),
+				},
+			}},
+		},
+	})
+	if err != nil {
+		return v1.PodSpec{}, nil, err
+	}
+	// secrets are mounted from the pod's namespace, and the operator only checks the ones of the
+	// envoy's namespace
+	if secrets := secretsOf(spec); namespace != e.Namespace && len(secrets) != 0 {
+		return v1.PodSpec{}, nil, fmt.Errorf("pods in namespace %s can't use the secrets %s of the envoy in namespace %s",
+			namespace, strings.Join(secrets, ", "), e.Namespace)
+	}
+	if ic := interceptionFor(e); ic != nil {
+		// the iptables rules need to be in place before any other container starts; the webhook
+		// injects the init containers ahead of the pod's own
+		spec.InitContainers = append([]v1.Container{InterceptionInitContainer(e)}, spec.InitContainers...)
+		proxyUID := int64(ProxyUID)
+		for i := range spec.Containers {
+			spec.Containers[i].SecurityContext = &v1.SecurityContext{RunAsUser: &proxyUID}
+			if ic.Mode == api.InterceptionModeTProxy {
+				// binding the transparent inbound listener needs NET_ADMIN
+				spec.Containers[i].SecurityContext.Capabilities = &v1.Capabilities{
+					Add: []v1.Capability{"NET_ADMIN"},
+				}
+			}
+		}
+		runSPIFFEAsEnvoy(&spec)
+	}
+	annotations := map[string]string{
+		BootstrapAnnotation: bootstrap,
+		InjectedAnnotation:  e.Name,
+	}
+	return spec, annotations, nil
+}
+
+// secretsOf returns the names of the secrets the pod mounts or reads env vars from
+func secretsOf(spec v1.PodSpec) []string {
+	names := map[string]bool{}
+	for _, v := range spec.Volumes {
+		if v.Secret != nil {
+			names[v.Secret.SecretName] = true
+		}
+	}
+	for _, c := range append(spec.InitContainers, spec.Containers...) {
+		for _, env := range c.Env {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+				names[env.ValueFrom.SecretKeyRef.Name] = true
+			}
+		}
+	}
+	var secrets []string
+	for name := range names {
+		secrets = append(secrets, name)
+	}
+	sort.Strings(secrets)
+	return secrets
+}
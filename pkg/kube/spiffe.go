@@ -0,0 +1,134 @@
+package kube
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	"github.com/solo-io/envoy-operator/pkg/spiffe"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// The spiffe init container writes the pod's certificate next to the bootstrap config
+const (
+	spiffeIdentityPath  = "/etc/envoy/identity/"
+	spiffeContainerName = "envoy-spiffe"
+
+	// The certificates aren't renewed, so they have to outlive most pods
+	minSPIFFEDuration = 24 * time.Hour
+)
+
+func spiffeFor(e *api.Envoy) *api.SPIFFESpec {
+	if e.Spec.TLS == nil {
+		return nil
+	}
+	return e.Spec.TLS.SPIFFE
+}
+
+// validateSPIFFE returns an error if the pods can't get their own certificates
+func validateSPIFFE(e *api.Envoy) error {
+	spec := spiffeFor(e)
+	if spec == nil {
+		return nil
+	}
+	if (spec.SignerName == "") == (spec.CAEndpoint == "") {
+		return fmt.Errorf("spiffe needs either a signerName or a caEndpoint")
+	}
+	if certIssuerRef(e) != nil {
+		return fmt.Errorf("set either the tls issuerRef or spiffe")
+	}
+	if sdsServer(e) != nil {
+		return fmt.Errorf("the sds server delivers the client certificate, so spiffe can't be used with it")
+	}
+	if e.Spec.TLSSecretName == "" {
+		return fmt.Errorf("spiffe needs a tls_secret_name with the ca.crt of the control plane")
+	}
+	if spec.Duration != nil && spec.Duration.Duration < minSPIFFEDuration {
+		return fmt.Errorf("the spiffe duration %s is below %s; the certificates aren't renewed", spec.Duration.Duration, minSPIFFEDuration)
+	}
+	return nil
+}
+
+// clientCertFiles returns the files of the client certificate and key envoy presents to the
+// control plane, or "" if it has none
+func clientCertFiles(e *api.Envoy, tlsSecret *v1.Secret) (cert, key string) {
+	if spiffeFor(e) != nil {
+		return filepath.Join(spiffeIdentityPath, spiffe.CertFile), filepath.Join(spiffeIdentityPath, spiffe.KeyFile)
+	}
+	if tlsSecret == nil || !hasKey(tlsSecret) {
+		return "", ""
+	}
+	return tlsFile(certKey(tlsSecret)), tlsFile(api.TLSKey)
+}
+
+// SPIFFEInitContainer returns the init container that requests the pod's own certificate, or nil
+// if the pods share the certificate of the tls secret
+func SPIFFEInitContainer(e *api.Envoy) *v1.Container {
+	spec := spiffeFor(e)
+	if spec == nil {
+		return nil
+	}
+	vmounts := []v1.VolumeMount{{
+		Name:      envoyConfigTmpVolName,
+		MountPath: filepath.Dir(envoyConfigTmpPath),
+	}}
+	args := []string{"spiffe", "-out-dir", spiffeIdentityPath}
+	if spec.TrustDomain != "" {
+		args = append(args, "-trust-domain", spec.TrustDomain)
+	}
+	if spec.SignerName != "" {
+		args = append(args, "-signer-name", spec.SignerName)
+	}
+	if spec.CAEndpoint != "" {
+		// the ca endpoint is trusted with the ca of the control plane
+		args = append(args, "-ca-endpoint", spec.CAEndpoint, "-ca-file", tlsFile(api.TLSCA))
+		vmounts = append(vmounts, v1.VolumeMount{
+			Name:      envoyTLSVolName,
+			MountPath: filepath.Dir(api.EnvoyTLSVolPath),
+		})
+	}
+	if spec.Duration != nil {
+		args = append(args, "-duration", spec.Duration.Duration.String())
+	}
+	return &v1.Container{
+		Name:  spiffeContainerName,
+		Image: initContainerImage,
+		Args:  args,
+		Env: []v1.EnvVar{
+			addEnv("POD_NAME", "metadata.name"),
+			addEnv("POD_NAMESPACE", "metadata.namespace"),
+			addEnv("POD_SVCACCNT", "spec.serviceAccountName"),
+			addEnv("POD_UID", "metadata.uid"),
+		},
+		VolumeMounts: vmounts,
+	}
+}
+
+// runSPIFFEAsEnvoy runs the spiffe init container as the user of the envoy container, as the key
+// it writes is only readable by its owner. A user set on the init container itself is kept.
+func runSPIFFEAsEnvoy(spec *v1.PodSpec) {
+	var user *int64
+	for _, c := range spec.Containers {
+		if c.Name == envoyContainerName && c.SecurityContext != nil {
+			user = c.SecurityContext.RunAsUser
+		}
+	}
+	if user == nil {
+		return
+	}
+	for i := range spec.InitContainers {
+		c := &spec.InitContainers[i]
+		if c.Name != spiffeContainerName {
+			continue
+		}
+		if c.SecurityContext == nil {
+			c.SecurityContext = &v1.SecurityContext{}
+		}
+		if c.SecurityContext.RunAsUser == nil {
+			runAsUser := *user
+			c.SecurityContext.RunAsUser = &runAsUser
+		}
+	}
+}
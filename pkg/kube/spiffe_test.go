@@ -0,0 +1,153 @@
+package kube_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	. "github.com/solo-io/envoy-operator/pkg/kube"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("SPIFFE", func() {
+	var (
+		e      *api.Envoy
+		secret *v1.Secret
+	)
+
+	BeforeEach(func() {
+		e = testEnvoy()
+		e.Spec.TLSSecretName = "certs"
+		e.Spec.TLS = &api.TLSSpec{
+			SPIFFE: &api.SPIFFESpec{SignerName: "example.org/spiffe"},
+		}
+		secret = &v1.Secret{Data: map[string][]byte{
+			api.TLSCA: []byte("ca"),
+		}}
+	})
+
+	It("should present the pod's own certificate", func() {
+		tlsContext := upstreamTLSContext(clusterNamed(generate(e, secret), "ads-control-plane"))
+		Expect(tlsContext.CommonTlsContext.GetValidationContext().TrustedCa.GetFilename()).To(Equal("/etc/certs/ca.crt"))
+		certs := tlsContext.CommonTlsContext.TlsCertificates
+		Expect(certs).To(HaveLen(1))
+		Expect(certs[0].CertificateChain.GetFilename()).To(Equal("/etc/envoy/identity/tls.crt"))
+		Expect(certs[0].PrivateKey.GetFilename()).To(Equal("/etc/envoy/identity/tls.key"))
+	})
+
+	It("should have an init container request the certificate", func() {
+		e.Spec.TLS.SPIFFE.TrustDomain = "example.org"
+		e.Spec.TLS.SPIFFE.Duration = &metav1.Duration{Duration: 48 * time.Hour}
+		template, err := PodTemplateForEnvoy(e, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(template.Spec.InitContainers).To(HaveLen(2))
+		c := template.Spec.InitContainers[1]
+		Expect(c.Args).To(Equal([]string{
+			"spiffe", "-out-dir", "/etc/envoy/identity/",
+			"-trust-domain", "example.org",
+			"-signer-name", "example.org/spiffe",
+			"-duration", "48h0m0s",
+		}))
+		var envNames []string
+		for _, env := range c.Env {
+			envNames = append(envNames, env.Name)
+		}
+		Expect(envNames).To(ConsistOf("POD_NAME", "POD_NAMESPACE", "POD_SVCACCNT", "POD_UID"))
+		Expect(c.VolumeMounts).To(HaveLen(1))
+	})
+
+	It("should trust the ca endpoint with the ca of the tls secret", func() {
+		e.Spec.TLS.SPIFFE = &api.SPIFFESpec{CAEndpoint: "https://ca.example.org/sign"}
+		c := SPIFFEInitContainer(e)
+		Expect(c.Args).To(ContainElement("https://ca.example.org/sign"))
+		Expect(c.Args).To(ContainElement("/etc/certs/ca.crt"))
+		Expect(c.VolumeMounts).To(HaveLen(2))
+	})
+
+	It("should request the certificate as the envoy's user", func() {
+		e.Spec.Injection = &api.InjectionSpec{Interception: &api.InterceptionSpec{}}
+		e.SetDefaults()
+		spec, _, err := SidecarForEnvoy(e, "{}", e.Namespace)
+		Expect(err).NotTo(HaveOccurred())
+		var spiffe *v1.Container
+		for i, c := range spec.InitContainers {
+			if c.Name == "envoy-spiffe" {
+				spiffe = &spec.InitContainers[i]
+			}
+		}
+		Expect(spiffe).NotTo(BeNil())
+		Expect(*spiffe.SecurityContext.RunAsUser).To(Equal(*spec.Containers[0].SecurityContext.RunAsUser))
+	})
+
+	It("should request the certificate as the user of the envoy container", func() {
+		user := int64(1000)
+		e.Spec.Deployment = &api.EnvoyDeploymentSpec{
+			Replicas: 1,
+			PodTemplate: &v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{{
+						Name:            "envoy",
+						SecurityContext: &v1.SecurityContext{RunAsUser: &user},
+					}},
+				},
+			},
+		}
+		d, err := DeploymentForEnvoy(e, nil)
+		Expect(err).NotTo(HaveOccurred())
+		spiffe := d.Spec.Template.Spec.InitContainers[1]
+		Expect(spiffe.Name).To(Equal("envoy-spiffe"))
+		Expect(*spiffe.SecurityContext.RunAsUser).To(Equal(user))
+	})
+
+	It("should turn unready once the certificate expires", func() {
+		template, err := PodTemplateForEnvoy(e, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(template.Spec.InitContainers[0].Args).To(ContainElement("/etc/envoy/envoy-operator-init"))
+		readiness := template.Spec.Containers[0].ReadinessProbe
+		Expect(readiness.HTTPGet).To(BeNil())
+		Expect(readiness.Exec.Command).To(Equal([]string{
+			"/etc/envoy/envoy-operator-init", "ready",
+			"-cert", "/etc/envoy/identity/tls.crt",
+			"-url", "http://127.0.0.1:19001/ready",
+		}))
+	})
+
+	It("should refuse certificates that expire too soon", func() {
+		e.Spec.TLS.SPIFFE.Duration = &metav1.Duration{Duration: time.Hour}
+		_, err := GenerateEnvoyConfig(e, secret)
+		Expect(err).To(MatchError(ContainSubstring("spiffe duration 1h0m0s")))
+	})
+
+	It("should need a single signer", func() {
+		e.Spec.TLS.SPIFFE.CAEndpoint = "https://ca.example.org/sign"
+		_, err := GenerateEnvoyConfig(e, secret)
+		Expect(err).To(MatchError(ContainSubstring("either a signerName or a caEndpoint")))
+		e.Spec.TLS.SPIFFE = &api.SPIFFESpec{}
+		_, err = GenerateEnvoyConfig(e, secret)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should need the ca of a tls secret", func() {
+		e.Spec.TLSSecretName = ""
+		_, err := GenerateEnvoyConfig(e, nil)
+		Expect(err).To(MatchError(ContainSubstring("tls_secret_name")))
+	})
+
+	It("should not be used with an sds server", func() {
+		e.Spec.SDS = &api.SDSSpec{Server: &api.SDSServerSpec{Address: "127.0.0.1", Port: 8234}}
+		_, err := GenerateEnvoyConfig(e, secret)
+		Expect(err).To(MatchError(ContainSubstring("sds server")))
+	})
+
+	It("should not run without spiffe", func() {
+		e.Spec.TLS = nil
+		Expect(SPIFFEInitContainer(e)).To(BeNil())
+		template, err := PodTemplateForEnvoy(e, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(template.Spec.InitContainers).To(HaveLen(1))
+	})
+})
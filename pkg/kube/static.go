@@ -0,0 +1,223 @@
+package kube
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/duration"
+
+	envoy_cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	envoy_listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	envoy_route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoy_router "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/router/v3"
+	envoy_hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	envoy_tcp_proxy "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+)
+
+const defaultClusterDomain = "cluster.local"
+
+// staticResources returns the listeners and clusters of the envoy's static resources
+func staticResources(e *api.Envoy) ([]*envoy_listener.Listener, []*envoy_cluster.Cluster, error) {
+	spec := e.Spec.StaticResources
+	if spec == nil {
+		return nil, nil, nil
+	}
+
+	clusterNames := map[string]bool{}
+	var clusters []*envoy_cluster.Cluster
+	for _, c := range spec.Clusters {
+		clusterNames[c.Name] = true
+		cluster, err := staticCluster(e, c)
+		if err != nil {
+			return nil, nil, err
+		}
+		clusters = append(clusters, cluster)
+	}
+
+	tracing, err := hcmTracing(e)
+	if err != nil {
+		return nil, nil, err
+	}
+	var listeners []*envoy_listener.Listener
+	for _, l := range spec.Listeners {
+		listener, err := staticListener(l, clusterNames, tracing)
+		if err != nil {
+			return nil, nil, err
+		}
+		listeners = append(listeners, listener)
+	}
+	return listeners, clusters, nil
+}
+
+func staticCluster(e *api.Envoy, c api.StaticCluster) (*envoy_cluster.Cluster, error) {
+	if c.Name == "" || c.Service == "" || c.Port == 0 {
+		return nil, fmt.Errorf("static cluster %q needs a name, a service and a port", c.Name)
+	}
+	namespace := c.Namespace
+	if namespace == "" {
+		namespace = e.Namespace
+	}
+	domain := e.Spec.StaticResources.ClusterDomain
+	if domain == "" {
+		domain = defaultClusterDomain
+	}
+	host := fmt.Sprintf("%s.%s.svc.%s", c.Service, namespace, domain)
+
+	cluster := &envoy_cluster.Cluster{
+		Name:                 c.Name,
+		ClusterDiscoveryType: &envoy_cluster.Cluster_Type{Type: envoy_cluster.Cluster_STRICT_DNS},
+		ConnectTimeout:       &duration.Duration{Seconds: 5},
+		LoadAssignment: &envoy_endpoint.ClusterLoadAssignment{
+			ClusterName: c.Name,
+			Endpoints: []*envoy_endpoint.LocalityLbEndpoints{{
+				LbEndpoints: []*envoy_endpoint.LbEndpoint{{
+					HostIdentifier: &envoy_endpoint.LbEndpoint_Endpoint{
+						Endpoint: &envoy_endpoint.Endpoint{
+							Address: socketAddress(host, c.Port),
+						},
+					},
+				}},
+			}},
+		},
+	}
+	if c.ConnectTimeout != nil {
+		cluster.ConnectTimeout = ptypes.DurationProto(c.ConnectTimeout.Duration)
+	}
+	if c.HTTP2 {
+		cluster.Http2ProtocolOptions = &envoy_core.Http2ProtocolOptions{}
+	}
+	return cluster, nil
+}
+
+func staticListener(l api.StaticListener, clusterNames map[string]bool, tracing *envoy_hcm.HttpConnectionManager_Tracing) (*envoy_listener.Listener, error) {
+	if l.Name == "" || l.Port == 0 {
+		return nil, fmt.Errorf("static listener %q needs a name and a port", l.Name)
+	}
+	address := l.Address
+	if address == "" {
+		address = "0.0.0.0"
+	}
+
+	var filter *envoy_listener.Filter
+	var err error
+	switch {
+	case len(l.VirtualHosts) != 0 && l.Cluster != "":
+		return nil, fmt.Errorf("static listener %q can't have both virtual hosts and a cluster", l.Name)
+	case l.Cluster != "":
+		if !clusterNames[l.Cluster] {
+			return nil, fmt.Errorf("static listener %q forwards to unknown cluster %q", l.Name, l.Cluster)
+		}
+		filter, err = tcpProxyFilter(l.Name, l.Cluster)
+	default:
+		filter, err = httpConnectionManagerFilter(l, clusterNames, tracing)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &envoy_listener.Listener{
+		Name:    l.Name,
+		Address: socketAddress(address, l.Port),
+		FilterChains: []*envoy_listener.FilterChain{{
+			Filters: []*envoy_listener.Filter{filter},
+		}},
+	}, nil
+}
+
+func tcpProxyFilter(statPrefix, cluster string) (*envoy_listener.Filter, error) {
+	tcpProxy, err := ptypes.MarshalAny(&envoy_tcp_proxy.TcpProxy{
+		StatPrefix:       statPrefix,
+		ClusterSpecifier: &envoy_tcp_proxy.TcpProxy_Cluster{Cluster: cluster},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &envoy_listener.Filter{
+		Name:       wellknown.TCPProxy,
+		ConfigType: &envoy_listener.Filter_TypedConfig{TypedConfig: tcpProxy},
+	}, nil
+}
+
+func httpConnectionManagerFilter(l api.StaticListener, clusterNames map[string]bool, tracing *envoy_hcm.HttpConnectionManager_Tracing) (*envoy_listener.Filter, error) {
+	routeConfig := &envoy_route.RouteConfiguration{Name: l.Name}
+	for _, vh := range l.VirtualHosts {
+		virtualHost, err := staticVirtualHost(l.Name, vh, clusterNames)
+		if err != nil {
+			return nil, err
+		}
+		routeConfig.VirtualHosts = append(routeConfig.VirtualHosts, virtualHost)
+	}
+	return httpConnectionManager(l.Name, routeConfig, tracing)
+}
+
+// httpConnectionManager returns a filter routing http requests with the route config
+func httpConnectionManager(statPrefix string, routeConfig *envoy_route.RouteConfiguration, tracing *envoy_hcm.HttpConnectionManager_Tracing) (*envoy_listener.Filter, error) {
+	router, err := ptypes.MarshalAny(&envoy_router.Router{})
+	if err != nil {
+		return nil, err
+	}
+	hcm, err := ptypes.MarshalAny(&envoy_hcm.HttpConnectionManager{
+		StatPrefix:     statPrefix,
+		RouteSpecifier: &envoy_hcm.HttpConnectionManager_RouteConfig{RouteConfig: routeConfig},
+		HttpFilters: []*envoy_hcm.HttpFilter{{
+			Name:       wellknown.Router,
+			ConfigType: &envoy_hcm.HttpFilter_TypedConfig{TypedConfig: router},
+		}},
+		Tracing: tracing,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &envoy_listener.Filter{
+		Name:       wellknown.HTTPConnectionManager,
+		ConfigType: &envoy_listener.Filter_TypedConfig{TypedConfig: hcm},
+	}, nil
+}
+
+func staticVirtualHost(listener string, vh api.StaticVirtualHost, clusterNames map[string]bool) (*envoy_route.VirtualHost, error) {
+	if vh.Name == "" {
+		return nil, fmt.Errorf("a virtual host of static listener %q has no name", listener)
+	}
+	domains := vh.Domains
+	if len(domains) == 0 {
+		domains = []string{"*"}
+	}
+	virtualHost := &envoy_route.VirtualHost{
+		Name:    vh.Name,
+		Domains: domains,
+	}
+	for _, r := range vh.Routes {
+		if !clusterNames[r.Cluster] {
+			return nil, fmt.Errorf("virtual host %q routes to unknown cluster %q", vh.Name, r.Cluster)
+		}
+		match := &envoy_route.RouteMatch{}
+		switch {
+		case r.Path != "" && r.Prefix != "":
+			return nil, fmt.Errorf("a route of virtual host %q has both a path and a prefix", vh.Name)
+		case r.Path != "":
+			match.PathSpecifier = &envoy_route.RouteMatch_Path{Path: r.Path}
+		case r.Prefix != "":
+			match.PathSpecifier = &envoy_route.RouteMatch_Prefix{Prefix: r.Prefix}
+		default:
+			match.PathSpecifier = &envoy_route.RouteMatch_Prefix{Prefix: "/"}
+		}
+
+		action := &envoy_route.RouteAction{
+			ClusterSpecifier: &envoy_route.RouteAction_Cluster{Cluster: r.Cluster},
+			PrefixRewrite:    r.PrefixRewrite,
+		}
+		if r.Timeout != nil {
+			action.Timeout = ptypes.DurationProto(r.Timeout.Duration)
+		}
+		virtualHost.Routes = append(virtualHost.Routes, &envoy_route.Route{
+			Match:  match,
+			Action: &envoy_route.Route_Route{Route: action},
+		})
+	}
+	return virtualHost, nil
+}
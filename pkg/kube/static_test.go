@@ -0,0 +1,148 @@
+package kube_test
+
+import (
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	envoy_cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	envoy_tcp_proxy "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	. "github.com/solo-io/envoy-operator/pkg/kube"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Static resources", func() {
+	var e *api.Envoy
+
+	BeforeEach(func() {
+		e = testEnvoy()
+		e.Spec.ADSServer = ""
+		e.Spec.StaticResources = &api.StaticResourcesSpec{
+			Clusters: []api.StaticCluster{{
+				Name:    "web",
+				Service: "web",
+				Port:    8080,
+			}, {
+				Name:           "grpc",
+				Service:        "grpc",
+				Namespace:      "backend",
+				Port:           9090,
+				HTTP2:          true,
+				ConnectTimeout: &metav1.Duration{Duration: time.Second},
+			}},
+			Listeners: []api.StaticListener{{
+				Name: "http",
+				Port: 8080,
+				VirtualHosts: []api.StaticVirtualHost{{
+					Name: "all",
+					Routes: []api.StaticRoute{{
+						Prefix:  "/api.Service/",
+						Cluster: "grpc",
+						Timeout: &metav1.Duration{Duration: time.Minute},
+					}, {
+						Cluster:       "web",
+						PrefixRewrite: "/web/",
+					}},
+				}},
+			}, {
+				Name:    "tcp",
+				Port:    9000,
+				Cluster: "grpc",
+			}},
+		}
+	})
+
+	It("should not need a control plane", func() {
+		b := generate(e, nil)
+		Expect(b.DynamicResources).To(BeNil())
+		Expect(clusterNamed(b, "ads-control-plane")).To(BeNil())
+		// and the stats listener, with its admin cluster
+		Expect(b.StaticResources.Clusters).To(HaveLen(3))
+		Expect(b.StaticResources.Listeners).To(HaveLen(3))
+	})
+
+	It("should keep the control plane when set", func() {
+		e.Spec.ADSServer = "ads.solo.io"
+		b := generate(e, nil)
+		Expect(b.DynamicResources).NotTo(BeNil())
+		Expect(b.StaticResources.Clusters).To(HaveLen(4))
+	})
+
+	It("should point clusters at kube services", func() {
+		b := generate(e, nil)
+		web := clusterNamed(b, "web")
+		Expect(web.GetType()).To(Equal(envoy_cluster.Cluster_STRICT_DNS))
+		addr := web.LoadAssignment.Endpoints[0].LbEndpoints[0].GetEndpoint().Address.GetSocketAddress()
+		Expect(addr.Address).To(Equal("web.default.svc.cluster.local"))
+		Expect(addr.GetPortValue()).To(BeEquivalentTo(8080))
+		Expect(web.Http2ProtocolOptions).To(BeNil())
+
+		grpc := clusterNamed(b, "grpc")
+		addr = grpc.LoadAssignment.Endpoints[0].LbEndpoints[0].GetEndpoint().Address.GetSocketAddress()
+		Expect(addr.Address).To(Equal("grpc.backend.svc.cluster.local"))
+		Expect(grpc.Http2ProtocolOptions).NotTo(BeNil())
+		Expect(grpc.ConnectTimeout.Seconds).To(BeEquivalentTo(1))
+	})
+
+	It("should resolve kube services in the cluster domain", func() {
+		e.Spec.StaticResources.ClusterDomain = "corp.example.com"
+		b := generate(e, nil)
+		addr := clusterNamed(b, "web").LoadAssignment.Endpoints[0].LbEndpoints[0].GetEndpoint().Address.GetSocketAddress()
+		Expect(addr.Address).To(Equal("web.default.svc.corp.example.com"))
+	})
+
+	It("should route http listeners", func() {
+		b := generate(e, nil)
+		l := b.StaticResources.Listeners[0]
+		Expect(l.Address.GetSocketAddress().Address).To(Equal("0.0.0.0"))
+		Expect(l.FilterChains[0].Filters[0].Name).To(Equal("envoy.http_connection_manager"))
+
+		var hcm envoy_hcm.HttpConnectionManager
+		err := ptypes.UnmarshalAny(l.FilterChains[0].Filters[0].GetTypedConfig(), &hcm)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hcm.HttpFilters[0].Name).To(Equal("envoy.router"))
+		vh := hcm.GetRouteConfig().VirtualHosts[0]
+		Expect(vh.Domains).To(Equal([]string{"*"}))
+		Expect(vh.Routes).To(HaveLen(2))
+		Expect(vh.Routes[0].Match.GetPrefix()).To(Equal("/api.Service/"))
+		Expect(vh.Routes[0].GetRoute().GetCluster()).To(Equal("grpc"))
+		Expect(vh.Routes[0].GetRoute().Timeout.Seconds).To(BeEquivalentTo(60))
+		Expect(vh.Routes[1].Match.GetPrefix()).To(Equal("/"))
+		Expect(vh.Routes[1].GetRoute().PrefixRewrite).To(Equal("/web/"))
+	})
+
+	It("should forward tcp listeners", func() {
+		b := generate(e, nil)
+		l := b.StaticResources.Listeners[1]
+		var tcpProxy envoy_tcp_proxy.TcpProxy
+		err := ptypes.UnmarshalAny(l.FilterChains[0].Filters[0].GetTypedConfig(), &tcpProxy)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tcpProxy.GetCluster()).To(Equal("grpc"))
+	})
+
+	It("should reject routes to unknown clusters", func() {
+		e.Spec.StaticResources.Listeners[0].VirtualHosts[0].Routes[0].Cluster = "nope"
+		_, err := GenerateEnvoyConfig(e, nil)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject duplicate names", func() {
+		e.Spec.ADSServer = "ads.solo.io"
+		e.Spec.StaticResources.Clusters[0].Name = "ads-control-plane"
+		e.Spec.StaticResources.Listeners[0].VirtualHosts[0].Routes[1].Cluster = "ads-control-plane"
+		_, err := GenerateEnvoyConfig(e, nil)
+		Expect(err).To(MatchError(ContainSubstring("duplicate cluster")))
+	})
+
+	It("should need a control plane or static resources", func() {
+		e.Spec.StaticResources = nil
+		_, err := GenerateEnvoyConfig(e, nil)
+		Expect(err).To(HaveOccurred())
+	})
+})
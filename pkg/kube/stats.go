@@ -0,0 +1,152 @@
+package kube
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/duration"
+
+	envoy_config_bootstrap "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v3"
+	envoy_cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	envoy_metrics "github.com/envoyproxy/go-control-plane/envoy/config/metrics/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+)
+
+const metricsServiceClusterName = "metrics-service"
+
+// addStats adds the stats sinks and tags of the envoy to the bootstrap config
+func addStats(e *api.Envoy, bootstrapConfig *envoy_config_bootstrap.Bootstrap) error {
+	spec := e.Spec.Stats
+	if spec == nil {
+		return nil
+	}
+
+	if spec.Statsd != nil {
+		address, err := statsdAddress(spec.Statsd)
+		if err != nil {
+			return err
+		}
+		sink, err := statsSink(wellknown.Statsd, &envoy_metrics.StatsdSink{
+			StatsdSpecifier: &envoy_metrics.StatsdSink_Address{Address: address},
+			Prefix:          spec.Statsd.Prefix,
+		})
+		if err != nil {
+			return err
+		}
+		bootstrapConfig.StatsSinks = append(bootstrapConfig.StatsSinks, sink)
+	}
+	if spec.DogStatsd != nil {
+		address, err := statsdAddress(spec.DogStatsd)
+		if err != nil {
+			return err
+		}
+		sink, err := statsSink(wellknown.DogStatsd, &envoy_metrics.DogStatsdSink{
+			DogStatsdSpecifier: &envoy_metrics.DogStatsdSink_Address{Address: address},
+			Prefix:             spec.DogStatsd.Prefix,
+		})
+		if err != nil {
+			return err
+		}
+		bootstrapConfig.StatsSinks = append(bootstrapConfig.StatsSinks, sink)
+	}
+	if ms := spec.MetricsService; ms != nil {
+		if ms.Address == "" || ms.Port == 0 {
+			return fmt.Errorf("metrics service sink needs an address and a port")
+		}
+		sink, err := statsSink(wellknown.MetricsService, &envoy_metrics.MetricsServiceConfig{
+			GrpcService: &envoy_core.GrpcService{
+				TargetSpecifier: &envoy_core.GrpcService_EnvoyGrpc_{
+					EnvoyGrpc: &envoy_core.GrpcService_EnvoyGrpc{
+						ClusterName: metricsServiceClusterName,
+					},
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		bootstrapConfig.StatsSinks = append(bootstrapConfig.StatsSinks, sink)
+		bootstrapConfig.StaticResources.Clusters = append(bootstrapConfig.StaticResources.Clusters,
+			dnsCluster(metricsServiceClusterName, ms.Address, ms.Port, true))
+	}
+
+	for _, tag := range spec.Tags {
+		specifier := &envoy_metrics.TagSpecifier{TagName: tag.Name}
+		switch {
+		case tag.Name == "":
+			return fmt.Errorf("stats tags need a name")
+		case tag.Regex != "" && tag.FixedValue != "":
+			return fmt.Errorf("stats tag %q can't have both a regex and a fixed value", tag.Name)
+		case tag.Regex != "":
+			specifier.TagValue = &envoy_metrics.TagSpecifier_Regex{Regex: tag.Regex}
+		case tag.FixedValue != "":
+			specifier.TagValue = &envoy_metrics.TagSpecifier_FixedValue{FixedValue: tag.FixedValue}
+		}
+		if bootstrapConfig.StatsConfig == nil {
+			bootstrapConfig.StatsConfig = &envoy_metrics.StatsConfig{}
+		}
+		bootstrapConfig.StatsConfig.StatsTags = append(bootstrapConfig.StatsConfig.StatsTags, specifier)
+	}
+	return nil
+}
+
+// statsdAddress returns the udp address of a statsd sink. Envoy doesn't resolve host names for
+// statsd, so the address has to be an ip, or a template the initializer fills with one.
+func statsdAddress(s *api.StatsdSinkSpec) (*envoy_core.Address, error) {
+	if s.Port == 0 {
+		return nil, fmt.Errorf("statsd sink needs a port")
+	}
+	if !isTemplate(s.Address) && net.ParseIP(s.Address) == nil {
+		return nil, fmt.Errorf("statsd sink address %q is not an ip address", s.Address)
+	}
+	address := socketAddress(s.Address, s.Port)
+	address.GetSocketAddress().Protocol = envoy_core.SocketAddress_UDP
+	return address, nil
+}
+
+func statsSink(name string, config proto.Message) (*envoy_metrics.StatsSink, error) {
+	typedConfig, err := ptypes.MarshalAny(config)
+	if err != nil {
+		return nil, err
+	}
+	return &envoy_metrics.StatsSink{
+		Name:       name,
+		ConfigType: &envoy_metrics.StatsSink_TypedConfig{TypedConfig: typedConfig},
+	}, nil
+}
+
+// dnsCluster returns a cluster resolving the host with dns, using http2 for grpc services
+func dnsCluster(name, host string, port uint32, http2 bool) *envoy_cluster.Cluster {
+	cluster := &envoy_cluster.Cluster{
+		Name:                 name,
+		ClusterDiscoveryType: &envoy_cluster.Cluster_Type{Type: envoy_cluster.Cluster_STRICT_DNS},
+		ConnectTimeout:       &duration.Duration{Seconds: 5},
+		LoadAssignment: &envoy_endpoint.ClusterLoadAssignment{
+			ClusterName: name,
+			Endpoints: []*envoy_endpoint.LocalityLbEndpoints{{
+				LbEndpoints: []*envoy_endpoint.LbEndpoint{{
+					HostIdentifier: &envoy_endpoint.LbEndpoint_Endpoint{
+						Endpoint: &envoy_endpoint.Endpoint{
+							Address: socketAddress(host, port),
+						},
+					},
+				}},
+			}},
+		},
+	}
+	if http2 {
+		cluster.Http2ProtocolOptions = &envoy_core.Http2ProtocolOptions{}
+	}
+	return cluster
+}
+
+func isTemplate(s string) bool {
+	return strings.Contains(s, "{{")
+}
@@ -0,0 +1,113 @@
+package kube_test
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	envoy_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_metrics "github.com/envoyproxy/go-control-plane/envoy/config/metrics/v3"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	. "github.com/solo-io/envoy-operator/pkg/kube"
+)
+
+var _ = Describe("Stats", func() {
+	var e *api.Envoy
+
+	BeforeEach(func() {
+		e = testEnvoy()
+		e.Spec.Stats = &api.StatsSpec{}
+	})
+
+	It("should send stats to statsd over udp", func() {
+		e.Spec.Stats.Statsd = &api.StatsdSinkSpec{Address: "10.0.0.1", Port: 8125, Prefix: "envoy"}
+		b := generate(e, nil)
+		Expect(b.StatsSinks).To(HaveLen(1))
+		Expect(b.StatsSinks[0].Name).To(Equal("envoy.statsd"))
+
+		var statsd envoy_metrics.StatsdSink
+		err := ptypes.UnmarshalAny(b.StatsSinks[0].GetTypedConfig(), &statsd)
+		Expect(err).NotTo(HaveOccurred())
+		addr := statsd.GetAddress().GetSocketAddress()
+		Expect(addr.Address).To(Equal("10.0.0.1"))
+		Expect(addr.GetPortValue()).To(BeEquivalentTo(8125))
+		Expect(addr.Protocol).To(Equal(envoy_core.SocketAddress_UDP))
+		Expect(statsd.Prefix).To(Equal("envoy"))
+	})
+
+	It("should send stats to a dogstatsd agent on the node", func() {
+		e.Spec.Stats.DogStatsd = &api.StatsdSinkSpec{Address: "{{.NodeIp}}", Port: 8125}
+		b := generate(e, nil)
+		Expect(b.StatsSinks[0].Name).To(Equal("envoy.dog_statsd"))
+
+		var dogStatsd envoy_metrics.DogStatsdSink
+		err := ptypes.UnmarshalAny(b.StatsSinks[0].GetTypedConfig(), &dogStatsd)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dogStatsd.GetAddress().GetSocketAddress().Address).To(Equal("{{.NodeIp}}"))
+
+		_, env, err := InitDownward(e)
+		Expect(err).NotTo(HaveOccurred())
+		var names []string
+		for _, v := range env {
+			names = append(names, v.Name)
+		}
+		Expect(names).To(ContainElement("NODE_IP"))
+	})
+
+	It("should reject statsd host names", func() {
+		e.Spec.Stats.Statsd = &api.StatsdSinkSpec{Address: "statsd.monitoring", Port: 8125}
+		_, err := GenerateEnvoyConfig(e, nil)
+		Expect(err).To(MatchError(ContainSubstring("not an ip address")))
+	})
+
+	It("should stream stats to a metrics service", func() {
+		e.Spec.Stats.MetricsService = &api.MetricsServiceSinkSpec{Address: "metrics.monitoring", Port: 9000}
+		b := generate(e, nil)
+		Expect(b.StatsSinks[0].Name).To(Equal("envoy.metrics_service"))
+
+		var ms envoy_metrics.MetricsServiceConfig
+		err := ptypes.UnmarshalAny(b.StatsSinks[0].GetTypedConfig(), &ms)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ms.GrpcService.GetEnvoyGrpc().ClusterName).To(Equal("metrics-service"))
+
+		c := clusterNamed(b, "metrics-service")
+		Expect(c).NotTo(BeNil())
+		Expect(c.Http2ProtocolOptions).NotTo(BeNil())
+		addr := c.LoadAssignment.Endpoints[0].LbEndpoints[0].GetEndpoint().Address.GetSocketAddress()
+		Expect(addr.Address).To(Equal("metrics.monitoring"))
+	})
+
+	It("should stream stats to a metrics service on the node", func() {
+		e.Spec.Stats.MetricsService = &api.MetricsServiceSinkSpec{Address: "{{.NodeIp}}", Port: 9000}
+		b := generate(e, nil)
+		addr := clusterNamed(b, "metrics-service").LoadAssignment.Endpoints[0].LbEndpoints[0].GetEndpoint().Address.GetSocketAddress()
+		Expect(addr.Address).To(Equal("{{.NodeIp}}"))
+
+		_, env, err := InitDownward(e)
+		Expect(err).NotTo(HaveOccurred())
+		var names []string
+		for _, v := range env {
+			names = append(names, v.Name)
+		}
+		Expect(names).To(ContainElement("NODE_IP"))
+	})
+
+	It("should add stats tags", func() {
+		e.Spec.Stats.Tags = []api.StatsTag{
+			{Name: "envoy_name", FixedValue: "ingress"},
+			{Name: "route", Regex: `^http\.\w+\.route\.((.*?)\.)`},
+		}
+		b := generate(e, nil)
+		tags := b.StatsConfig.StatsTags
+		Expect(tags).To(HaveLen(2))
+		Expect(tags[0].GetFixedValue()).To(Equal("ingress"))
+		Expect(tags[1].GetRegex()).To(Equal(`^http\.\w+\.route\.((.*?)\.)`))
+	})
+
+	It("should reject tags with a regex and a fixed value", func() {
+		e.Spec.Stats.Tags = []api.StatsTag{{Name: "t", Regex: "(.*)", FixedValue: "v"}}
+		_, err := GenerateEnvoyConfig(e, nil)
+		Expect(err).To(HaveOccurred())
+	})
+})
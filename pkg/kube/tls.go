@@ -0,0 +1,197 @@
+package kube
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	envoy_tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	envoy_matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// certKey returns the key of the client certificate in the secret: the standard tls.crt, or
+// else the legacy tls.cert
+func certKey(secret *v1.Secret) string {
+	if _, ok := secret.Data[api.TLSCert]; !ok {
+		if _, ok := secret.Data[api.TLSCertLegacy]; ok {
+			return api.TLSCertLegacy
+		}
+	}
+	return api.TLSCert
+}
+
+// hasKey returns true if the secret holds a client certificate and its key
+func hasKey(secret *v1.Secret) bool {
+	if _, ok := secret.Data[api.TLSKey]; !ok {
+		return false
+	}
+	if _, ok := secret.Data[certKey(secret)]; !ok {
+		return false
+	}
+	return true
+}
+
+// tlsFile returns the path of the key of the mounted tls secret
+func tlsFile(key string) string {
+	return filepath.Join(api.EnvoyTLSVolPath, key)
+}
+
+// ValidateTLSSecret returns an error if the secret doesn't hold a ca, or holds a client
+// certificate without its key, or if they can't be parsed, don't match or aren't valid at now
+func ValidateTLSSecret(secret *v1.Secret, now time.Time) error {
+	ca, ok := secret.Data[api.TLSCA]
+	if !ok {
+		return fmt.Errorf("secret %s has no %s", secret.Name, api.TLSCA)
+	}
+	if err := validateCA(ca, now); err != nil {
+		return fmt.Errorf("secret %s: %v", secret.Name, err)
+	}
+
+	cert, hasCert := secret.Data[certKey(secret)]
+	key, hasPrivateKey := secret.Data[api.TLSKey]
+	switch {
+	case hasCert && !hasPrivateKey:
+		return fmt.Errorf("secret %s has a client certificate but no %s", secret.Name, api.TLSKey)
+	case !hasCert && hasPrivateKey:
+		return fmt.Errorf("secret %s has a %s but no client certificate %s", secret.Name, api.TLSKey, api.TLSCert)
+	case !hasCert:
+		return nil
+	}
+	pair, err := tls.X509KeyPair(cert, key)
+	if err != nil {
+		return fmt.Errorf("secret %s has an invalid client certificate: %v", secret.Name, err)
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("secret %s has an invalid client certificate: %v", secret.Name, err)
+	}
+	if err := checkValidity(leaf, now); err != nil {
+		return fmt.Errorf("secret %s: client certificate %v", secret.Name, err)
+	}
+	return nil
+}
+
+// validateCA returns an error if the ca bundle has no certificate that can be used at now
+func validateCA(bundle []byte, now time.Time) error {
+	var (
+		certs   int
+		lastErr error
+	)
+	for {
+		var block *pem.Block
+		block, bundle = pem.Decode(bundle)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("invalid ca certificate: %v", err)
+		}
+		certs++
+		if lastErr = checkValidity(cert, now); lastErr == nil {
+			return nil
+		}
+	}
+	if certs == 0 {
+		return fmt.Errorf("%s holds no pem encoded certificate", api.TLSCA)
+	}
+	return fmt.Errorf("ca certificate %v", lastErr)
+}
+
+func checkValidity(cert *x509.Certificate, now time.Time) error {
+	if now.Before(cert.NotBefore) {
+		return fmt.Errorf("%q is not valid before %s", cert.Subject.CommonName, cert.NotBefore.Format(time.RFC3339))
+	}
+	if now.After(cert.NotAfter) {
+		return fmt.Errorf("%q expired on %s", cert.Subject.CommonName, cert.NotAfter.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// tlsParametersSet returns true if the spec sets parameters of the tls connection to the control
+// plane
+func tlsParametersSet(spec *api.TLSSpec) bool {
+	return spec != nil && (spec.SNI != "" || len(spec.MatchSubjectAltNames) != 0 ||
+		spec.MinVersion != "" || spec.MaxVersion != "" || len(spec.CipherSuites) != 0 ||
+		len(spec.ALPN) != 0 || spec.CRLKey != "")
+}
+
+// tlsParameters returns the tls versions and cipher suites of the spec, or nil for envoy's
+// defaults
+func tlsParameters(spec *api.TLSSpec) (*envoy_tls.TlsParameters, error) {
+	if spec.MinVersion == "" && spec.MaxVersion == "" && len(spec.CipherSuites) == 0 {
+		return nil, nil
+	}
+	min, err := tlsVersion(spec.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	max, err := tlsVersion(spec.MaxVersion)
+	if err != nil {
+		return nil, err
+	}
+	if min != envoy_tls.TlsParameters_TLS_AUTO && max != envoy_tls.TlsParameters_TLS_AUTO && min > max {
+		return nil, fmt.Errorf("the tls minVersion %s is above the maxVersion %s", spec.MinVersion, spec.MaxVersion)
+	}
+	return &envoy_tls.TlsParameters{
+		TlsMinimumProtocolVersion: min,
+		TlsMaximumProtocolVersion: max,
+		CipherSuites:              spec.CipherSuites,
+	}, nil
+}
+
+func tlsVersion(v api.TLSVersion) (envoy_tls.TlsParameters_TlsProtocol, error) {
+	if v == "" {
+		return envoy_tls.TlsParameters_TLS_AUTO, nil
+	}
+	value, ok := envoy_tls.TlsParameters_TlsProtocol_value[string(v)]
+	if !ok || value == int32(envoy_tls.TlsParameters_TLS_AUTO) {
+		return 0, fmt.Errorf("unknown tls version %q, use one of TLSv1_0, TLSv1_1, TLSv1_2 or TLSv1_3", v)
+	}
+	return envoy_tls.TlsParameters_TlsProtocol(value), nil
+}
+
+// stringMatchers returns the envoy matchers of the string matches
+func stringMatchers(matches []api.StringMatch) ([]*envoy_matcher.StringMatcher, error) {
+	var matchers []*envoy_matcher.StringMatcher
+	for _, m := range matches {
+		matcher := &envoy_matcher.StringMatcher{}
+		set := 0
+		if m.Exact != "" {
+			matcher.MatchPattern = &envoy_matcher.StringMatcher_Exact{Exact: m.Exact}
+			set++
+		}
+		if m.Prefix != "" {
+			matcher.MatchPattern = &envoy_matcher.StringMatcher_Prefix{Prefix: m.Prefix}
+			set++
+		}
+		if m.Regex != "" {
+			if _, err := regexp.Compile(m.Regex); err != nil {
+				return nil, fmt.Errorf("invalid subject alt name regex: %v", err)
+			}
+			matcher.MatchPattern = &envoy_matcher.StringMatcher_SafeRegex{
+				SafeRegex: &envoy_matcher.RegexMatcher{
+					EngineType: &envoy_matcher.RegexMatcher_GoogleRe2{GoogleRe2: &envoy_matcher.RegexMatcher_GoogleRE2{}},
+					Regex:      m.Regex,
+				},
+			}
+			set++
+		}
+		if set != 1 {
+			return nil, fmt.Errorf("subject alt name matches need one of exact, prefix or regex")
+		}
+		matchers = append(matchers, matcher)
+	}
+	return matchers, nil
+}
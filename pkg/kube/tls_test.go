@@ -0,0 +1,231 @@
+package kube_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	envoy_tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	. "github.com/solo-io/envoy-operator/pkg/kube"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// selfSigned returns a pem encoded self signed certificate valid between notBefore and
+// notAfter, and its key
+func selfSigned(name string, notBefore, notAfter time.Time) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	Expect(err).NotTo(HaveOccurred())
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+var _ = Describe("TLS secret", func() {
+	var (
+		now    time.Time
+		secret *v1.Secret
+	)
+
+	BeforeEach(func() {
+		now = time.Now()
+		ca, _ := selfSigned("ca", now.Add(-time.Hour), now.Add(time.Hour))
+		cert, key := selfSigned("client", now.Add(-time.Hour), now.Add(time.Hour))
+		secret = &v1.Secret{Data: map[string][]byte{
+			api.TLSCA:   ca,
+			api.TLSCert: cert,
+			api.TLSKey:  key,
+		}}
+		secret.Name = "certs"
+	})
+
+	It("should accept a ca and a client certificate", func() {
+		Expect(ValidateTLSSecret(secret, now)).To(Succeed())
+	})
+
+	It("should accept a ca alone", func() {
+		delete(secret.Data, api.TLSCert)
+		delete(secret.Data, api.TLSKey)
+		Expect(ValidateTLSSecret(secret, now)).To(Succeed())
+	})
+
+	It("should accept the legacy client certificate key", func() {
+		secret.Data[api.TLSCertLegacy] = secret.Data[api.TLSCert]
+		delete(secret.Data, api.TLSCert)
+		Expect(ValidateTLSSecret(secret, now)).To(Succeed())
+
+		e := testEnvoy()
+		e.Spec.TLSSecretName = "certs"
+		tlsContext := upstreamTLSContext(clusterNamed(generate(e, secret), "ads-control-plane"))
+		Expect(tlsContext.CommonTlsContext.TlsCertificates[0].CertificateChain.GetFilename()).To(Equal("/etc/certs/tls.cert"))
+	})
+
+	It("should use the standard client certificate key", func() {
+		e := testEnvoy()
+		e.Spec.TLSSecretName = "certs"
+		tlsContext := upstreamTLSContext(clusterNamed(generate(e, secret), "ads-control-plane"))
+		Expect(tlsContext.CommonTlsContext.TlsCertificates[0].CertificateChain.GetFilename()).To(Equal("/etc/certs/tls.crt"))
+	})
+
+	It("should need a ca", func() {
+		delete(secret.Data, api.TLSCA)
+		Expect(ValidateTLSSecret(secret, now)).To(MatchError(ContainSubstring("has no ca.crt")))
+	})
+
+	It("should reject a ca that isn't pem", func() {
+		secret.Data[api.TLSCA] = []byte("not a cert")
+		Expect(ValidateTLSSecret(secret, now)).To(MatchError(ContainSubstring("no pem encoded certificate")))
+	})
+
+	It("should reject an expired ca", func() {
+		secret.Data[api.TLSCA], _ = selfSigned("ca", now.Add(-2*time.Hour), now.Add(-time.Hour))
+		Expect(ValidateTLSSecret(secret, now)).To(MatchError(ContainSubstring("expired")))
+	})
+
+	It("should reject a client certificate without its key", func() {
+		delete(secret.Data, api.TLSKey)
+		Expect(ValidateTLSSecret(secret, now)).To(MatchError(ContainSubstring("no tls.key")))
+	})
+
+	It("should reject a key that doesn't match the certificate", func() {
+		_, secret.Data[api.TLSKey] = selfSigned("other", now.Add(-time.Hour), now.Add(time.Hour))
+		Expect(ValidateTLSSecret(secret, now)).To(MatchError(ContainSubstring("invalid client certificate")))
+	})
+
+	It("should reject an expired client certificate", func() {
+		secret.Data[api.TLSCert], secret.Data[api.TLSKey] = selfSigned("client", now.Add(-2*time.Hour), now.Add(-time.Hour))
+		Expect(ValidateTLSSecret(secret, now)).To(MatchError(ContainSubstring(`"client" expired`)))
+	})
+
+	It("should reject a client certificate that isn't valid yet", func() {
+		secret.Data[api.TLSCert], secret.Data[api.TLSKey] = selfSigned("client", now.Add(time.Hour), now.Add(2*time.Hour))
+		Expect(ValidateTLSSecret(secret, now)).To(MatchError(ContainSubstring("not valid before")))
+	})
+})
+
+var _ = Describe("TLS parameters", func() {
+	var (
+		e      *api.Envoy
+		secret *v1.Secret
+	)
+
+	BeforeEach(func() {
+		e = testEnvoy()
+		e.Spec.TLSSecretName = "certs"
+		e.Spec.TLS = &api.TLSSpec{}
+		secret = &v1.Secret{Data: map[string][]byte{
+			api.TLSCA:   []byte("ca"),
+			api.TLSCert: []byte("cert"),
+			api.TLSKey:  []byte("key"),
+			"ca.crl":    []byte("crl"),
+		}}
+	})
+
+	It("should keep envoy's defaults", func() {
+		tlsContext := upstreamTLSContext(clusterNamed(generate(e, secret), "ads-control-plane"))
+		Expect(tlsContext.Sni).To(Equal("ads.solo.io"))
+		Expect(tlsContext.CommonTlsContext.TlsParams).To(BeNil())
+		Expect(tlsContext.CommonTlsContext.AlpnProtocols).To(BeEmpty())
+		Expect(tlsContext.CommonTlsContext.GetValidationContext().MatchSubjectAltNames).To(BeEmpty())
+	})
+
+	It("should render the parameters", func() {
+		e.Spec.TLS = &api.TLSSpec{
+			SNI: "control-plane.example.org",
+			MatchSubjectAltNames: []api.StringMatch{
+				{Exact: "spiffe://example.org/control-plane"},
+				{Prefix: "control-plane."},
+				{Regex: `^cp-[0-9]+\.example\.org$`},
+			},
+			MinVersion:   api.TLSVersion1_2,
+			MaxVersion:   api.TLSVersion1_3,
+			CipherSuites: []string{"ECDHE-ECDSA-AES128-GCM-SHA256"},
+			ALPN:         []string{"h2"},
+			CRLKey:       "ca.crl",
+		}
+		tlsContext := upstreamTLSContext(clusterNamed(generate(e, secret), "ads-control-plane"))
+		Expect(tlsContext.Sni).To(Equal("control-plane.example.org"))
+
+		common := tlsContext.CommonTlsContext
+		Expect(common.TlsParams.TlsMinimumProtocolVersion).To(Equal(envoy_tls.TlsParameters_TLSv1_2))
+		Expect(common.TlsParams.TlsMaximumProtocolVersion).To(Equal(envoy_tls.TlsParameters_TLSv1_3))
+		Expect(common.TlsParams.CipherSuites).To(Equal([]string{"ECDHE-ECDSA-AES128-GCM-SHA256"}))
+		Expect(common.AlpnProtocols).To(Equal([]string{"h2"}))
+
+		validation := common.GetValidationContext()
+		Expect(validation.TrustedCa.GetFilename()).To(Equal("/etc/certs/ca.crt"))
+		Expect(validation.Crl.GetFilename()).To(Equal("/etc/certs/ca.crl"))
+		Expect(validation.MatchSubjectAltNames).To(HaveLen(3))
+		Expect(validation.MatchSubjectAltNames[0].GetExact()).To(Equal("spiffe://example.org/control-plane"))
+		Expect(validation.MatchSubjectAltNames[1].GetPrefix()).To(Equal("control-plane."))
+		Expect(validation.MatchSubjectAltNames[2].GetSafeRegex().Regex).To(Equal(`^cp-[0-9]+\.example\.org$`))
+		Expect(validation.MatchSubjectAltNames[2].GetSafeRegex().GetGoogleRe2()).NotTo(BeNil())
+	})
+
+	It("should combine the validation with the ca of sds", func() {
+		e.Spec.SDS = &api.SDSSpec{Server: &api.SDSServerSpec{Address: "127.0.0.1", Port: 8234}}
+		e.Spec.TLS.MatchSubjectAltNames = []api.StringMatch{{Exact: "control-plane"}}
+		tlsContext := upstreamTLSContext(clusterNamed(generate(e, secret), "ads-control-plane"))
+		combined := tlsContext.CommonTlsContext.GetCombinedValidationContext()
+		Expect(combined.ValidationContextSdsSecretConfig.Name).To(Equal("ROOTCA"))
+		Expect(combined.DefaultValidationContext.MatchSubjectAltNames).To(HaveLen(1))
+		Expect(combined.DefaultValidationContext.TrustedCa).To(BeNil())
+	})
+
+	It("should reject invalid parameters", func() {
+		e.Spec.TLS.MinVersion = "TLSv1_4"
+		_, err := GenerateEnvoyConfig(e, secret)
+		Expect(err).To(MatchError(ContainSubstring("unknown tls version")))
+
+		e.Spec.TLS.MinVersion, e.Spec.TLS.MaxVersion = api.TLSVersion1_3, api.TLSVersion1_2
+		_, err = GenerateEnvoyConfig(e, secret)
+		Expect(err).To(MatchError(ContainSubstring("above the maxVersion")))
+
+		e.Spec.TLS = &api.TLSSpec{MatchSubjectAltNames: []api.StringMatch{{Exact: "a", Prefix: "b"}}}
+		_, err = GenerateEnvoyConfig(e, secret)
+		Expect(err).To(MatchError(ContainSubstring("one of exact, prefix or regex")))
+
+		e.Spec.TLS = &api.TLSSpec{MatchSubjectAltNames: []api.StringMatch{{Regex: "("}}}
+		_, err = GenerateEnvoyConfig(e, secret)
+		Expect(err).To(MatchError(ContainSubstring("invalid subject alt name regex")))
+
+		e.Spec.TLS = &api.TLSSpec{CRLKey: "missing.crl"}
+		_, err = GenerateEnvoyConfig(e, secret)
+		Expect(err).To(MatchError(ContainSubstring("missing.crl")))
+	})
+
+	It("should need tls for the parameters", func() {
+		e.Spec.TLSSecretName = ""
+		e.Spec.TLS.SNI = "control-plane.example.org"
+		_, err := GenerateEnvoyConfig(e, nil)
+		Expect(err).To(MatchError(ContainSubstring("tls_secret_name")))
+	})
+
+	It("should not be used with the google grpc client", func() {
+		e.Spec.ControlPlane = &api.ControlPlaneSpec{GRPC: &api.GRPCClientSpec{Google: &api.GoogleGRPCSpec{}}}
+		e.Spec.TLS.ALPN = []string{"h2"}
+		_, err := GenerateEnvoyConfig(e, secret)
+		Expect(err).To(MatchError(ContainSubstring("google grpc")))
+	})
+})
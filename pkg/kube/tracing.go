@@ -0,0 +1,149 @@
+package kube
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+
+	envoy_config_bootstrap "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v3"
+	envoy_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_trace "github.com/envoyproxy/go-control-plane/envoy/config/trace/v3"
+	envoy_hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+)
+
+const (
+	tracingClusterName = "tracing-collector"
+
+	datadogTracerName    = "envoy.tracers.datadog"
+	openCensusTracerName = "envoy.tracers.opencensus"
+
+	defaultZipkinEndpoint = "/api/v2/spans"
+)
+
+// addTracing adds the tracer of the envoy, and the cluster of its collector, to the bootstrap config
+func addTracing(e *api.Envoy, bootstrapConfig *envoy_config_bootstrap.Bootstrap) error {
+	spec := e.Spec.Tracing
+	if spec == nil {
+		return nil
+	}
+	// the sampling only applies to static listeners, but is checked either way
+	if _, err := hcmTracing(e); err != nil {
+		return err
+	}
+
+	var (
+		name      string
+		config    proto.Message
+		collector api.TracingCollectorSpec
+		http2     bool
+		tracers   int
+	)
+	if z := spec.Zipkin; z != nil {
+		tracers++
+		endpoint := z.Endpoint
+		if endpoint == "" {
+			endpoint = defaultZipkinEndpoint
+		}
+		name, collector = wellknown.Zipkin, z.Collector
+		config = &envoy_trace.ZipkinConfig{
+			CollectorCluster:         tracingClusterName,
+			CollectorEndpoint:        endpoint,
+			CollectorEndpointVersion: envoy_trace.ZipkinConfig_HTTP_JSON,
+			TraceId_128Bit:           z.TraceID128Bit,
+		}
+	}
+	if oc := spec.OpenCensus; oc != nil {
+		tracers++
+		name, collector, http2 = openCensusTracerName, oc.Collector, true
+		config = &envoy_trace.OpenCensusConfig{
+			OcagentExporterEnabled: true,
+			OcagentGrpcService: &envoy_core.GrpcService{
+				TargetSpecifier: &envoy_core.GrpcService_EnvoyGrpc_{
+					EnvoyGrpc: &envoy_core.GrpcService_EnvoyGrpc{
+						ClusterName: tracingClusterName,
+					},
+				},
+			},
+			IncomingTraceContext: []envoy_trace.OpenCensusConfig_TraceContext{
+				envoy_trace.OpenCensusConfig_TRACE_CONTEXT,
+				envoy_trace.OpenCensusConfig_B3,
+			},
+			OutgoingTraceContext: []envoy_trace.OpenCensusConfig_TraceContext{
+				envoy_trace.OpenCensusConfig_TRACE_CONTEXT,
+			},
+		}
+	}
+	if d := spec.Datadog; d != nil {
+		tracers++
+		serviceName := d.ServiceName
+		if serviceName == "" {
+			serviceName = e.Name
+		}
+		name, collector = datadogTracerName, d.Collector
+		config = &envoy_trace.DatadogConfig{
+			CollectorCluster: tracingClusterName,
+			ServiceName:      serviceName,
+		}
+	}
+
+	switch {
+	case tracers == 0:
+		return fmt.Errorf("tracing needs a zipkin, openCensus or datadog tracer")
+	case tracers > 1:
+		return fmt.Errorf("tracing can only have one tracer")
+	case collector.Address == "" || collector.Port == 0:
+		return fmt.Errorf("tracing collector needs an address and a port")
+	}
+
+	typedConfig, err := ptypes.MarshalAny(config)
+	if err != nil {
+		return err
+	}
+	bootstrapConfig.Tracing = &envoy_trace.Tracing{
+		Http: &envoy_trace.Tracing_Http{
+			Name:       name,
+			ConfigType: &envoy_trace.Tracing_Http_TypedConfig{TypedConfig: typedConfig},
+		},
+	}
+	bootstrapConfig.StaticResources.Clusters = append(bootstrapConfig.StaticResources.Clusters,
+		dnsCluster(tracingClusterName, collector.Address, collector.Port, http2))
+	return nil
+}
+
+// hcmTracing returns the tracing settings of the static http connection managers, nil when the
+// envoy doesn't trace
+func hcmTracing(e *api.Envoy) (*envoy_hcm.HttpConnectionManager_Tracing, error) {
+	spec := e.Spec.Tracing
+	if spec == nil {
+		return nil, nil
+	}
+	tracing := &envoy_hcm.HttpConnectionManager_Tracing{}
+	if s := spec.Sampling; s != nil {
+		var err error
+		if tracing.RandomSampling, err = samplingPercent("random", s.Random); err != nil {
+			return nil, err
+		}
+		if tracing.ClientSampling, err = samplingPercent("client", s.Client); err != nil {
+			return nil, err
+		}
+		if tracing.OverallSampling, err = samplingPercent("overall", s.Overall); err != nil {
+			return nil, err
+		}
+	}
+	return tracing, nil
+}
+
+func samplingPercent(name string, value *float64) (*envoy_type.Percent, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if *value < 0 || *value > 100 {
+		return nil, fmt.Errorf("%s sampling must be between 0 and 100", name)
+	}
+	return &envoy_type.Percent{Value: *value}, nil
+}
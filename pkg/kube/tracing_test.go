@@ -0,0 +1,119 @@
+package kube_test
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	envoy_trace "github.com/envoyproxy/go-control-plane/envoy/config/trace/v3"
+	envoy_hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	. "github.com/solo-io/envoy-operator/pkg/kube"
+)
+
+var _ = Describe("Tracing", func() {
+	var e *api.Envoy
+
+	collector := api.TracingCollectorSpec{Address: "collector.tracing", Port: 9411}
+
+	BeforeEach(func() {
+		e = testEnvoy()
+		e.Spec.Tracing = &api.TracingSpec{}
+	})
+
+	It("should send traces to zipkin", func() {
+		e.Spec.Tracing.Zipkin = &api.ZipkinTracerSpec{Collector: collector}
+		b := generate(e, nil)
+		Expect(b.Tracing.Http.Name).To(Equal("envoy.zipkin"))
+
+		var zipkin envoy_trace.ZipkinConfig
+		err := ptypes.UnmarshalAny(b.Tracing.Http.GetTypedConfig(), &zipkin)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(zipkin.CollectorCluster).To(Equal("tracing-collector"))
+		Expect(zipkin.CollectorEndpoint).To(Equal("/api/v2/spans"))
+
+		c := clusterNamed(b, "tracing-collector")
+		Expect(c).NotTo(BeNil())
+		Expect(c.Http2ProtocolOptions).To(BeNil())
+		addr := c.LoadAssignment.Endpoints[0].LbEndpoints[0].GetEndpoint().Address.GetSocketAddress()
+		Expect(addr.Address).To(Equal("collector.tracing"))
+		Expect(addr.GetPortValue()).To(BeEquivalentTo(9411))
+	})
+
+	It("should export traces to an opencensus agent over grpc", func() {
+		e.Spec.Tracing.OpenCensus = &api.OpenCensusTracerSpec{Collector: collector}
+		b := generate(e, nil)
+		Expect(b.Tracing.Http.Name).To(Equal("envoy.tracers.opencensus"))
+
+		var oc envoy_trace.OpenCensusConfig
+		err := ptypes.UnmarshalAny(b.Tracing.Http.GetTypedConfig(), &oc)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(oc.OcagentExporterEnabled).To(BeTrue())
+		Expect(oc.OcagentGrpcService.GetEnvoyGrpc().ClusterName).To(Equal("tracing-collector"))
+		Expect(clusterNamed(b, "tracing-collector").Http2ProtocolOptions).NotTo(BeNil())
+	})
+
+	It("should send traces to a collector on the node", func() {
+		e.Spec.Tracing.Datadog = &api.DatadogTracerSpec{Collector: api.TracingCollectorSpec{Address: "{{.NodeIp}}", Port: 8126}}
+		b := generate(e, nil)
+		addr := clusterNamed(b, "tracing-collector").LoadAssignment.Endpoints[0].LbEndpoints[0].GetEndpoint().Address.GetSocketAddress()
+		Expect(addr.Address).To(Equal("{{.NodeIp}}"))
+
+		_, env, err := InitDownward(e)
+		Expect(err).NotTo(HaveOccurred())
+		var names []string
+		for _, v := range env {
+			names = append(names, v.Name)
+		}
+		Expect(names).To(ContainElement("NODE_IP"))
+	})
+
+	It("should name the datadog service after the envoy", func() {
+		e.Spec.Tracing.Datadog = &api.DatadogTracerSpec{Collector: collector}
+		b := generate(e, nil)
+		var datadog envoy_trace.DatadogConfig
+		err := ptypes.UnmarshalAny(b.Tracing.Http.GetTypedConfig(), &datadog)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(datadog.ServiceName).To(Equal("myenvoy"))
+	})
+
+	It("should sample the static http listeners", func() {
+		random := 10.0
+		e.Spec.Tracing.Zipkin = &api.ZipkinTracerSpec{Collector: collector}
+		e.Spec.Tracing.Sampling = &api.TracingSamplingSpec{Random: &random}
+		e.Spec.StaticResources = &api.StaticResourcesSpec{
+			Clusters: []api.StaticCluster{{Name: "web", Service: "web", Port: 8080}},
+			Listeners: []api.StaticListener{{
+				Name: "http",
+				Port: 8080,
+				VirtualHosts: []api.StaticVirtualHost{{
+					Name:   "all",
+					Routes: []api.StaticRoute{{Cluster: "web"}},
+				}},
+			}},
+		}
+		b := generate(e, nil)
+		var hcm envoy_hcm.HttpConnectionManager
+		err := ptypes.UnmarshalAny(b.StaticResources.Listeners[0].FilterChains[0].Filters[0].GetTypedConfig(), &hcm)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hcm.Tracing).NotTo(BeNil())
+		Expect(hcm.Tracing.RandomSampling.Value).To(Equal(10.0))
+		Expect(hcm.Tracing.ClientSampling).To(BeNil())
+	})
+
+	It("should reject more than one tracer", func() {
+		e.Spec.Tracing.Zipkin = &api.ZipkinTracerSpec{Collector: collector}
+		e.Spec.Tracing.Datadog = &api.DatadogTracerSpec{Collector: collector}
+		_, err := GenerateEnvoyConfig(e, nil)
+		Expect(err).To(MatchError(ContainSubstring("only have one tracer")))
+	})
+
+	It("should reject sampling out of range", func() {
+		overall := 150.0
+		e.Spec.Tracing.Zipkin = &api.ZipkinTracerSpec{Collector: collector}
+		e.Spec.Tracing.Sampling = &api.TracingSamplingSpec{Overall: &overall}
+		_, err := GenerateEnvoyConfig(e, nil)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,114 @@
+package kube
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+
+	envoy_config_bootstrap "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v3"
+	envoy_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+)
+
+func xdsMode(e *api.Envoy) api.XDSMode {
+	if e.Spec.XDS == nil || e.Spec.XDS.Mode == "" {
+		return api.XDSModeADS
+	}
+	return e.Spec.XDS.Mode
+}
+
+func apiVersion(v api.APIVersion) (envoy_core.ApiVersion, error) {
+	switch v {
+	case "", api.APIVersionAuto:
+		return envoy_core.ApiVersion_AUTO, nil
+	case api.APIVersionV2:
+		return envoy_core.ApiVersion_V2, nil
+	case api.APIVersionV3:
+		return envoy_core.ApiVersion_V3, nil
+	}
+	return 0, fmt.Errorf("unknown api version %q", v)
+}
+
+// dynamicResources returns the dynamic resources that fetch the listeners and clusters from the
+// control plane cluster, the way the xds mode says. grpc streams use grpcService.
+func dynamicResources(e *api.Envoy, cluster string, grpcService *envoy_core.GrpcService) (*envoy_config_bootstrap.Bootstrap_DynamicResources, error) {
+	var spec api.XDSSpec
+	if e.Spec.XDS != nil {
+		spec = *e.Spec.XDS
+	}
+	resourceVersion, err := apiVersion(spec.ResourceAPIVersion)
+	if err != nil {
+		return nil, err
+	}
+	transportVersion, err := apiVersion(spec.TransportAPIVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	apiConfigSource := &envoy_core.ApiConfigSource{
+		TransportApiVersion:       transportVersion,
+		SetNodeOnFirstMessageOnly: spec.SetNodeOnFirstMessageOnly,
+	}
+	mode := xdsMode(e)
+	switch mode {
+	case api.XDSModeADS, api.XDSModeGRPC:
+		apiConfigSource.ApiType = envoy_core.ApiConfigSource_GRPC
+	case api.XDSModeDeltaADS, api.XDSModeDeltaGRPC:
+		apiConfigSource.ApiType = envoy_core.ApiConfigSource_DELTA_GRPC
+	case api.XDSModeREST:
+		apiConfigSource.ApiType = envoy_core.ApiConfigSource_REST
+	default:
+		return nil, fmt.Errorf("unknown xds mode %q", mode)
+	}
+	if mode == api.XDSModeREST {
+		if spec.SetNodeOnFirstMessageOnly {
+			return nil, fmt.Errorf("setNodeOnFirstMessageOnly needs a grpc xds mode")
+		}
+		if grpcClientSpec(e) != nil {
+			return nil, fmt.Errorf("the control plane grpc client needs a grpc xds mode")
+		}
+		apiConfigSource.ClusterNames = []string{cluster}
+		apiConfigSource.RefreshDelay = durationOr(spec.RefreshDelay, 10*time.Second)
+		if spec.RequestTimeout != nil {
+			apiConfigSource.RequestTimeout = ptypes.DurationProto(spec.RequestTimeout.Duration)
+		}
+	} else {
+		if spec.RefreshDelay != nil || spec.RequestTimeout != nil {
+			return nil, fmt.Errorf("refreshDelay and requestTimeout only apply to the REST xds mode")
+		}
+		apiConfigSource.GrpcServices = []*envoy_core.GrpcService{grpcService}
+	}
+
+	if mode == api.XDSModeADS || mode == api.XDSModeDeltaADS {
+		ads := func() *envoy_core.ConfigSource {
+			return &envoy_core.ConfigSource{
+				ConfigSourceSpecifier: &envoy_core.ConfigSource_Ads{
+					Ads: &envoy_core.AggregatedConfigSource{},
+				},
+				ResourceApiVersion: resourceVersion,
+			}
+		}
+		return &envoy_config_bootstrap.Bootstrap_DynamicResources{
+			AdsConfig: apiConfigSource,
+			CdsConfig: ads(),
+			LdsConfig: ads(),
+		}, nil
+	}
+
+	// each resource type has its own stream, or is polled on its own
+	source := func() *envoy_core.ConfigSource {
+		return &envoy_core.ConfigSource{
+			ConfigSourceSpecifier: &envoy_core.ConfigSource_ApiConfigSource{
+				ApiConfigSource: proto.Clone(apiConfigSource).(*envoy_core.ApiConfigSource),
+			},
+			ResourceApiVersion: resourceVersion,
+		}
+	}
+	return &envoy_config_bootstrap.Bootstrap_DynamicResources{
+		CdsConfig: source(),
+		LdsConfig: source(),
+	}, nil
+}
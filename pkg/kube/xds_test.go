@@ -0,0 +1,100 @@
+package kube_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	envoy_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+	. "github.com/solo-io/envoy-operator/pkg/kube"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("xDS", func() {
+	var e *api.Envoy
+
+	BeforeEach(func() {
+		e = testEnvoy()
+	})
+
+	It("should use ads by default", func() {
+		b := generate(e, nil)
+		ads := b.DynamicResources.AdsConfig
+		Expect(ads.ApiType).To(Equal(envoy_core.ApiConfigSource_GRPC))
+		Expect(ads.GrpcServices[0].GetEnvoyGrpc().ClusterName).To(Equal("ads-control-plane"))
+		Expect(b.DynamicResources.CdsConfig.GetAds()).NotTo(BeNil())
+		Expect(b.DynamicResources.LdsConfig.GetAds()).NotTo(BeNil())
+	})
+
+	It("should use delta ads", func() {
+		e.Spec.XDS = &api.XDSSpec{Mode: api.XDSModeDeltaADS}
+		b := generate(e, nil)
+		Expect(b.DynamicResources.AdsConfig.ApiType).To(Equal(envoy_core.ApiConfigSource_DELTA_GRPC))
+		Expect(b.DynamicResources.CdsConfig.GetAds()).NotTo(BeNil())
+	})
+
+	It("should use a grpc stream per resource type", func() {
+		e.Spec.XDS = &api.XDSSpec{
+			Mode:                      api.XDSModeDeltaGRPC,
+			ResourceAPIVersion:        api.APIVersionV3,
+			TransportAPIVersion:       api.APIVersionV3,
+			SetNodeOnFirstMessageOnly: true,
+		}
+		b := generate(e, nil)
+		Expect(b.DynamicResources.AdsConfig).To(BeNil())
+		for _, source := range []*envoy_core.ConfigSource{b.DynamicResources.CdsConfig, b.DynamicResources.LdsConfig} {
+			Expect(source.ResourceApiVersion).To(Equal(envoy_core.ApiVersion_V3))
+			apiSource := source.GetApiConfigSource()
+			Expect(apiSource.ApiType).To(Equal(envoy_core.ApiConfigSource_DELTA_GRPC))
+			Expect(apiSource.TransportApiVersion).To(Equal(envoy_core.ApiVersion_V3))
+			Expect(apiSource.SetNodeOnFirstMessageOnly).To(BeTrue())
+			Expect(apiSource.GrpcServices[0].GetEnvoyGrpc().ClusterName).To(Equal("ads-control-plane"))
+		}
+	})
+
+	It("should poll REST endpoints", func() {
+		e.Spec.XDS = &api.XDSSpec{
+			Mode:           api.XDSModeREST,
+			RequestTimeout: &metav1.Duration{Duration: 3 * time.Second},
+		}
+		b := generate(e, nil)
+		apiSource := b.DynamicResources.LdsConfig.GetApiConfigSource()
+		Expect(apiSource.ApiType).To(Equal(envoy_core.ApiConfigSource_REST))
+		Expect(apiSource.ClusterNames).To(Equal([]string{"ads-control-plane"}))
+		Expect(apiSource.RefreshDelay.Seconds).To(BeEquivalentTo(10))
+		Expect(apiSource.RequestTimeout.Seconds).To(BeEquivalentTo(3))
+		Expect(apiSource.GrpcServices).To(BeEmpty())
+		Expect(clusterNamed(b, "ads-control-plane").Http2ProtocolOptions).To(BeNil())
+	})
+
+	It("should reject grpc settings with REST", func() {
+		e.Spec.XDS = &api.XDSSpec{Mode: api.XDSModeREST, SetNodeOnFirstMessageOnly: true}
+		_, err := GenerateEnvoyConfig(e, nil)
+		Expect(err).To(HaveOccurred())
+
+		e.Spec.XDS = &api.XDSSpec{Mode: api.XDSModeREST}
+		e.Spec.ControlPlane = &api.ControlPlaneSpec{HealthCheck: &api.HealthCheckSpec{}}
+		_, err = GenerateEnvoyConfig(e, nil)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject REST settings with grpc", func() {
+		e.Spec.XDS = &api.XDSSpec{RefreshDelay: &metav1.Duration{Duration: time.Second}}
+		_, err := GenerateEnvoyConfig(e, nil)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject unknown modes and versions", func() {
+		e.Spec.XDS = &api.XDSSpec{Mode: "SOAP"}
+		_, err := GenerateEnvoyConfig(e, nil)
+		Expect(err).To(MatchError(ContainSubstring("unknown xds mode")))
+
+		e.Spec.XDS = &api.XDSSpec{ResourceAPIVersion: "V4"}
+		_, err = GenerateEnvoyConfig(e, nil)
+		Expect(err).To(MatchError(ContainSubstring("unknown api version")))
+	})
+})
@@ -0,0 +1,68 @@
+// Package metrics exposes the operator's own metrics in the prometheus text format.
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+var (
+	// Reconciles counts the reconciles of envoys, by result
+	Reconciles = NewCounterVec("envoy_operator_reconciles_total", "Reconciles of envoys.", "result")
+	// Injections counts the pods the webhook injected an envoy into
+	Injections = NewCounterVec("envoy_operator_injections_total", "Pods the sidecar injection webhook injected.", "envoy")
+
+	registry = []*CounterVec{Reconciles, Injections}
+)
+
+// CounterVec is a counter with one label
+type CounterVec struct {
+	name, help, label string
+
+	lock   sync.Mutex
+	values map[string]uint64
+}
+
+func NewCounterVec(name, help, label string) *CounterVec {
+	return &CounterVec{name: name, help: help, label: label, values: map[string]uint64{}}
+}
+
+// Inc increments the counter with the label value
+func (c *CounterVec) Inc(value string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.values[value]++
+}
+
+// Get returns the counter with the label value
+func (c *CounterVec) Get(value string) uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.values[value]
+}
+
+func (c *CounterVec) write(w http.ResponseWriter) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	var values []string
+	for v := range c.values {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	for _, v := range values {
+		fmt.Fprintf(w, "%s{%s=%q} %d\n", c.name, c.label, v, c.values[v])
+	}
+}
+
+// Handler serves the metrics
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		for _, c := range registry {
+			c.write(w)
+		}
+	})
+}
@@ -0,0 +1,26 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/solo-io/envoy-operator/pkg/metrics"
+)
+
+var _ = Describe("Metrics", func() {
+	It("should serve the counters in the prometheus text format", func() {
+		Reconciles.Inc("success")
+		Reconciles.Inc("success")
+		Reconciles.Inc("error")
+
+		rec := httptest.NewRecorder()
+		Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(ContainSubstring("# TYPE envoy_operator_reconciles_total counter\n"))
+		Expect(rec.Body.String()).To(ContainSubstring(`envoy_operator_reconciles_total{result="error"} 1` + "\n" +
+			`envoy_operator_reconciles_total{result="success"} 2` + "\n"))
+	})
+})
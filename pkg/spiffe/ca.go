@@ -0,0 +1,47 @@
+package spiffe
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// the longest certificate chain we read from a CA endpoint
+const maxChainSize = 1 << 20
+
+// CASigner has the certificates signed by a CA endpoint, which is posted the PEM certificate
+// requests and returns the PEM certificate chains
+type CASigner struct {
+	Endpoint string
+	Client   *http.Client
+	// Sent as a bearer token if set, for the CA to authenticate the pod, e.g. with a token review
+	Token string
+}
+
+// Sign posts the certificate request to the CA endpoint
+func (s *CASigner) Sign(csr []byte) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodPost, s.Endpoint, bytes.NewReader(csr))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/pkcs10")
+	req.Header.Set("Accept", "application/x-pem-file")
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach the ca endpoint: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxChainSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the certificate from the ca endpoint: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("the ca endpoint returned %s: %s", resp.Status, body)
+	}
+	return body, nil
+}
@@ -0,0 +1,121 @@
+package spiffe
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+// certificateSigningRequest holds the fields we use of certificates.k8s.io/v1
+// CertificateSigningRequests; our client-go predates their signerName.
+type certificateSigningRequest struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name         string `json:"name,omitempty"`
+		GenerateName string `json:"generateName,omitempty"`
+	} `json:"metadata"`
+	Spec struct {
+		Request           []byte   `json:"request"`
+		SignerName        string   `json:"signerName"`
+		Usages            []string `json:"usages"`
+		ExpirationSeconds *int32   `json:"expirationSeconds,omitempty"`
+	} `json:"spec"`
+	Status struct {
+		Certificate []byte `json:"certificate,omitempty"`
+		Conditions  []struct {
+			Type    string `json:"type"`
+			Reason  string `json:"reason,omitempty"`
+			Message string `json:"message,omitempty"`
+		} `json:"conditions,omitempty"`
+	} `json:"status,omitempty"`
+}
+
+// CSRSigner has the certificates signed through the kubernetes CSR API
+type CSRSigner struct {
+	Client     rest.Interface
+	SignerName string
+	// The prefix of the names of the CertificateSigningRequests
+	GenerateName string
+	// The requested lifetime of the certificates, or 0 for the signer's default
+	Duration time.Duration
+	// How long to wait for the certificate to be approved and issued
+	Timeout      time.Duration
+	PollInterval time.Duration
+}
+
+// NewCSRClient returns a client of the certificates.k8s.io/v1 api
+func NewCSRClient(cfg *rest.Config) (*rest.RESTClient, error) {
+	cfg = rest.CopyConfig(cfg)
+	cfg.ContentConfig = dynamic.ContentConfig()
+	cfg.GroupVersion = &schema.GroupVersion{Group: "certificates.k8s.io", Version: "v1"}
+	cfg.APIPath = "/apis"
+	return rest.RESTClientFor(cfg)
+}
+
+// Sign creates a CertificateSigningRequest, and waits for its certificate
+func (s *CSRSigner) Sign(csr []byte) ([]byte, error) {
+	req := &certificateSigningRequest{
+		APIVersion: "certificates.k8s.io/v1",
+		Kind:       "CertificateSigningRequest",
+	}
+	req.Metadata.GenerateName = s.GenerateName
+	req.Spec.Request = csr
+	req.Spec.SignerName = s.SignerName
+	req.Spec.Usages = []string{"digital signature", "key encipherment", "client auth"}
+	if s.Duration != 0 {
+		seconds := int32(s.Duration.Seconds())
+		req.Spec.ExpirationSeconds = &seconds
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := s.Client.Post().Resource("certificatesigningrequests").Body(body).DoRaw()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the certificate signing request: %v: %s", err, raw)
+	}
+	created := &certificateSigningRequest{}
+	if err := json.Unmarshal(raw, created); err != nil {
+		return nil, err
+	}
+
+	timeout := time.After(s.Timeout)
+	for {
+		cert, err := s.certificate(created.Metadata.Name)
+		if cert != nil || err != nil {
+			return cert, err
+		}
+		select {
+		case <-timeout:
+			return nil, fmt.Errorf("the certificate signing request %s wasn't approved and issued after %v", created.Metadata.Name, s.Timeout)
+		case <-time.After(s.PollInterval):
+		}
+	}
+}
+
+// certificate returns the certificate of the CertificateSigningRequest, or nil if it isn't
+// issued yet
+func (s *CSRSigner) certificate(name string) ([]byte, error) {
+	raw, err := s.Client.Get().Resource("certificatesigningrequests").Name(name).DoRaw()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the certificate signing request %s: %v", name, err)
+	}
+	req := &certificateSigningRequest{}
+	if err := json.Unmarshal(raw, req); err != nil {
+		return nil, err
+	}
+	for _, c := range req.Status.Conditions {
+		if c.Type == "Denied" || c.Type == "Failed" {
+			return nil, fmt.Errorf("the certificate signing request %s was %s: %s %s", name, c.Type, c.Reason, c.Message)
+		}
+	}
+	if len(req.Status.Certificate) == 0 {
+		return nil, nil
+	}
+	return req.Status.Certificate, nil
+}
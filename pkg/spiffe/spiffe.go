@@ -0,0 +1,119 @@
+// Package spiffe gets each envoy pod its own client certificate, for a SPIFFE ID built from the
+// pod's namespace, service account and name.
+package spiffe
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+const (
+	DefaultTrustDomain = "cluster.local"
+
+	// The files the certificate chain and key are written to
+	CertFile = "tls.crt"
+	KeyFile  = "tls.key"
+)
+
+// Signer issues the certificate of a PEM certificate request, and returns the PEM certificate
+// chain
+type Signer interface {
+	Sign(csr []byte) ([]byte, error)
+}
+
+// ID returns the SPIFFE ID of the pod
+func ID(trustDomain, namespace, serviceAccount, pod string) (*url.URL, error) {
+	if trustDomain == "" {
+		trustDomain = DefaultTrustDomain
+	}
+	if namespace == "" || serviceAccount == "" || pod == "" {
+		return nil, fmt.Errorf("the spiffe id needs the namespace, service account and name of the pod")
+	}
+	return &url.URL{
+		Scheme: "spiffe",
+		Host:   trustDomain,
+		Path:   path.Join("/ns", namespace, "sa", serviceAccount, "pod", pod),
+	}, nil
+}
+
+// Request generates a key, and has the signer issue a certificate for the id. It returns the
+// PEM certificate chain and key.
+func Request(signer Signer, id *url.URL) (cert, key []byte, err error) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{URIs: []*url.URL{id}}, priv)
+	if err != nil {
+		return nil, nil, err
+	}
+	csr := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+
+	cert, err = signer.Sign(csr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := verify(cert, priv.Public(), id); err != nil {
+		return nil, nil, err
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), nil
+}
+
+// verify checks that the first certificate of the chain is the one of the key, for the id
+func verify(chain []byte, pub crypto.PublicKey, id *url.URL) error {
+	block, _ := pem.Decode(chain)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return fmt.Errorf("the signer didn't return a PEM certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("the signer returned an invalid certificate: %v", err)
+	}
+	want, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return err
+	}
+	got, err := x509.MarshalPKIXPublicKey(cert.PublicKey)
+	if err != nil || !bytes.Equal(got, want) {
+		return fmt.Errorf("the signer returned the certificate of another key")
+	}
+	for _, uri := range cert.URIs {
+		if uri.String() == id.String() {
+			return nil
+		}
+	}
+	return fmt.Errorf("the certificate the signer returned isn't for %s", id)
+}
+
+// Write writes the certificate chain and key to dir. The key is only readable by the user
+// writing it, which has to be the one envoy runs as.
+func Write(dir string, cert, key []byte) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	// a key left by an earlier run keeps its mode when overwritten
+	keyFile := filepath.Join(dir, KeyFile)
+	if err := os.Remove(keyFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := ioutil.WriteFile(keyFile, key, 0600); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, CertFile), cert, 0644)
+}
@@ -0,0 +1,285 @@
+package spiffe_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/solo-io/envoy-operator/pkg/spiffe"
+
+	"k8s.io/client-go/rest"
+)
+
+// testCA signs certificate requests with a self signed ca
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA() *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	Expect(err).NotTo(HaveOccurred())
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).NotTo(HaveOccurred())
+	return &testCA{cert: cert, key: key}
+}
+
+// sign returns the PEM certificate of the csr, for the uris of the csr unless uris are given
+func (ca *testCA) sign(csrPEM []byte, uris ...*url.URL) []byte {
+	block, _ := pem.Decode(csrPEM)
+	Expect(block).NotTo(BeNil())
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	Expect(err).NotTo(HaveOccurred())
+	if uris == nil {
+		uris = csr.URIs
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		URIs:         uris,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, csr.PublicKey, ca.key)
+	Expect(err).NotTo(HaveOccurred())
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+var _ = Describe("Spiffe", func() {
+	var (
+		ca *testCA
+		id *url.URL
+	)
+
+	BeforeEach(func() {
+		ca = newTestCA()
+		var err error
+		id, err = ID("", "default", "envoy", "envoy-1")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should build the id of the pod", func() {
+		Expect(id.String()).To(Equal("spiffe://cluster.local/ns/default/sa/envoy/pod/envoy-1"))
+		id, err := ID("example.org", "default", "envoy", "envoy-1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(id.Host).To(Equal("example.org"))
+		_, err = ID("", "default", "", "envoy-1")
+		Expect(err).To(HaveOccurred())
+	})
+
+	Context("with a ca endpoint", func() {
+		var (
+			server   *httptest.Server
+			response func(csr []byte) []byte
+			token    string
+		)
+
+		BeforeEach(func() {
+			response = func(csr []byte) []byte { return ca.sign(csr) }
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				Expect(r.Method).To(Equal(http.MethodPost))
+				Expect(r.Header.Get("Content-Type")).To(Equal("application/pkcs10"))
+				token = r.Header.Get("Authorization")
+				csr, err := ioutil.ReadAll(r.Body)
+				Expect(err).NotTo(HaveOccurred())
+				w.Write(response(csr))
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should get a certificate for the key it generates", func() {
+			signer := &CASigner{Endpoint: server.URL, Client: server.Client(), Token: "secret"}
+			cert, key, err := Request(signer, id)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token).To(Equal("Bearer secret"))
+			_, err = tls.X509KeyPair(cert, key)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should reject certificates for another id", func() {
+			other, _ := url.Parse("spiffe://cluster.local/ns/default/sa/admin")
+			response = func(csr []byte) []byte { return ca.sign(csr, other) }
+			signer := &CASigner{Endpoint: server.URL, Client: server.Client()}
+			_, _, err := Request(signer, id)
+			Expect(err).To(MatchError(ContainSubstring("isn't for")))
+		})
+
+		It("should reject certificates for another key", func() {
+			csr := &x509.CertificateRequest{URIs: []*url.URL{id}}
+			key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			der, _ := x509.CreateCertificateRequest(rand.Reader, csr, key)
+			otherCSR := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+			response = func([]byte) []byte { return ca.sign(otherCSR) }
+			signer := &CASigner{Endpoint: server.URL, Client: server.Client()}
+			_, _, err := Request(signer, id)
+			Expect(err).To(MatchError(ContainSubstring("another key")))
+		})
+	})
+
+	Context("with the CSR API", func() {
+		var (
+			server   *httptest.Server
+			created  map[string]interface{}
+			gets     int
+			denied   bool
+			csrBytes []byte
+		)
+
+		BeforeEach(func() {
+			gets, denied = 0, false
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				status := map[string]interface{}{}
+				switch {
+				case r.Method == http.MethodPost && r.URL.Path == "/apis/certificates.k8s.io/v1/certificatesigningrequests":
+					Expect(json.NewDecoder(r.Body).Decode(&created)).To(Succeed())
+					spec := created["spec"].(map[string]interface{})
+					csrBytes, _ = base64.StdEncoding.DecodeString(spec["request"].(string))
+				case r.Method == http.MethodGet && r.URL.Path == "/apis/certificates.k8s.io/v1/certificatesigningrequests/envoy-default-envoy-1-abcde":
+					gets++
+					if denied {
+						status["conditions"] = []interface{}{map[string]interface{}{"type": "Denied", "reason": "NotAllowed"}}
+					} else if gets > 1 {
+						status["certificate"] = ca.sign(csrBytes)
+					}
+				default:
+					Fail("unexpected request " + r.Method + " " + r.URL.Path)
+				}
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"apiVersion": "certificates.k8s.io/v1",
+					"kind":       "CertificateSigningRequest",
+					"metadata":   map[string]interface{}{"name": "envoy-default-envoy-1-abcde"},
+					"status":     status,
+				})
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		signer := func() *CSRSigner {
+			client, err := NewCSRClient(&rest.Config{Host: server.URL})
+			Expect(err).NotTo(HaveOccurred())
+			return &CSRSigner{
+				Client:       client,
+				SignerName:   "example.org/spiffe",
+				GenerateName: "envoy-default-envoy-1-",
+				Duration:     24 * time.Hour,
+				Timeout:      5 * time.Second,
+				PollInterval: 10 * time.Millisecond,
+			}
+		}
+
+		It("should wait for the certificate to be issued", func() {
+			cert, key, err := Request(signer(), id)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = tls.X509KeyPair(cert, key)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(gets).To(Equal(2))
+
+			Expect(created["metadata"]).To(HaveKeyWithValue("generateName", "envoy-default-envoy-1-"))
+			spec := created["spec"].(map[string]interface{})
+			Expect(spec).To(HaveKeyWithValue("signerName", "example.org/spiffe"))
+			Expect(spec).To(HaveKeyWithValue("expirationSeconds", BeEquivalentTo(86400)))
+			Expect(spec["usages"]).To(ContainElement("client auth"))
+		})
+
+		It("should fail when the request is denied", func() {
+			denied = true
+			_, _, err := Request(signer(), id)
+			Expect(err).To(MatchError(ContainSubstring("Denied")))
+		})
+	})
+
+	It("should write the certificate and key", func() {
+		dir, err := ioutil.TempDir("", "spiffe")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		Expect(Write(filepath.Join(dir, "identity"), []byte("cert"), []byte("key"))).To(Succeed())
+		cert, err := ioutil.ReadFile(filepath.Join(dir, "identity", "tls.crt"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(cert)).To(Equal("cert"))
+		key, err := ioutil.ReadFile(filepath.Join(dir, "identity", "tls.key"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(key)).To(Equal("key"))
+	})
+
+	It("should only let its user read the key", func() {
+		dir, err := ioutil.TempDir("", "spiffe")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		Expect(ioutil.WriteFile(filepath.Join(dir, "tls.key"), []byte("old"), 0644)).To(Succeed())
+		Expect(Write(dir, []byte("cert"), []byte("key"))).To(Succeed())
+		info, err := os.Stat(filepath.Join(dir, "tls.key"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+	})
+
+	It("should request a token for the ca endpoint only", func() {
+		var created map[string]interface{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			Expect(r.Method).To(Equal(http.MethodPost))
+			Expect(r.URL.Path).To(Equal("/api/v1/namespaces/default/serviceaccounts/envoy/token"))
+			Expect(json.NewDecoder(r.Body).Decode(&created)).To(Succeed())
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"apiVersion": "authentication.k8s.io/v1",
+				"kind":       "TokenRequest",
+				"status":     map[string]interface{}{"token": "bound"},
+			})
+		}))
+		defer server.Close()
+		client, err := NewCoreClient(&rest.Config{Host: server.URL})
+		Expect(err).NotTo(HaveOccurred())
+		requester := &TokenRequester{
+			Client:         client,
+			Namespace:      "default",
+			ServiceAccount: "envoy",
+			Pod:            "envoy-1",
+			PodUID:         "1234",
+			Expiration:     10 * time.Minute,
+		}
+
+		token, err := requester.Token("https://ca.example.org/sign")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).To(Equal("bound"))
+		spec := created["spec"].(map[string]interface{})
+		Expect(spec["audiences"]).To(ConsistOf("https://ca.example.org/sign"))
+		Expect(spec["expirationSeconds"]).To(BeEquivalentTo(600))
+		Expect(spec["boundObjectRef"]).To(HaveKeyWithValue("name", "envoy-1"))
+		Expect(spec["boundObjectRef"]).To(HaveKeyWithValue("uid", "1234"))
+	})
+})
@@ -0,0 +1,84 @@
+package spiffe
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+// tokenRequest holds the fields we use of authentication.k8s.io/v1 TokenRequests; our client-go
+// predates them, as well as the projected service account tokens that would carry them.
+type tokenRequest struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Spec       struct {
+		Audiences         []string `json:"audiences"`
+		ExpirationSeconds int64    `json:"expirationSeconds"`
+		BoundObjectRef    struct {
+			APIVersion string `json:"apiVersion"`
+			Kind       string `json:"kind"`
+			Name       string `json:"name"`
+			UID        string `json:"uid,omitempty"`
+		} `json:"boundObjectRef"`
+	} `json:"spec"`
+	Status struct {
+		Token string `json:"token"`
+	} `json:"status"`
+}
+
+// TokenRequester gets the pod a token of its service account for a single audience, so that the
+// CA endpoint can't replay it against the api server or other services
+type TokenRequester struct {
+	Client         rest.Interface
+	Namespace      string
+	ServiceAccount string
+	// The token is bound to the pod, and is revoked when the pod is deleted
+	Pod    string
+	PodUID string
+	// The lifetime of the token; the api server requires at least 10 minutes
+	Expiration time.Duration
+}
+
+// NewCoreClient returns a client of the core v1 api
+func NewCoreClient(cfg *rest.Config) (*rest.RESTClient, error) {
+	cfg = rest.CopyConfig(cfg)
+	cfg.ContentConfig = dynamic.ContentConfig()
+	cfg.GroupVersion = &schema.GroupVersion{Version: "v1"}
+	cfg.APIPath = "/api"
+	return rest.RESTClientFor(cfg)
+}
+
+// Token requests a token for the audience
+func (r *TokenRequester) Token(audience string) (string, error) {
+	req := &tokenRequest{
+		APIVersion: "authentication.k8s.io/v1",
+		Kind:       "TokenRequest",
+	}
+	req.Spec.Audiences = []string{audience}
+	req.Spec.ExpirationSeconds = int64(r.Expiration.Seconds())
+	req.Spec.BoundObjectRef.APIVersion = "v1"
+	req.Spec.BoundObjectRef.Kind = "Pod"
+	req.Spec.BoundObjectRef.Name = r.Pod
+	req.Spec.BoundObjectRef.UID = r.PodUID
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	raw, err := r.Client.Post().Namespace(r.Namespace).Resource("serviceaccounts").Name(r.ServiceAccount).
+		SubResource("token").Body(body).DoRaw()
+	if err != nil {
+		return "", fmt.Errorf("failed to request a token for %s: %v: %s", audience, err, raw)
+	}
+	created := &tokenRequest{}
+	if err := json.Unmarshal(raw, created); err != nil {
+		return "", err
+	}
+	if created.Status.Token == "" {
+		return "", fmt.Errorf("the api server returned no token for %s", audience)
+	}
+	return created.Status.Token, nil
+}
@@ -0,0 +1,29 @@
+package stub
+
+import (
+	"github.com/operator-framework/operator-sdk/pkg/k8sclient"
+	"github.com/solo-io/envoy-operator/pkg/envoy"
+
+	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
+
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+// NewClients returns the sdk's clients for the reconciler, and a client of the envoy status sub
+// resource with the same config. The sdk's k8sclient has already looked up the api server for
+// rest.InClusterConfig when it was imported, so this is the config of the sdk's clients.
+func NewClients() (envoy.Clients, error) {
+	cfg, err := rest.InClusterConfig()
+	if err != nil {
+		return envoy.Clients{}, err
+	}
+	cfg.ContentConfig = dynamic.ContentConfig()
+	cfg.GroupVersion = &api.SchemeGroupVersion
+	cfg.APIPath = "/apis"
+	status, err := rest.RESTClientFor(cfg)
+	if err != nil {
+		return envoy.Clients{}, err
+	}
+	return envoy.Clients{Resources: k8sclient.GetResourceClient, Status: status}, nil
+}
@@ -2,6 +2,8 @@ package stub
 
 import (
 	"github.com/solo-io/envoy-operator/pkg/envoy"
+	"github.com/solo-io/envoy-operator/pkg/inject"
+	"github.com/solo-io/envoy-operator/pkg/metrics"
 
 	"github.com/operator-framework/operator-sdk/pkg/sdk/handler"
 	"github.com/operator-framework/operator-sdk/pkg/sdk/types"
@@ -9,23 +11,45 @@ import (
 	api "github.com/solo-io/envoy-operator/pkg/apis/envoy/v1alpha1"
 )
 
-func NewHandler() handler.Handler {
-	return &Handler{}
+func NewHandler(registry *inject.Registry) handler.Handler {
+	return &Handler{registry: registry}
 }
 
 type Handler struct {
-	// Fill me
+	// envoys to inject as sidecars are registered here for the webhook
+	registry *inject.Registry
 }
 
 func (h *Handler) Handle(ctx types.Context, event types.Event) error {
 
-	// deleted things will get GC'ed by kube.
-	if event.Deleted {
-		return nil
-	}
 	switch o := event.Object.(type) {
 	case *api.Envoy:
-		return envoy.Reconcile(o)
+		// deleted things will get GC'ed by kube.
+		if event.Deleted {
+			h.registry.Delete(o)
+			return nil
+		}
+		if err := envoy.Reconcile(o); err != nil {
+			metrics.Reconciles.Inc("error")
+			return err
+		}
+		metrics.Reconciles.Inc("success")
+		return h.syncInjection(o)
+	}
+	return nil
+}
+
+func (h *Handler) syncInjection(o *api.Envoy) error {
+	if o.Spec.Injection == nil {
+		h.registry.Delete(o)
+		return nil
+	}
+	e := o.DeepCopy()
+	e.SetDefaults()
+	bootstrap, err := envoy.RenderBootstrap(e)
+	if err != nil {
+		return err
 	}
+	h.registry.Set(inject.Config{Envoy: e, Bootstrap: bootstrap})
 	return nil
 }